@@ -1,17 +1,69 @@
 package ao3
 
 import (
+	"errors"
 	"net/http"
+	"net/http/cookiejar"
+	"net/url"
+	"strings"
+	"sync"
 	"time"
 )
 
-const baseURL = "https://archiveofourown.org/"
+const defaultBaseURL = "https://archiveofourown.org/"
 const defaultTimeout = 5 * time.Second
 
+const defaultMaxThrottleWait = time.Minute
+
 // AO3Client contains configuration parameters for the package
 type AO3Client struct {
 	HttpClient    *http.Client
 	HtmlSanitizer *Sanitizer
+
+	// RateLimiter, if set, limits the rate of requests across all endpoints.
+	// It may be shared between clients.
+	RateLimiter *RateLimiter
+
+	// MaxThrottleWait is the longest the client waits for AO3's Retry-After
+	// delay when throttled before giving up. Zero waits for any delay.
+	MaxThrottleWait time.Duration
+
+	// RetryPolicy decides whether failed requests are retried. Nil disables
+	// retries.
+	RetryPolicy RetryPolicy
+
+	// Cache, if set, stores responses for the durations in CacheTTLs. See
+	// NewMemoryCache and NewDiskCache.
+	Cache     Cache
+	CacheTTLs CacheTTLs
+
+	// Lenient makes GetWork, GetSeries and GetTagWorks return what they could
+	// parse instead of failing on unexpected markup. Fields which could not be
+	// parsed are left empty and described by the result's Diagnostics.
+	Lenient bool
+
+	// Middleware hooks into every request and parse error. See Use.
+	Middleware []Middleware
+
+	// HideMutedCreators removes the works of users muted by the logged-in user
+	// from the works listed by GetTagWorks and GetSeries. The muted users are
+	// fetched once per session and kept up to date by MuteUser and UnmuteUser.
+	// If they cannot be fetched, lenient clients list every work with a
+	// diagnostic for the field "MutedCreators".
+	HideMutedCreators bool
+
+	// baseURL is the root of the archive being scraped, always ending in "/"
+	baseURL *url.URL
+
+	// username is the user the client is logged in as and csrfToken the
+	// authenticity token of the session, both guarded by sessionMu
+	sessionMu sync.RWMutex
+	username  string
+	csrfToken string
+
+	// mutedUsers are the lowercased names of the users muted by the logged-in
+	// user for HideMutedCreators, nil until fetched, guarded by sessionMu
+	mutedUsers map[string]bool
 }
 
 // InitAO3Client optionally takes in two parameters:
@@ -19,12 +71,19 @@ type AO3Client struct {
 //   use custom clients, for example, with Google Cloud Platform);
 // - sanitizerStrength, the sanitization policy for sanitization of blurbs,e tc.
 //   (NonePolicy performs no sanitization)
+//
+// The given client is copied rather than used directly, and the copy gets a
+// cookie jar of its own unless client already has one, so that AO3's session
+// cookies never leak to other users of client.
+//
+// The client targets https://archiveofourown.org/ until SetBaseURL is called.
+// Requests are not rate limited unless RateLimiter is set, and transient
+// failures are retried according to NewBackoffPolicy. Responses are not cached
+// unless Cache is set.
 func InitAO3Client(client *http.Client, sanitizationPolicy SanitizationPolicy) (*AO3Client, *AO3Error) {
-	if client == nil {
-		client = &http.Client{
-			Timeout: defaultTimeout,
-
-		}
+	httpClient, err := newHTTPClient(client)
+	if err != nil {
+		return nil, WrapError(http.StatusInternalServerError, err, "unable to create cookie jar")
 	}
 
 	sanitizer, err := NewSanitizer(sanitizationPolicy)
@@ -32,14 +91,126 @@ func InitAO3Client(client *http.Client, sanitizationPolicy SanitizationPolicy) (
 		return nil, WrapError(http.StatusNotImplemented, err, "unable to create sanitizer")
 	}
 
+	base, err := parseBaseURL(defaultBaseURL)
+	if err != nil {
+		return nil, WrapError(http.StatusNotImplemented, err, "unable to parse default base URL")
+	}
+
 	return &AO3Client{
-		HttpClient:    client,
-		HtmlSanitizer: sanitizer,
+		HttpClient:      httpClient,
+		HtmlSanitizer:   sanitizer,
+		MaxThrottleWait: defaultMaxThrottleWait,
+		RetryPolicy:     NewBackoffPolicy(),
+		CacheTTLs:       DefaultCacheTTLs(),
+		baseURL:         base,
 	}, nil
 }
 
+// newHTTPClient returns a copy of client, or a client with the default timeout
+// if it is nil, with a new cookie jar unless client has one
+func newHTTPClient(client *http.Client) (*http.Client, error) {
+	httpClient := &http.Client{Timeout: defaultTimeout}
+	if client != nil {
+		*httpClient = *client
+	}
+
+	if httpClient.Jar == nil {
+		jar, err := cookiejar.New(nil)
+		if err != nil {
+			return nil, err
+		}
+		httpClient.Jar = jar
+	}
+
+	return httpClient, nil
+}
+
+// SetBaseURL points the client at another otwarchive deployment, such as a
+// mirror (e.g., https://archiveofourown.gay) or a local fixture server. The URL
+// must be absolute, use http or https and must not contain a query or fragment.
+// A path prefix (e.g., http://localhost:8080/ao3) is preserved.
+func (client *AO3Client) SetBaseURL(rawURL string) *AO3Error {
+	base, err := parseBaseURL(rawURL)
+	if err != nil {
+		return WrapError(http.StatusBadRequest, err, "invalid base URL")
+	}
+
+	client.baseURL = base
+	return nil
+}
+
+// BaseURL returns the root URL which all endpoints are resolved against
+func (client *AO3Client) BaseURL() string {
+	return client.baseURL.String()
+}
+
+// endpointURL joins an endpoint to the base URL. Endpoints may or may not start
+// with a slash (e.g., "/works/1" and "media" are both accepted) and are joined
+// verbatim so that pre-escaped slugs such as "Anime%20*a*%20Manga" survive.
+func (client *AO3Client) endpointURL(endpoint string) string {
+	return client.baseURL.String() + strings.TrimLeft(endpoint, "/")
+}
+
+// relativeLink converts a link found on a page into a path relative to the
+// archive root with a leading slash, e.g. both "/media/Books/fandoms" and
+// "https://mirror.example/media/Books/fandoms" become "/media/Books/fandoms".
+// The path of the base URL is only removed from links which start with it.
+// Links pointing to other hosts are returned unchanged.
+func (client *AO3Client) relativeLink(link string) string {
+	parsed, err := url.Parse(link)
+	if err != nil {
+		return link
+	}
+
+	if parsed.IsAbs() && parsed.Host != client.baseURL.Host {
+		return link
+	}
+
+	relative := parsed.EscapedPath()
+	if basePath := client.baseURL.EscapedPath(); strings.HasPrefix(relative, basePath) {
+		relative = "/" + strings.TrimPrefix(relative, basePath)
+	} else if !strings.HasPrefix(relative, "/") {
+		relative = "/" + relative
+	}
+	if parsed.RawQuery != "" {
+		relative += "?" + parsed.RawQuery
+	}
+
+	return relative
+}
+
+// parseBaseURL validates a base URL, normalising it to end with a slash
+func parseBaseURL(rawURL string) (*url.URL, error) {
+	base, err := url.Parse(rawURL)
+	if err != nil {
+		return nil, err
+	}
+
+	if base.Scheme != "http" && base.Scheme != "https" {
+		return nil, errors.New("base URL scheme must be http or https")
+	}
+	if base.Host == "" {
+		return nil, errors.New("base URL must contain a host")
+	}
+	if base.RawQuery != "" || base.Fragment != "" {
+		return nil, errors.New("base URL must not contain a query or fragment")
+	}
+	if base.User != nil {
+		return nil, errors.New("base URL must not contain credentials")
+	}
+
+	if !strings.HasSuffix(base.Path, "/") {
+		base.Path += "/"
+		if base.RawPath != "" {
+			base.RawPath += "/"
+		}
+	}
+
+	return base, nil
+}
+
 // Link is an internal representation of links parsed from the website
 type Link struct {
 	Text string
 	Slug string
-}
\ No newline at end of file
+}
@@ -1,30 +1,223 @@
 package ao3
 
-import "github.com/pkg/errors"
+import (
+	"context"
+	"net/http"
+	"github.com/pkg/errors"
+)
 
+// StatusRequestCanceled is the code of errors caused by the caller's context
+// being canceled or exceeding its deadline. It mirrors the non-standard 499
+// "Client Closed Request" status code.
+const StatusRequestCanceled = 499
+
+// ErrorKind classifies why a request failed, independently of the HTTP-like
+// code of the error
+type ErrorKind int
+
+const (
+	// KindUnknown is the kind of errors which fit no other kind
+	KindUnknown ErrorKind = iota
+	// KindNotFound is the kind of errors caused by AO3's 404 page
+	KindNotFound
+	// KindRestricted is the kind of errors caused by works only visible to
+	// logged-in users
+	KindRestricted
+	// KindAdultGate is the kind of errors caused by AO3 asking for confirmation
+	// before showing adult content
+	KindAdultGate
+	// KindDeleted is the kind of errors caused by pages which were deleted or
+	// hidden by AO3's administrators
+	KindDeleted
+	// KindMaintenance is the kind of errors caused by AO3 being unavailable or
+	// down for maintenance
+	KindMaintenance
+	// KindThrottled is the kind of errors caused by AO3 or the client's
+	// RateLimiter throttling requests
+	KindThrottled
+	// KindParse is the kind of errors caused by pages which could not be parsed
+	KindParse
+	// KindNetwork is the kind of errors caused by failed connections
+	KindNetwork
+	// KindCanceled is the kind of errors caused by the caller's context being
+	// canceled or exceeding its deadline
+	KindCanceled
+	// KindBadCredentials is the kind of errors caused by logging in with a
+	// wrong user name or password
+	KindBadCredentials
+	// KindAccountLocked is the kind of errors caused by logging in to an
+	// account which AO3 has locked, e.g. after too many failed attempts
+	KindAccountLocked
+	// KindValidation is the kind of errors caused by AO3 rejecting a submitted
+	// form, e.g. a bookmark with notes which are too long. The messages AO3
+	// gave are available from the wrapped ValidationError.
+	KindValidation
+	// KindCommentsDisabled is the kind of errors caused by commenting on a work
+	// whose creator disabled comments
+	KindCommentsDisabled
+	// KindCommentModerated is the kind of errors caused by comments which were
+	// saved but only appear once the creator of the work approves them
+	KindCommentModerated
+)
+
+// Sentinel errors matching AO3Errors of each kind with errors.Is, e.g.
+//
+//     if errors.Is(err, ao3.ErrNotFound) { ... }
+var (
+	ErrNotFound    = errors.New("ao3: not found")
+	ErrRestricted  = errors.New("ao3: restricted to logged-in users")
+	ErrAdultGate   = errors.New("ao3: adult content confirmation required")
+	ErrDeleted     = errors.New("ao3: deleted or hidden")
+	ErrMaintenance = errors.New("ao3: unavailable or down for maintenance")
+	ErrThrottled   = errors.New("ao3: throttled")
+	ErrParse       = errors.New("ao3: unable to parse page")
+	ErrNetwork     = errors.New("ao3: network error")
+	ErrCanceled    = errors.New("ao3: request canceled")
+
+	ErrBadCredentials = errors.New("ao3: wrong user name or password")
+	ErrAccountLocked  = errors.New("ao3: account locked")
+	ErrValidation     = errors.New("ao3: rejected by validation")
+
+	ErrCommentsDisabled = errors.New("ao3: comments disabled")
+	ErrCommentModerated = errors.New("ao3: comment awaiting moderation")
+)
+
+var errorKindSentinels = map[ErrorKind]error{
+	KindNotFound:    ErrNotFound,
+	KindRestricted:  ErrRestricted,
+	KindAdultGate:   ErrAdultGate,
+	KindDeleted:     ErrDeleted,
+	KindMaintenance: ErrMaintenance,
+	KindThrottled:   ErrThrottled,
+	KindParse:       ErrParse,
+	KindNetwork:     ErrNetwork,
+	KindCanceled:    ErrCanceled,
+
+	KindBadCredentials: ErrBadCredentials,
+	KindAccountLocked:  ErrAccountLocked,
+	KindValidation:     ErrValidation,
+
+	KindCommentsDisabled: ErrCommentsDisabled,
+	KindCommentModerated: ErrCommentModerated,
+}
+
+var errorKindNames = map[ErrorKind]string{
+	KindUnknown:     "unknown",
+	KindNotFound:    "not found",
+	KindRestricted:  "restricted",
+	KindAdultGate:   "adult gate",
+	KindDeleted:     "deleted",
+	KindMaintenance: "maintenance",
+	KindThrottled:   "throttled",
+	KindParse:       "parse",
+	KindNetwork:     "network",
+	KindCanceled:    "canceled",
+
+	KindBadCredentials: "bad credentials",
+	KindAccountLocked:  "account locked",
+	KindValidation:     "validation",
+
+	KindCommentsDisabled: "comments disabled",
+	KindCommentModerated: "comment moderated",
+}
+
+func (kind ErrorKind) String() string {
+	if name, ok := errorKindNames[kind]; ok {
+		return name
+	}
+	return "unknown"
+}
+
+// AO3Error is the error returned by the client. Code is an HTTP status code
+// describing the failure and Kind classifies it.
+//
+// AO3Errors support the standard errors package: errors.Is matches the
+// sentinel of the error's kind, e.g. ErrNotFound, and errors.Is and errors.As
+// see through to the underlying error, e.g. context.Canceled or a net.Error.
 type AO3Error struct {
 	code int
+	kind ErrorKind
 	err  error
 }
 
+// NewError creates an error whose kind is derived from its code
 func NewError(code int, message string) *AO3Error {
 	return &AO3Error{
 		code: code,
+		kind: kindFromCode(code),
 		err:  errors.New(message),
 	}
 }
 
+// WrapError creates an error wrapping err whose kind is derived from its code
 func WrapError(code int, err error, message string) *AO3Error {
 	return &AO3Error{
 		code: code,
+		kind: kindFromCode(code),
 		err:  errors.Wrap(err, message),
 	}
 }
 
+// withKind overrides the kind derived from the error's code
+func (e *AO3Error) withKind(kind ErrorKind) *AO3Error {
+	e.kind = kind
+	return e
+}
+
 func (e *AO3Error) Code() int {
 	return e.code
 }
 
+// Kind classifies the error
+func (e *AO3Error) Kind() ErrorKind {
+	return e.kind
+}
+
 func (e *AO3Error) Error() string {
 	return e.err.Error()
 }
+
+// Unwrap returns the error wrapped by the AO3Error
+func (e *AO3Error) Unwrap() error {
+	return e.err
+}
+
+// Is reports whether target is the sentinel of the error's kind
+func (e *AO3Error) Is(target error) bool {
+	sentinel, ok := errorKindSentinels[e.kind]
+	return ok && target == sentinel
+}
+
+// IsCanceled reports whether the request was abandoned because its context was
+// canceled or its deadline was exceeded
+func (e *AO3Error) IsCanceled() bool {
+	cause := errors.Cause(e.err)
+	return e.kind == KindCanceled && (cause == context.Canceled || cause == context.DeadlineExceeded)
+}
+
+// IsThrottled reports whether the request was given up on because AO3 or the
+// client's RateLimiter would not allow it before the caller's deadline
+func (e *AO3Error) IsThrottled() bool {
+	return e.kind == KindThrottled
+}
+
+// kindFromCode returns the kind of errors with the given code unless they are
+// classified otherwise
+func kindFromCode(code int) ErrorKind {
+	switch {
+	case code == http.StatusNotFound:
+		return KindNotFound
+	case code == http.StatusGone:
+		return KindDeleted
+	case code == http.StatusTooManyRequests:
+		return KindThrottled
+	case code == http.StatusUnprocessableEntity:
+		return KindParse
+	case code == StatusRequestCanceled:
+		return KindCanceled
+	case isMaintenanceStatusCode(code):
+		return KindMaintenance
+	}
+
+	return KindUnknown
+}
@@ -0,0 +1,59 @@
+package ao3
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"testing"
+	"github.com/stretchr/testify/assert"
+)
+
+// TestErrorKindFromCode ensures errors created from status codes are
+// classified without callers specifying a kind
+func TestErrorKindFromCode(t *testing.T) {
+	tests := []struct {
+		code     int
+		kind     ErrorKind
+		sentinel error
+	}{
+		{http.StatusNotFound, KindNotFound, ErrNotFound},
+		{http.StatusGone, KindDeleted, ErrDeleted},
+		{http.StatusTooManyRequests, KindThrottled, ErrThrottled},
+		{http.StatusUnprocessableEntity, KindParse, ErrParse},
+		{StatusRequestCanceled, KindCanceled, ErrCanceled},
+		{http.StatusServiceUnavailable, KindMaintenance, ErrMaintenance},
+		{StatusCloudflareSSLHandshakeFailed, KindMaintenance, ErrMaintenance},
+		{http.StatusBadRequest, KindUnknown, nil},
+	}
+
+	for _, test := range tests {
+		err := NewError(test.code, "failed")
+
+		assert.Equal(t, test.kind, err.Kind(), test.kind.String())
+		if test.sentinel != nil {
+			assert.True(t, errors.Is(err, test.sentinel), test.kind.String())
+		}
+		assert.False(t, errors.Is(err, ErrRestricted), test.kind.String())
+	}
+}
+
+// TestErrorUnwrap ensures the standard errors package sees through AO3Errors
+// to the errors they wrap
+func TestErrorUnwrap(t *testing.T) {
+	err := WrapError(StatusRequestCanceled, context.Canceled, "fetching work was canceled")
+
+	assert.True(t, errors.Is(err, context.Canceled))
+	assert.True(t, errors.Is(err, ErrCanceled))
+	assert.False(t, errors.Is(err, context.DeadlineExceeded))
+	assert.True(t, err.IsCanceled())
+
+	var wrapped error = WrapError(http.StatusServiceUnavailable, errors.New("connection refused"), "fetching work returned an err").withKind(KindNetwork)
+
+	var ao3Err *AO3Error
+	if assert.True(t, errors.As(wrapped, &ao3Err)) {
+		assert.Equal(t, KindNetwork, ao3Err.Kind())
+		assert.Equal(t, http.StatusServiceUnavailable, ao3Err.Code())
+	}
+	assert.True(t, errors.Is(wrapped, ErrNetwork))
+	assert.False(t, errors.Is(wrapped, ErrMaintenance))
+}
@@ -0,0 +1,688 @@
+package ao3test
+
+import (
+	"fmt"
+	"html"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+	"github.com/kz/ao3-go"
+)
+
+// completeChaptersRegex matches chapter counts of complete works, e.g. "3/3"
+var completeChaptersRegex = regexp.MustCompile(`^(\d+)/(\d+)$`)
+
+// renderPage wraps the main content of a page in AO3's layout
+func renderPage(title string, mainClass string, main string) string {
+	return `<!DOCTYPE html>
+<html lang="en">
+  <head>
+    <meta http-equiv="Content-Type" content="text/html; charset=utf-8" />
+    <meta name="csrf-param" content="authenticity_token" />
+    <meta name="csrf-token" content="ao3test" />
+    <title>` + html.EscapeString(title) + ` | Archive of Our Own</title>
+  </head>
+  <body class="logged-out">
+    <div id="outer" class="wrapper">
+      <div id="header" class="region">
+        <h1 class="heading"><a href="/"><span>Archive of Our Own</span><sup> beta</sup></a></h1>
+        <div id="greeting">
+          <ul class="user navigation actions" role="navigation">
+            <li id="login-dropdown" class="dropdown"><a class="dropdown-toggle" href="/users/login">Log In</a></li>
+          </ul>
+        </div>
+      </div>
+      <div id="inner" class="wrapper">
+        <div id="main" class="` + mainClass + ` region" role="main">
+` + main + `
+        </div>
+      </div>
+      <div id="footer" role="contentinfo" class="region">
+        <h3 class="landmark heading">Footer</h3>
+      </div>
+    </div>
+  </body>
+</html>
+`
+}
+
+func renderNotFound() string {
+	return renderPage("Error 404", "error-404", `
+          <h2 class="heading">Error 404</h2>
+          <h3 class="heading">The page you were looking for doesn't exist.</h3>
+          <p>You may have mistyped the address or the page may have moved.</p>`)
+}
+
+func renderFandomCategories(categories []ao3.FandomCategory) string {
+	var main strings.Builder
+	main.WriteString(`
+          <h2 class="heading">Fandoms on the Archive</h2>
+          <ul class="media fandom index group">`)
+
+	for _, category := range categories {
+		fmt.Fprintf(&main, `
+            <li class="medium listbox group">
+              <h3 class="heading"><a href="/media/%s/fandoms">%s</a></h3>
+              <p class="actions"><a href="/media/%s/fandoms">All %s...</a></p>
+            </li>`, escapeAttribute(category.Slug), html.EscapeString(category.Name), escapeAttribute(category.Slug), html.EscapeString(category.Name))
+	}
+
+	main.WriteString(`
+          </ul>`)
+
+	return renderPage("Fandoms", "media-index", main.String())
+}
+
+func renderFandomCategory(category ao3.FandomCategory, fandoms []ao3.Fandom) string {
+	var main strings.Builder
+	fmt.Fprintf(&main, `
+          <h2 class="heading">%s Fandoms</h2>
+          <ol class="alphabet fandom index group">`, html.EscapeString(category.Name))
+
+	fandoms = sortedFandoms(fandoms)
+	for i, fandom := range fandoms {
+		if i == 0 || fandoms[i-1].Letter != fandom.Letter {
+			if i != 0 {
+				main.WriteString(`
+              </ul>
+            </li>`)
+			}
+
+			fmt.Fprintf(&main, `
+            <li class="letter listbox group" id="letter-%s">
+              <h3 class="heading">
+                %s
+                <span class="action"><a href="#main">&#8593;</a></span>
+              </h3>
+              <ul class="tags index group">`, escapeAttribute(fandom.Letter), html.EscapeString(fandom.Letter))
+		}
+
+		fmt.Fprintf(&main, `
+                <li>
+                  <a class="tag" href="/tags/%s/works">%s</a>
+                  (%d)
+                </li>`, escapeAttribute(fandom.Slug), html.EscapeString(fandom.Name), fandom.Count)
+	}
+
+	if len(fandoms) > 0 {
+		main.WriteString(`
+              </ul>
+            </li>`)
+	}
+
+	main.WriteString(`
+          </ol>`)
+
+	return renderPage(category.Name, "media-show", main.String())
+}
+
+func renderTagWorks(tag ao3.Link, works []ao3.IndexedWork, total int, start int, page int, lastPage int) string {
+	base := "/tags/" + escapeAttribute(tag.Slug) + "/works"
+
+	heading := fmt.Sprintf("%s Works in", formatCount(total))
+	if lastPage > 1 {
+		heading = fmt.Sprintf("%d - %d of %s", start+1, start+len(works), heading)
+	}
+
+	var main strings.Builder
+	fmt.Fprintf(&main, `
+          <h2 class="heading">
+            %s <a class="tag" href="%s">%s</a>
+          </h2>`, heading, base, html.EscapeString(tag.Text))
+
+	if lastPage > 1 {
+		main.WriteString(renderPagination(base, page, lastPage))
+	}
+
+	main.WriteString(`
+          <h3 class="landmark heading">Listing Works</h3>
+          <ol class="work index group">`)
+	for _, work := range works {
+		main.WriteString(renderBlurb(work))
+	}
+	main.WriteString(`
+          </ol>`)
+
+	if lastPage > 1 {
+		main.WriteString(renderPagination(base, page, lastPage))
+	}
+
+	return renderPage(tag.Text+" - Works", "works-index dashboard", main.String())
+}
+
+// renderPagination renders AO3's pagination bar, which links to the first
+// two pages, the pages around the current page and the last two pages
+func renderPagination(base string, current int, last int) string {
+	var pagination strings.Builder
+	pagination.WriteString(`
+          <h3 class="landmark heading">Pages Navigation</h3>
+          <ol class="pagination actions" role="navigation" title="pagination">`)
+
+	if current <= 1 {
+		pagination.WriteString(`
+            <li class="previous" title="previous"><span class="disabled">&#8592; Previous</span></li>`)
+	} else {
+		fmt.Fprintf(&pagination, `
+            <li class="previous" title="previous"><a rel="prev" href="%s?page=%d">&#8592; Previous</a></li>`, base, current-1)
+	}
+
+	previous := 0
+	for page := 1; page <= last; page++ {
+		if page > 2 && page < last-1 && (page < current-1 || page > current+1) {
+			continue
+		}
+
+		if page-previous > 1 {
+			pagination.WriteString(`
+            <li class="gap">&hellip;</li>`)
+		}
+
+		if page == current {
+			fmt.Fprintf(&pagination, `
+            <li><span class="current">%d</span></li>`, page)
+		} else {
+			fmt.Fprintf(&pagination, `
+            <li><a href="%s?page=%d">%d</a></li>`, base, page, page)
+		}
+
+		previous = page
+	}
+
+	if current >= last {
+		pagination.WriteString(`
+            <li class="next" title="next"><span class="disabled">Next &#8594;</span></li>`)
+	} else {
+		fmt.Fprintf(&pagination, `
+            <li class="next" title="next"><a rel="next" href="%s?page=%d">Next &#8594;</a></li>`, base, current+1)
+	}
+
+	pagination.WriteString(`
+          </ol>`)
+
+	return pagination.String()
+}
+
+// restrictedIcon is the padlock shown next to the titles of works only visible
+// to logged-in users
+const restrictedIcon = `<img alt="(Restricted)" title="Restricted" src="/images/lockblue.png" width="15" height="15" />`
+
+// renderBlurb renders the listing of a work used on tag, series and search
+// pages
+func renderBlurb(work ao3.IndexedWork) string {
+	var blurb strings.Builder
+
+	fmt.Fprintf(&blurb, `
+            <li id="work_%s" class="work blurb group" role="article">
+              <div class="header module">
+                <h4 class="heading">
+                  <a href="/works/%s">%s</a>`, escapeAttribute(work.Slug), escapeAttribute(work.Slug), html.EscapeString(work.Title))
+
+	if work.IsAnonymous {
+		blurb.WriteString(`
+                  by Anonymous`)
+	} else {
+		blurb.WriteString(`
+                  by
+                  ` + renderAuthors(work.Authors))
+
+		if len(work.Recipients) > 0 {
+			recipients := make([]string, 0, len(work.Recipients))
+			for _, recipient := range work.Recipients {
+				recipients = append(recipients, fmt.Sprintf(`<a href="/users/%s/gifts">%s</a>`, escapeAttribute(recipient.Slug), html.EscapeString(recipient.Text)))
+			}
+			blurb.WriteString(`
+                  for ` + strings.Join(recipients, ", "))
+		}
+	}
+
+	if work.IsRestricted {
+		blurb.WriteString(`
+                  ` + restrictedIcon)
+	}
+
+	fandoms := make([]string, 0, len(work.FandomTags))
+	for _, fandom := range work.FandomTags {
+		fandoms = append(fandoms, renderTag(fandom))
+	}
+
+	fmt.Fprintf(&blurb, `
+                </h4>
+                <h5 class="fandoms heading">
+                  <span class="landmark">Fandoms:</span>
+                  %s
+                  &nbsp;
+                </h5>
+                <ul class="required-tags">
+                  <li>%s</li>
+                  <li>%s</li>
+                  <li>%s</li>
+                  <li>%s</li>
+                </ul>
+                <p class="datetime">%s</p>
+              </div>
+              <h6 class="landmark heading">Tags</h6>
+              <ul class="tags commas">`,
+		strings.Join(fandoms, ", "),
+		renderSymbol("rating", work.Rating),
+		renderSymbol("warnings", work.Warnings),
+		renderSymbol("category", work.Category),
+		renderSymbol("iswip", work.Status),
+		html.EscapeString(work.LastUpdated))
+
+	tagLists := []struct {
+		class string
+		tags  []ao3.Link
+	}{
+		{"warnings", work.WarningTags},
+		{"relationships", work.RelationshipTags},
+		{"characters", work.CharacterTags},
+		{"freeforms", work.FreeformTags},
+	}
+	for _, tagList := range tagLists {
+		for _, tag := range tagList.tags {
+			tagHTML := renderTag(tag)
+			if tagList.class == "warnings" {
+				tagHTML = "<strong>" + tagHTML + "</strong>"
+			}
+
+			fmt.Fprintf(&blurb, `
+                <li class="%s">%s</li>`, tagList.class, tagHTML)
+		}
+	}
+
+	blurb.WriteString(`
+              </ul>`)
+
+	if work.Summary != "" {
+		blurb.WriteString(`
+              <h6 class="landmark heading">Summary</h6>
+              <blockquote class="userstuff summary">
+                ` + work.Summary + `
+              </blockquote>`)
+	}
+
+	if work.IsSeries {
+		fmt.Fprintf(&blurb, `
+              <h6 class="landmark heading">Series</h6>
+              <ul class="series">
+                <li>
+                  Part <strong>%d</strong> of <a href="/series/%s">%s</a>
+                </li>
+              </ul>`, work.SeriesPart, escapeAttribute(work.Series.Slug), html.EscapeString(work.Series.Text))
+	}
+
+	fmt.Fprintf(&blurb, `
+              <dl class="stats">
+                <dt class="language">Language:</dt>
+                <dd class="language">%s</dd>
+                <dt class="words">Words:</dt>
+                <dd class="words">%s</dd>
+                <dt class="chapters">Chapters:</dt>
+                <dd class="chapters">%s</dd>`, html.EscapeString(work.Language), formatCount(work.Words), html.EscapeString(work.Chapters))
+
+	if work.Comments > 0 {
+		fmt.Fprintf(&blurb, `
+                <dt class="comments">Comments:</dt>
+                <dd class="comments"><a href="/works/%s?show_comments=true#comments">%s</a></dd>`, escapeAttribute(work.Slug), formatCount(work.Comments))
+	}
+	if work.Kudos > 0 {
+		fmt.Fprintf(&blurb, `
+                <dt class="kudos">Kudos:</dt>
+                <dd class="kudos"><a href="/works/%s#comments">%s</a></dd>`, escapeAttribute(work.Slug), formatCount(work.Kudos))
+	}
+	if work.Bookmarks > 0 {
+		fmt.Fprintf(&blurb, `
+                <dt class="bookmarks">Bookmarks:</dt>
+                <dd class="bookmarks"><a href="/works/%s/bookmarks">%s</a></dd>`, escapeAttribute(work.Slug), formatCount(work.Bookmarks))
+	}
+
+	fmt.Fprintf(&blurb, `
+                <dt class="hits">Hits:</dt>
+                <dd class="hits">%s</dd>
+              </dl>
+            </li>`, formatCount(work.Hits))
+
+	return blurb.String()
+}
+
+func renderWork(id string, work ao3.Work) string {
+	var main strings.Builder
+
+	downloads := []struct {
+		label     string
+		extension string
+	}{
+		{"AZW3", ".azw3"},
+		{"EPUB", ".epub"},
+		{"MOBI", ".mobi"},
+		{"PDF", ".pdf"},
+		{"HTML", ".html"},
+	}
+
+	fmt.Fprintf(&main, `
+          <div class="work">
+            <ul class="work navigation actions" role="menu">
+              <li class="chapter entire"><a href="/works/%s?view_full_work=true">Entire Work</a></li>
+              <li class="download" aria-haspopup="true">
+                <a href="#">Download</a>
+                <ul class="expandable secondary">`, escapeAttribute(id))
+
+	for _, download := range downloads {
+		fmt.Fprintf(&main, `
+                  <li><a href="/downloads/%s">%s</a></li>`, escapeAttribute(strings.Replace(work.HTMLDownloadSlug, ".html", download.extension, 1)), download.label)
+	}
+
+	main.WriteString(`
+                </ul>
+              </li>
+            </ul>
+            <div class="wrapper">
+              <dl class="work meta group">`)
+
+	tagLists := []struct {
+		class string
+		label string
+		tags  []ao3.Link
+	}{
+		{"rating", "Rating", work.RatingTags},
+		{"warning", "Archive Warning", work.WarningTags},
+		{"category", "Category", work.CategoryTags},
+		{"fandom", "Fandom", work.FandomTags},
+		{"character", "Characters", work.CharacterTags},
+		{"freeform", "Additional Tags", work.FreeformTags},
+	}
+	for _, tagList := range tagLists {
+		if len(tagList.tags) == 0 {
+			continue
+		}
+
+		fmt.Fprintf(&main, `
+                <dt class="%s tags">%s:</dt>
+                <dd class="%s tags">
+                  <ul class="commas">`, tagList.class, tagList.label, tagList.class)
+		for _, tag := range tagList.tags {
+			main.WriteString(`
+                    <li>` + renderTag(tag) + `</li>`)
+		}
+		main.WriteString(`
+                  </ul>
+                </dd>`)
+	}
+
+	fmt.Fprintf(&main, `
+                <dt class="language">Language:</dt>
+                <dd class="language">
+                  %s
+                </dd>`, html.EscapeString(work.Language))
+
+	if work.IsSeries {
+		fmt.Fprintf(&main, `
+                <dt class="series">Series:</dt>
+                <dd class="series">
+                  <span class="series">
+                    <span class="position">Part %d of the <a href="/series/%s">%s</a> series</span>
+                  </span>
+                </dd>`, work.SeriesPart, escapeAttribute(work.Series.Slug), html.EscapeString(work.Series.Text))
+	}
+
+	main.WriteString(`
+                <dt class="stats">Stats:</dt>
+                <dd class="stats"><dl class="stats">`)
+	fmt.Fprintf(&main, `<dt class="published">Published:</dt><dd class="published">%s</dd>`, html.EscapeString(work.Published))
+	if work.Updated != "" {
+		fmt.Fprintf(&main, `<dt class="status">Updated:</dt><dd class="status">%s</dd>`, html.EscapeString(work.Updated))
+	}
+	fmt.Fprintf(&main, `<dt class="words">Words:</dt><dd class="words">%s</dd>`, formatCount(work.Words))
+	fmt.Fprintf(&main, `<dt class="chapters">Chapters:</dt><dd class="chapters">%s</dd>`, html.EscapeString(work.Chapters))
+	if work.Comments > 0 {
+		fmt.Fprintf(&main, `<dt class="comments">Comments:</dt><dd class="comments">%s</dd>`, formatCount(work.Comments))
+	}
+	if work.Kudos > 0 {
+		fmt.Fprintf(&main, `<dt class="kudos">Kudos:</dt><dd class="kudos">%s</dd>`, formatCount(work.Kudos))
+	}
+	if work.Bookmarks > 0 {
+		fmt.Fprintf(&main, `<dt class="bookmarks">Bookmarks:</dt><dd class="bookmarks"><a href="/works/%s/bookmarks">%s</a></dd>`, escapeAttribute(id), formatCount(work.Bookmarks))
+	}
+	fmt.Fprintf(&main, `<dt class="hits">Hits:</dt><dd class="hits">%s</dd>`, formatCount(work.Hits))
+	main.WriteString(`</dl></dd>
+              </dl>
+            </div>`)
+
+	byline := "Anonymous"
+	if !work.IsAnonymous {
+		byline = renderAuthors(work.Authors)
+	}
+
+	title := html.EscapeString(work.Title)
+	if work.IsRestricted {
+		title = restrictedIcon + "\n                  " + title
+	}
+
+	fmt.Fprintf(&main, `
+            <div id="workskin">
+              <div class="preface group">
+                <h2 class="title heading">
+                  %s
+                </h2>
+                <h3 class="byline heading">
+                  %s
+                </h3>`, title, byline)
+
+	if work.Summary != "" {
+		main.WriteString(`
+                <div class="summary module" role="complementary">
+                  <h3 class="heading">Summary:</h3>
+                  <blockquote class="userstuff">
+                    ` + work.Summary + `
+                  </blockquote>
+                </div>`)
+	}
+
+	main.WriteString(`
+              </div>
+              <div id="chapters" role="article">
+                <div class="userstuff"><p>This work is served by ao3test.</p></div>
+              </div>
+            </div>
+          </div>`)
+
+	return renderPage(work.Title, "works-show", main.String())
+}
+
+func renderSeries(id string, series ao3.Series) string {
+	var main strings.Builder
+
+	creators := "Anonymous"
+	if !series.IsAnonymous {
+		creators = renderAuthors(series.Creators)
+	}
+
+	fmt.Fprintf(&main, `
+          <h2 class="heading">%s</h2>
+          <div class="wrapper">
+            <dl class="series meta group">
+              <dt>Creator:</dt>
+              <dd>%s</dd>
+              <dt>Series Begun:</dt>
+              <dd>%s</dd>
+              <dt>Series Updated:</dt>
+              <dd>%s</dd>`, html.EscapeString(series.Title), creators, html.EscapeString(series.Begun), html.EscapeString(series.Updated))
+
+	if series.Description != "" {
+		main.WriteString(`
+              <dt>Description:</dt>
+              <dd><blockquote class="userstuff">` + series.Description + `</blockquote></dd>`)
+	}
+	if series.Notes != "" {
+		main.WriteString(`
+              <dt>Notes:</dt>
+              <dd><blockquote class="userstuff">` + series.Notes + `</blockquote></dd>`)
+	}
+
+	numWorks := series.NumWorks
+	if numWorks == 0 {
+		numWorks = len(series.Works)
+	}
+
+	complete := "No"
+	if series.IsComplete {
+		complete = "Yes"
+	}
+
+	fmt.Fprintf(&main, `
+              <dt>Stats:</dt>
+              <dd>
+                <dl class="stats"><dt>Words:</dt><dd>%s</dd><dt>Works:</dt><dd>%d</dd><dt>Complete:</dt><dd>%s</dd>`, formatCount(series.Words), numWorks, complete)
+	if series.Bookmarks > 0 {
+		fmt.Fprintf(&main, `<dt>Bookmarks:</dt><dd><a href="/series/%s/bookmarks">%s</a></dd>`, escapeAttribute(id), formatCount(series.Bookmarks))
+	}
+	main.WriteString(`</dl>
+              </dd>
+            </dl>
+          </div>
+          <h3 class="landmark heading">Listing Series</h3>
+          <ul class="series work index group">`)
+
+	for _, work := range series.Works {
+		main.WriteString(renderBlurb(work))
+	}
+
+	main.WriteString(`
+          </ul>`)
+
+	return renderPage(series.Title, "series-show", main.String())
+}
+
+// renderDownload renders the HTML download of a work
+func renderDownload(id string, work ao3.Work) string {
+	byline := "Anonymous"
+	if !work.IsAnonymous {
+		byline = renderAuthors(work.Authors)
+	}
+
+	return `<!DOCTYPE html>
+<html>
+<head>
+<meta charset="UTF-8"/>
+<title>` + html.EscapeString(work.Title) + `</title>
+</head>
+<body>
+<div id="preface">
+  <h1>` + html.EscapeString(work.Title) + `</h1>
+  <div class="byline">by ` + byline + `</div>
+  <blockquote class="userstuff">` + work.Summary + `</blockquote>
+</div>
+<div id="chapters" class="userstuff">
+  <p>Work ` + html.EscapeString(id) + ` is served by ao3test.</p>
+</div>
+</body>
+</html>
+`
+}
+
+// indexWork converts a work into its listing on tag pages
+func indexWork(id string, work ao3.Work) ao3.IndexedWork {
+	status := "Work in Progress"
+	if matches := completeChaptersRegex.FindStringSubmatch(work.Chapters); len(matches) == 3 && matches[1] == matches[2] {
+		status = "Complete Work"
+	}
+
+	lastUpdated := work.Updated
+	if lastUpdated == "" {
+		lastUpdated = work.Published
+	}
+	if date, err := time.Parse("2006-01-02", lastUpdated); err == nil {
+		lastUpdated = date.Format("02 Jan 2006")
+	}
+
+	return ao3.IndexedWork{
+		Title:       work.Title,
+		Slug:        id,
+		LastUpdated: lastUpdated,
+
+		IsAnonymous: work.IsAnonymous,
+		Authors:     work.Authors,
+
+		IsRestricted: work.IsRestricted,
+
+		Rating:   joinTagText(work.RatingTags, "Not Rated"),
+		Warnings: joinTagText(work.WarningTags, "Creator Chose Not To Use Archive Warnings"),
+		Category: joinTagText(work.CategoryTags, "No category"),
+		Status:   status,
+
+		FandomTags:    work.FandomTags,
+		WarningTags:   work.WarningTags,
+		CharacterTags: work.CharacterTags,
+		FreeformTags:  work.FreeformTags,
+
+		IsSeries:   work.IsSeries,
+		Series:     work.Series,
+		SeriesPart: work.SeriesPart,
+
+		Summary: work.Summary,
+
+		Language:  work.Language,
+		Words:     work.Words,
+		Chapters:  work.Chapters,
+		Comments:  work.Comments,
+		Kudos:     work.Kudos,
+		Bookmarks: work.Bookmarks,
+		Hits:      work.Hits,
+	}
+}
+
+func renderAuthors(authors []ao3.Link) string {
+	links := make([]string, 0, len(authors))
+	for _, author := range authors {
+		links = append(links, fmt.Sprintf(`<a rel="author" href="/users/%s/pseuds/%s">%s</a>`, escapeAttribute(author.Slug), escapeAttribute(author.Slug), html.EscapeString(author.Text)))
+	}
+
+	return strings.Join(links, ", ")
+}
+
+func renderTag(tag ao3.Link) string {
+	return fmt.Sprintf(`<a class="tag" href="/tags/%s/works">%s</a>`, escapeAttribute(tag.Slug), html.EscapeString(tag.Text))
+}
+
+// renderSymbol renders one of the four symbols in a blurb's required tags
+func renderSymbol(class string, title string) string {
+	return fmt.Sprintf(`<a class="help symbol question modal" title="Symbols key" href="/help/symbols-key.html"><span class="%s" title="%s"><span class="text">%s</span></span></a>`, class, escapeAttribute(title), html.EscapeString(title))
+}
+
+func joinTagText(tags []ao3.Link, fallback string) string {
+	if len(tags) == 0 {
+		return fallback
+	}
+
+	texts := make([]string, 0, len(tags))
+	for _, tag := range tags {
+		texts = append(texts, tag.Text)
+	}
+
+	return strings.Join(texts, ", ")
+}
+
+// escapeAttribute escapes a value for use in a double-quoted attribute
+func escapeAttribute(value string) string {
+	return html.EscapeString(value)
+}
+
+// formatCount formats a number with thousands separators, e.g. 18530 becomes
+// "18,530"
+func formatCount(count int) string {
+	digits := strconv.Itoa(count)
+	if count < 0 {
+		return "-" + formatCount(-count)
+	}
+
+	var formatted strings.Builder
+	for i, digit := range digits {
+		if i > 0 && (len(digits)-i)%3 == 0 {
+			formatted.WriteByte(',')
+		}
+		formatted.WriteRune(digit)
+	}
+
+	return formatted.String()
+}
@@ -0,0 +1,382 @@
+// Package ao3test provides a fake AO3 server for integration tests of code
+// built on ao3.AO3Client.
+//
+// The server emulates the pages scraped by the client from a programmable,
+// in-memory dataset, rendering markup close enough to AO3's that the client's
+// parsers consume it:
+//
+//     server := ao3test.NewServer()
+//     defer server.Close()
+//
+//     server.AddWork("1", ao3.Work{Title: "A Work", ...})
+//
+//     client, err := server.NewClient(ao3.AO3Policy)
+//     work, err := client.GetWork("1")
+package ao3test
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"unicode/utf8"
+	"github.com/kz/ao3-go"
+)
+
+// DefaultPageSize is the number of works listed per page, as on AO3
+const DefaultPageSize = 20
+
+// Server is a fake AO3 running on a local httptest server. It serves:
+//
+//     /media
+//     /media/[category]/fandoms
+//     /tags/[tag]/works?page=[page]
+//     /works/[work]
+//     /series/[series]
+//     /downloads/[path]
+//
+// Requests for anything else, or for data which has not been added, receive
+// AO3's 404 page. Server is safe for concurrent use.
+type Server struct {
+	*httptest.Server
+
+	mu sync.RWMutex
+
+	pageSize   int
+	categories []ao3.FandomCategory
+	fandoms    map[string][]ao3.Fandom
+	works      map[string]ao3.Work
+	workIDs    []string
+	series     map[string]ao3.Series
+	downloads  map[string][]byte
+}
+
+// NewServer starts a fake AO3 with an empty dataset. The caller must call
+// Close when finished.
+func NewServer() *Server {
+	server := &Server{
+		pageSize:  DefaultPageSize,
+		fandoms:   map[string][]ao3.Fandom{},
+		works:     map[string]ao3.Work{},
+		series:    map[string]ao3.Series{},
+		downloads: map[string][]byte{},
+	}
+	server.Server = httptest.NewServer(http.HandlerFunc(server.serveHTTP))
+
+	return server
+}
+
+// NewClient creates a client pointed at the server
+func (server *Server) NewClient(policy ao3.SanitizationPolicy) (*ao3.AO3Client, *ao3.AO3Error) {
+	client, err := ao3.InitAO3Client(nil, policy)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := client.SetBaseURL(server.URL); err != nil {
+		return nil, err
+	}
+
+	return client, nil
+}
+
+// SetPageSize sets the number of works listed per page of a tag
+func (server *Server) SetPageSize(size int) {
+	server.mu.Lock()
+	defer server.mu.Unlock()
+
+	if size < 1 {
+		size = 1
+	}
+	server.pageSize = size
+}
+
+// AddFandomCategory lists a category on /media
+func (server *Server) AddFandomCategory(category ao3.FandomCategory) {
+	server.mu.Lock()
+	defer server.mu.Unlock()
+
+	server.categories = append(server.categories, category)
+}
+
+// AddFandom lists a fandom on the category's page. The category is added to
+// /media if it has not been already. If the fandom's Letter is empty, the
+// first letter of its name is used.
+func (server *Server) AddFandom(category ao3.FandomCategory, fandom ao3.Fandom) {
+	server.mu.Lock()
+	defer server.mu.Unlock()
+
+	hasCategory := false
+	for _, existing := range server.categories {
+		if existing.Slug == category.Slug {
+			hasCategory = true
+			break
+		}
+	}
+	if !hasCategory {
+		server.categories = append(server.categories, category)
+	}
+
+	if fandom.Letter == "" && fandom.Name != "" {
+		first, _ := utf8.DecodeRuneInString(fandom.Name)
+		fandom.Letter = strings.ToUpper(string(first))
+	}
+
+	server.fandoms[category.Slug] = append(server.fandoms[category.Slug], fandom)
+}
+
+// AddWork serves the work at /works/[id] and lists it on the works page of
+// each of its tags. Works are listed in the order they were added.
+//
+// If the work has no HTMLDownloadSlug, one is generated, and a download of
+// the work is served unless SetDownload has been called for its path.
+func (server *Server) AddWork(id string, work ao3.Work) {
+	server.mu.Lock()
+	defer server.mu.Unlock()
+
+	if work.HTMLDownloadSlug == "" {
+		work.HTMLDownloadSlug = downloadSlug(id, work)
+	}
+
+	if _, ok := server.works[id]; !ok {
+		server.workIDs = append(server.workIDs, id)
+	}
+	server.works[id] = work
+
+	path := downloadPath(work.HTMLDownloadSlug)
+	if _, ok := server.downloads[path]; !ok {
+		server.downloads[path] = []byte(renderDownload(id, work))
+	}
+}
+
+// AddSeries serves the series at /series/[id]. The series' Works are rendered
+// as they are given and do not need to be added with AddWork.
+func (server *Server) AddSeries(id string, series ao3.Series) {
+	server.mu.Lock()
+	defer server.mu.Unlock()
+
+	server.series[id] = series
+}
+
+// SetDownload serves body at /downloads/[path]. Any query in path, such as
+// "?updated_at=...", is ignored when matching requests.
+func (server *Server) SetDownload(path string, body []byte) {
+	server.mu.Lock()
+	defer server.mu.Unlock()
+
+	server.downloads[downloadPath(path)] = body
+}
+
+func (server *Server) serveHTTP(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet && r.Method != http.MethodHead {
+		w.Header().Set("Allow", "GET, HEAD")
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	server.mu.RLock()
+	defer server.mu.RUnlock()
+
+	segments := strings.Split(strings.Trim(r.URL.EscapedPath(), "/"), "/")
+
+	switch {
+	case len(segments) == 1 && segments[0] == "media":
+		writeHTML(w, http.StatusOK, renderFandomCategories(server.categories))
+	case len(segments) == 3 && segments[0] == "media" && segments[2] == "fandoms":
+		server.serveFandomCategory(w, segments[1])
+	case len(segments) == 3 && segments[0] == "tags" && segments[2] == "works":
+		server.serveTagWorks(w, r, segments[1])
+	case len(segments) == 2 && segments[0] == "works":
+		server.serveWork(w, segments[1])
+	case len(segments) == 2 && segments[0] == "series":
+		server.serveSeries(w, segments[1])
+	case len(segments) > 1 && segments[0] == "downloads":
+		server.serveDownload(w, strings.Join(segments[1:], "/"))
+	default:
+		writeNotFound(w)
+	}
+}
+
+func (server *Server) serveFandomCategory(w http.ResponseWriter, slug string) {
+	for _, category := range server.categories {
+		if sameSlug(category.Slug, slug) {
+			fandoms := append([]ao3.Fandom(nil), server.fandoms[category.Slug]...)
+			writeHTML(w, http.StatusOK, renderFandomCategory(category, fandoms))
+			return
+		}
+	}
+
+	writeNotFound(w)
+}
+
+func (server *Server) serveTagWorks(w http.ResponseWriter, r *http.Request, slug string) {
+	var tag *ao3.Link
+	var works []ao3.IndexedWork
+
+	for _, id := range server.workIDs {
+		work := server.works[id]
+
+		matchedTag, ok := findTag(work, slug)
+		if !ok {
+			continue
+		}
+		if tag == nil {
+			tag = &matchedTag
+		}
+
+		works = append(works, indexWork(id, work))
+	}
+
+	if tag == nil {
+		writeNotFound(w)
+		return
+	}
+
+	page := 1
+	if rawPage := r.URL.Query().Get("page"); rawPage != "" {
+		parsedPage, err := strconv.Atoi(rawPage)
+		if err != nil || parsedPage < 1 {
+			writeNotFound(w)
+			return
+		}
+		page = parsedPage
+	}
+
+	lastPage := (len(works) + server.pageSize - 1) / server.pageSize
+	if lastPage < 1 {
+		lastPage = 1
+	}
+	if page > lastPage {
+		page = lastPage
+	}
+
+	start := (page - 1) * server.pageSize
+	end := start + server.pageSize
+	if end > len(works) {
+		end = len(works)
+	}
+
+	writeHTML(w, http.StatusOK, renderTagWorks(*tag, works[start:end], len(works), start, page, lastPage))
+}
+
+func (server *Server) serveWork(w http.ResponseWriter, id string) {
+	work, ok := server.works[id]
+	if !ok {
+		writeNotFound(w)
+		return
+	}
+
+	writeHTML(w, http.StatusOK, renderWork(id, work))
+}
+
+func (server *Server) serveSeries(w http.ResponseWriter, id string) {
+	series, ok := server.series[id]
+	if !ok {
+		writeNotFound(w)
+		return
+	}
+
+	writeHTML(w, http.StatusOK, renderSeries(id, series))
+}
+
+func (server *Server) serveDownload(w http.ResponseWriter, path string) {
+	body, ok := server.downloads[downloadPath(path)]
+	if !ok {
+		writeNotFound(w)
+		return
+	}
+
+	w.Header().Set("Content-Type", "text/html; charset=utf-8")
+	w.WriteHeader(http.StatusOK)
+	w.Write(body)
+}
+
+// findTag returns the tag of the work with the given slug
+func findTag(work ao3.Work, slug string) (ao3.Link, bool) {
+	tagLists := [][]ao3.Link{
+		work.RatingTags,
+		work.WarningTags,
+		work.CategoryTags,
+		work.FandomTags,
+		work.CharacterTags,
+		work.FreeformTags,
+	}
+
+	for _, tags := range tagLists {
+		for _, tag := range tags {
+			if sameSlug(tag.Slug, slug) {
+				return tag, true
+			}
+		}
+	}
+
+	return ao3.Link{}, false
+}
+
+// sameSlug compares slugs after unescaping them, as clients may escape
+// characters such as apostrophes differently
+func sameSlug(a string, b string) bool {
+	unescapedA, errA := url.PathUnescape(a)
+	unescapedB, errB := url.PathUnescape(b)
+	if errA != nil || errB != nil {
+		return a == b
+	}
+
+	return unescapedA == unescapedB
+}
+
+// downloadPath strips the query from a download slug
+func downloadPath(slug string) string {
+	path := strings.TrimPrefix(slug, "/")
+	if index := strings.Index(path, "?"); index != -1 {
+		path = path[:index]
+	}
+
+	unescaped, err := url.PathUnescape(path)
+	if err != nil {
+		return path
+	}
+
+	return unescaped
+}
+
+// downloadSlug generates a download slug in AO3's format, e.g.
+// "Co/CodenameCarrot/5191202/A%20Complete%20Guide.html"
+func downloadSlug(id string, work ao3.Work) string {
+	author := "Anonymous"
+	if !work.IsAnonymous && len(work.Authors) > 0 {
+		author = work.Authors[0].Slug
+	}
+
+	prefix := author
+	if len(prefix) > 2 {
+		prefix = prefix[:2]
+	}
+
+	return prefix + "/" + url.PathEscape(author) + "/" + id + "/" + url.PathEscape(work.Title) + ".html"
+}
+
+// sortedFandoms sorts fandoms by letter then name, as on AO3
+func sortedFandoms(fandoms []ao3.Fandom) []ao3.Fandom {
+	sort.SliceStable(fandoms, func(i, j int) bool {
+		if fandoms[i].Letter != fandoms[j].Letter {
+			return fandoms[i].Letter < fandoms[j].Letter
+		}
+		return fandoms[i].Name < fandoms[j].Name
+	})
+
+	return fandoms
+}
+
+func writeHTML(w http.ResponseWriter, status int, body string) {
+	w.Header().Set("Content-Type", "text/html; charset=utf-8")
+	w.WriteHeader(status)
+	w.Write([]byte(body))
+}
+
+func writeNotFound(w http.ResponseWriter) {
+	writeHTML(w, http.StatusNotFound, renderNotFound())
+}
@@ -0,0 +1,320 @@
+package ao3test
+
+import (
+	"fmt"
+	"net/http"
+	"testing"
+	"github.com/kz/ao3-go"
+	"github.com/stretchr/testify/assert"
+)
+
+var testWork = ao3.Work{
+	Title:       "A Complete Guide to 'Limited HTML' on AO3",
+	IsAnonymous: false,
+	Authors:     []ao3.Link{{Text: "CodenameCarrot", Slug: "CodenameCarrot"}},
+
+	RatingTags:    []ao3.Link{{Text: "General Audiences", Slug: "General%20Audiences"}},
+	FandomTags:    []ao3.Link{{Text: "No Fandom", Slug: "No%20Fandom"}},
+	WarningTags:   []ao3.Link{{Text: "No Archive Warnings Apply", Slug: "No%20Archive%20Warnings%20Apply"}},
+	CategoryTags:  []ao3.Link{{Text: "Gen", Slug: "Gen"}},
+	CharacterTags: []ao3.Link{},
+	FreeformTags: []ao3.Link{
+		{Text: "HTML", Slug: "HTML"},
+		{Text: "Fanwork Research & Reference Guides", Slug: "Fanwork%20Research%20*a*%20Reference%20Guides"},
+	},
+
+	IsSeries:   true,
+	Series:     ao3.Link{Text: "Guides", Slug: "3487"},
+	SeriesPart: 2,
+
+	Language:  "English",
+	Published: "2015-11-11",
+	Updated:   "2015-11-23",
+	Words:     2642,
+	Chapters:  "3/4",
+	Comments:  153,
+	Kudos:     18530,
+	Bookmarks: 12000,
+	Hits:      1260000,
+
+	Summary: "<p>A guide to the HTML allowed on AO3.</p>",
+}
+
+func newTestClient(t *testing.T, server *Server) *ao3.AO3Client {
+	client, err := server.NewClient(ao3.AO3Policy)
+	if err != nil {
+		t.Fatal(err.Error())
+	}
+
+	return client
+}
+
+// TestGetFandomCategories tests that categories added to the server are listed
+// on /media
+func TestGetFandomCategories(t *testing.T) {
+	server := NewServer()
+	defer server.Close()
+
+	expected := []ao3.FandomCategory{
+		{Name: "Anime & Manga", Slug: "Anime%20*a*%20Manga"},
+		{Name: "Books & Literature", Slug: "Books%20*a*%20Literature"},
+	}
+	for _, category := range expected {
+		server.AddFandomCategory(category)
+	}
+
+	categories, err := newTestClient(t, server).GetFandomCategories()
+	if err != nil {
+		t.Fatal(err.Error())
+	}
+
+	assert.Equal(t, expected, categories)
+}
+
+// TestGetFandomCategory tests that fandoms are grouped by letter and counted
+func TestGetFandomCategory(t *testing.T) {
+	server := NewServer()
+	defer server.Close()
+
+	category := ao3.FandomCategory{Name: "Books & Literature", Slug: "Books%20*a*%20Literature"}
+	server.AddFandom(category, ao3.Fandom{Name: "Harry Potter - J. K. Rowling", Slug: "Harry%20Potter%20-%20J*d*%20K*d*%20Rowling", Count: 200000})
+	server.AddFandom(category, ao3.Fandom{Name: "A Song of Ice and Fire - George R. R. Martin", Slug: "A%20Song%20of%20Ice%20and%20Fire%20-%20George%20R*d*%20R*d*%20Martin", Count: 9000})
+	server.AddFandom(category, ao3.Fandom{Name: "ōkami", Slug: "%C5%8Ckami", Count: 300})
+
+	client := newTestClient(t, server)
+
+	categories, err := client.GetFandomCategories()
+	if err != nil {
+		t.Fatal(err.Error())
+	}
+	assert.Equal(t, []ao3.FandomCategory{category}, categories)
+
+	fandoms, err := client.GetFandomCategory(category.Slug)
+	if err != nil {
+		t.Fatal(err.Error())
+	}
+
+	assert.Equal(t, []ao3.Fandom{
+		{Name: "A Song of Ice and Fire - George R. R. Martin", Letter: "A", Slug: "A%20Song%20of%20Ice%20and%20Fire%20-%20George%20R*d*%20R*d*%20Martin", Count: 9000},
+		{Name: "Harry Potter - J. K. Rowling", Letter: "H", Slug: "Harry%20Potter%20-%20J*d*%20K*d*%20Rowling", Count: 200000},
+		{Name: "ōkami", Letter: "Ō", Slug: "%C5%8Ckami", Count: 300},
+	}, fandoms)
+}
+
+// TestGetWork tests that a work round-trips through its rendered page
+func TestGetWork(t *testing.T) {
+	server := NewServer()
+	defer server.Close()
+
+	server.AddWork("5191202", testWork)
+
+	work, err := newTestClient(t, server).GetWork("5191202")
+	if err != nil {
+		t.Fatal(err.Error())
+	}
+
+	assert.Equal(t, testWork.Title, work.Title)
+	assert.Equal(t, testWork.Authors, work.Authors)
+	assert.Equal(t, testWork.RatingTags, work.RatingTags)
+	assert.Equal(t, testWork.FandomTags, work.FandomTags)
+	assert.Equal(t, testWork.WarningTags, work.WarningTags)
+	assert.Equal(t, testWork.CategoryTags, work.CategoryTags)
+	assert.Equal(t, testWork.FreeformTags, work.FreeformTags)
+	assert.True(t, work.IsSeries)
+	assert.Equal(t, testWork.Series, work.Series)
+	assert.Equal(t, testWork.SeriesPart, work.SeriesPart)
+	assert.Equal(t, testWork.Language, work.Language)
+	assert.Equal(t, testWork.Published, work.Published)
+	assert.Equal(t, testWork.Updated, work.Updated)
+	assert.Equal(t, testWork.Words, work.Words)
+	assert.Equal(t, testWork.Chapters, work.Chapters)
+	assert.Equal(t, testWork.Kudos, work.Kudos)
+	assert.Equal(t, testWork.Hits, work.Hits)
+	assert.Contains(t, work.Summary, "A guide to the HTML allowed on AO3.")
+	assert.Equal(t, "Co/CodenameCarrot/5191202/A%20Complete%20Guide%20to%20%27Limited%20HTML%27%20on%20AO3.html", work.HTMLDownloadSlug)
+}
+
+// TestDownloadWork tests that works added to the server can be downloaded
+// from their HTMLDownloadSlug
+func TestDownloadWork(t *testing.T) {
+	server := NewServer()
+	defer server.Close()
+
+	server.AddWork("5191202", testWork)
+	server.AddWork("1", ao3.Work{Title: "Custom", IsAnonymous: true, HTMLDownloadSlug: "An/Anonymous/1/Custom.html?updated_at=1"})
+	server.SetDownload("An/Anonymous/1/Custom.html", []byte("custom download"))
+
+	client := newTestClient(t, server)
+
+	work, err := client.GetWork("5191202")
+	if err != nil {
+		t.Fatal(err.Error())
+	}
+
+	body, err := client.DownloadWork(work.HTMLDownloadSlug)
+	if err != nil {
+		t.Fatal(err.Error())
+	}
+	assert.Contains(t, string(body), "<!DOCTYPE html>")
+	assert.Contains(t, string(body), "A Complete Guide to &#39;Limited HTML&#39; on AO3")
+
+	body, err = client.DownloadWork("An/Anonymous/1/Custom.html?updated_at=1")
+	if err != nil {
+		t.Fatal(err.Error())
+	}
+	assert.Equal(t, "custom download", string(body))
+}
+
+// TestGetTagWorks tests that works are listed under each of their tags and
+// paginated
+func TestGetTagWorks(t *testing.T) {
+	server := NewServer()
+	defer server.Close()
+
+	server.SetPageSize(2)
+	for i := 1; i <= 5; i++ {
+		work := testWork
+		work.Title = fmt.Sprintf("Work %d", i)
+		work.IsSeries = false
+		server.AddWork(fmt.Sprint(i), work)
+	}
+
+	client := newTestClient(t, server)
+
+	tagWorks, err := client.GetTagWorks("HTML", 1)
+	if err != nil {
+		t.Fatal(err.Error())
+	}
+
+	assert.Equal(t, 5, tagWorks.Count)
+	assert.True(t, tagWorks.IsPaginated)
+	assert.Equal(t, 1, tagWorks.CurrentPage)
+	assert.Equal(t, 3, tagWorks.LastPage)
+	works := tagWorks.Works
+	if assert.Len(t, works, 2) {
+		assert.Equal(t, "1", works[0].Slug)
+		assert.Equal(t, "Work 1", works[0].Title)
+		assert.Equal(t, testWork.Authors, works[0].Authors)
+		assert.Equal(t, "General Audiences", works[0].Rating)
+		assert.Equal(t, "Work in Progress", works[0].Status)
+		assert.Equal(t, "23 Nov 2015", works[0].LastUpdated)
+		assert.Equal(t, testWork.FandomTags, works[0].FandomTags)
+		if assert.Len(t, works[0].FreeformTags, 2) {
+			assert.Equal(t, testWork.FreeformTags[0], works[0].FreeformTags[0])
+			assert.Equal(t, testWork.FreeformTags[1].Slug, works[0].FreeformTags[1].Slug)
+		}
+		assert.Equal(t, testWork.Words, works[0].Words)
+		assert.Equal(t, testWork.Kudos, works[0].Kudos)
+		assert.Equal(t, testWork.Hits, works[0].Hits)
+	}
+
+	tagWorks, err = client.GetTagWorks("HTML", 3)
+	if err != nil {
+		t.Fatal(err.Error())
+	}
+
+	assert.Equal(t, 3, tagWorks.CurrentPage)
+	assert.Equal(t, 3, tagWorks.LastPage)
+	if assert.Len(t, tagWorks.Works, 1) {
+		assert.Equal(t, "5", tagWorks.Works[0].Slug)
+	}
+}
+
+// TestRestrictedWork tests that restricted works are rendered with the padlock
+// on their page and in listings
+func TestRestrictedWork(t *testing.T) {
+	server := NewServer()
+	defer server.Close()
+
+	work := testWork
+	work.IsRestricted = true
+	server.AddWork("5191202", work)
+
+	client := newTestClient(t, server)
+
+	fetched, err := client.GetWork("5191202")
+	if err != nil {
+		t.Fatal(err.Error())
+	}
+	assert.True(t, fetched.IsRestricted)
+	assert.Equal(t, testWork.Title, fetched.Title)
+
+	tagWorks, err := client.GetTagWorks("HTML", 1)
+	if err != nil {
+		t.Fatal(err.Error())
+	}
+	if assert.Len(t, tagWorks.Works, 1) {
+		assert.True(t, tagWorks.Works[0].IsRestricted)
+		assert.Equal(t, testWork.Title, tagWorks.Works[0].Title)
+	}
+}
+
+// TestGetSeries tests that a series and its works round-trip through the
+// rendered page
+func TestGetSeries(t *testing.T) {
+	server := NewServer()
+	defer server.Close()
+
+	indexed := indexWork("5191202", testWork)
+	server.AddSeries("3487", ao3.Series{
+		Title:    "Guides",
+		Creators: []ao3.Link{{Text: "CodenameCarrot", Slug: "CodenameCarrot"}},
+		Begun:    "2015-11-11",
+		Updated:  "2015-11-23",
+		Words:    2642,
+		Works:    []ao3.IndexedWork{indexed},
+	})
+
+	series, err := newTestClient(t, server).GetSeries("3487")
+	if err != nil {
+		t.Fatal(err.Error())
+	}
+
+	assert.Equal(t, "Guides", series.Title)
+	assert.Equal(t, []ao3.Link{{Text: "CodenameCarrot", Slug: "CodenameCarrot"}}, series.Creators)
+	assert.Equal(t, "2015-11-11", series.Begun)
+	assert.Equal(t, "2015-11-23", series.Updated)
+	assert.Equal(t, 2642, series.Words)
+	assert.Equal(t, 1, series.NumWorks)
+	if assert.Len(t, series.Works, 1) {
+		assert.Equal(t, "5191202", series.Works[0].Slug)
+		assert.True(t, series.Works[0].IsSeries)
+		assert.Equal(t, 2, series.Works[0].SeriesPart)
+	}
+}
+
+// TestNotFound tests that missing data receives AO3's 404 page
+func TestNotFound(t *testing.T) {
+	server := NewServer()
+	defer server.Close()
+
+	client := newTestClient(t, server)
+	client.RetryPolicy = nil
+
+	_, err := client.GetWork("1")
+	if assert.NotNil(t, err) {
+		assert.Equal(t, http.StatusNotFound, err.Code())
+	}
+
+	_, err = client.GetSeries("1")
+	if assert.NotNil(t, err) {
+		assert.Equal(t, http.StatusNotFound, err.Code())
+	}
+
+	_, err = client.GetTagWorks("Missing", 1)
+	if assert.NotNil(t, err) {
+		assert.Equal(t, http.StatusNotFound, err.Code())
+	}
+
+	_, err = client.GetFandomCategory("Missing")
+	if assert.NotNil(t, err) {
+		assert.Equal(t, http.StatusNotFound, err.Code())
+	}
+
+	res, getErr := http.Post(server.URL+"/works/1", "text/plain", nil)
+	if getErr != nil {
+		t.Fatal(getErr.Error())
+	}
+	res.Body.Close()
+	assert.Equal(t, http.StatusMethodNotAllowed, res.StatusCode)
+}
@@ -0,0 +1,159 @@
+package ao3
+
+import (
+	"bytes"
+	"context"
+	"net/http"
+	"net/url"
+	"regexp"
+	"strings"
+	"github.com/PuerkitoBio/goquery"
+)
+
+// loginEndpoint serves the login form and receives its submissions
+const loginEndpoint = "/users/login"
+
+var userLinkRegex = regexp.MustCompile("^/users/([^/?#]+)$")
+
+// Authenticate logs in to AO3 and stores the session in the cookie jar of the
+// client's HttpClient, which InitAO3Client sets up. A client whose jar was
+// removed returns an error. Every endpoint returns the logged-in view of its
+// pages afterwards, e.g. restricted works and the work counts seen by
+// logged-in users.
+//
+// login is a user name or email address. A wrong login or password returns an
+// error matching ErrBadCredentials, and a locked account one matching
+// ErrAccountLocked.
+//
+// Endpoint: https://archiveofourown.org/users/login
+func (client *AO3Client) Authenticate(login string, password string) *AO3Error {
+	return client.AuthenticateWithContext(context.Background(), login, password)
+}
+
+// AuthenticateWithContext is Authenticate with a context which cancels the
+// requests when it is done
+func (client *AO3Client) AuthenticateWithContext(ctx context.Context, login string, password string) *AO3Error {
+	if login == "" || password == "" {
+		return NewError(http.StatusBadRequest, "logging in requires a login and password").withKind(KindBadCredentials)
+	}
+
+	if ao3Err := client.requireCookieJar("logging in"); ao3Err != nil {
+		return ao3Err
+	}
+
+	// The login form must be fetched first for its authenticity (CSRF) token,
+	// which is tied to the session cookie set by the same response
+	token, ao3Err := client.fetchCSRFToken(ctx, loginEndpoint, "fetching login form")
+	if ao3Err != nil {
+		return ao3Err
+	}
+
+	form := url.Values{}
+	form.Set("authenticity_token", token)
+	form.Set("user[login]", login)
+	form.Set("user[password]", password)
+	form.Set("user[remember_me]", "1")
+	form.Set("commit", "Log In")
+
+	res, ao3Err := client.post(ctx, loginEndpoint, form, "logging in")
+	if ao3Err != nil {
+		return ao3Err
+	}
+
+	doc, err := goquery.NewDocumentFromReader(bytes.NewReader(res.body))
+	if err != nil {
+		return WrapError(http.StatusUnprocessableEntity, err, "parsing login response with goquery failed")
+	}
+
+	if !isLoggedIn(doc) {
+		return loginError(doc)
+	}
+
+	username := client.loggedInUsername(doc)
+	if username == "" {
+		username = login
+	}
+	client.setSession(username, csrfToken(doc))
+
+	return nil
+}
+
+// requireCookieJar returns an error if the client's HttpClient was replaced by
+// one without a cookie jar, as sessions and authenticity tokens are tied to
+// AO3's session cookie
+func (client *AO3Client) requireCookieJar(action string) *AO3Error {
+	if client.HttpClient.Jar == nil {
+		return NewError(http.StatusBadRequest, action+" failed as the client has no cookie jar")
+	}
+
+	return nil
+}
+
+// fetchCSRFToken fetches a page bypassing the cache and returns the
+// authenticity token used to submit its forms
+func (client *AO3Client) fetchCSRFToken(ctx context.Context, endpoint string, action string) (string, *AO3Error) {
+	body, ao3Err := client.get(ctx, endpoint, 0, action)
+	if ao3Err != nil {
+		return "", ao3Err
+	}
+
+	doc, err := goquery.NewDocumentFromReader(bytes.NewReader(body))
+	if err != nil {
+		return "", WrapError(http.StatusUnprocessableEntity, err, "parsing page with goquery failed")
+	}
+
+	token := csrfToken(doc)
+	if token == "" {
+		return "", NewError(http.StatusUnprocessableEntity, "unable to find authenticity token")
+	}
+
+	return token, nil
+}
+
+// csrfToken extracts the authenticity token from the page's meta tags or, on
+// pages without them, its forms
+func csrfToken(doc *goquery.Document) string {
+	if token, ok := doc.Find(`meta[name="csrf-token"]`).First().Attr("content"); ok && token != "" {
+		return token
+	}
+
+	token, _ := doc.Find(`input[name="authenticity_token"]`).First().Attr("value")
+	return token
+}
+
+// isLoggedIn reports whether the page was rendered for a logged-in user
+func isLoggedIn(doc *goquery.Document) bool {
+	return len(doc.Find("body.logged-in").Nodes) > 0
+}
+
+// loggedInUsername extracts the name of the logged-in user from the greeting
+// in the page's header, e.g. "Hi, CodenameCarrot!"
+func (client *AO3Client) loggedInUsername(doc *goquery.Document) string {
+	username := ""
+	doc.Find("#greeting a[href]").EachWithBreak(func(_ int, node *goquery.Selection) bool {
+		link, _ := node.Attr("href")
+
+		matches := userLinkRegex.FindStringSubmatch(client.relativeLink(link))
+		if len(matches) == 2 {
+			username, _ = url.PathUnescape(matches[1])
+			return false
+		}
+		return true
+	})
+
+	return username
+}
+
+// loginError converts the flash message of a failed login into an error
+func loginError(doc *goquery.Document) *AO3Error {
+	message := strings.TrimSpace(doc.Find(".flash.error, .flash.alert").First().Text())
+
+	switch {
+	case strings.Contains(strings.ToLower(message), "locked"):
+		return NewError(http.StatusLocked, "logging in failed as the account is locked: "+message).withKind(KindAccountLocked)
+	case message != "":
+		return NewError(http.StatusUnauthorized, "logging in failed: "+message).withKind(KindBadCredentials)
+	}
+
+	return NewError(http.StatusUnprocessableEntity, "logging in failed for an unknown reason")
+}
@@ -0,0 +1,123 @@
+package ao3
+
+import (
+	"errors"
+	"net/http"
+	"testing"
+	"github.com/stretchr/testify/assert"
+)
+
+const testLoginFormPage = `<html><head><meta name="csrf-param" content="authenticity_token" /><meta name="csrf-token" content="form-token" /></head>
+<body class="logged-out"><div id="main"><form id="new_user" action="/users/login" method="post"><input type="hidden" name="authenticity_token" value="form-token" /></form></div></body></html>`
+
+const testLoggedInPage = `<html><head><meta name="csrf-token" content="session-token" /></head><body class="logged-in"><div id="greeting"><ul class="user navigation actions"><li class="dropdown"><a class="dropdown-toggle" href="/users/reader">Hi, reader!</a></li></ul></div></body></html>`
+
+// newLoginServer returns a client pointed at a fake AO3 which accepts the
+// user "reader" with the password "hunter2", and locks the user "locked". Forms
+// submitted while logged in must carry the token "session-token".
+func newLoginServer(t *testing.T) (*AO3Client, func()) {
+	client, server := newTestClient(t, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		session, _ := r.Cookie("_otwarchive_session")
+
+		switch {
+		case r.URL.Path == "/users/login" && r.Method == http.MethodGet:
+			http.SetCookie(w, &http.Cookie{Name: "_otwarchive_session", Value: "anonymous", Path: "/"})
+			w.Write([]byte(testLoginFormPage))
+
+		case r.URL.Path == "/users/login" && r.Method == http.MethodPost:
+			if session == nil || r.PostFormValue("authenticity_token") != "form-token" {
+				w.WriteHeader(http.StatusUnprocessableEntity)
+				return
+			}
+
+			switch {
+			case r.PostFormValue("user[login]") == "locked":
+				w.Write([]byte(`<body class="logged-out"><div class="flash error">Your account has been locked for 5 minutes due to too many failed login attempts.</div></body>`))
+			case r.PostFormValue("user[login]") != "reader" || r.PostFormValue("user[password]") != "hunter2":
+				w.Write([]byte(`<body class="logged-out"><div class="flash error">The password or user name you entered doesn't match our records.</div></body>`))
+			default:
+				http.SetCookie(w, &http.Cookie{Name: "_otwarchive_session", Value: "reader", Path: "/"})
+				http.Redirect(w, r, "/users/reader", http.StatusFound)
+			}
+
+		case r.URL.Path == "/users/reader" || (r.URL.Path == "/" && session != nil && session.Value == "reader"):
+			w.Write([]byte(testLoggedInPage))
+
+		case r.URL.Path == "/":
+			w.Write([]byte(testLoginFormPage))
+
+		case r.URL.Path == "/users/logout" && r.Method == http.MethodPost:
+			if session == nil || session.Value != "reader" || r.PostFormValue("authenticity_token") != "session-token" {
+				w.WriteHeader(http.StatusUnprocessableEntity)
+				return
+			}
+			http.SetCookie(w, &http.Cookie{Name: "_otwarchive_session", Value: "anonymous", Path: "/"})
+			http.Redirect(w, r, "/", http.StatusFound)
+
+		case r.URL.Path == "/media":
+			if session != nil && session.Value == "reader" {
+				w.Write([]byte(`<div class="medium listbox group"><h3 class="heading"><a href="/media/Restricted/fandoms">Restricted</a></h3></div>`))
+				return
+			}
+			w.Write([]byte(testFandomCategoriesPage))
+
+		default:
+			http.NotFound(w, r)
+		}
+	}))
+
+	return client, server.Close
+}
+
+// TestAuthenticate ensures logging in stores the session so that later
+// requests see logged-in pages
+func TestAuthenticate(t *testing.T) {
+	client, closeServer := newLoginServer(t)
+	defer closeServer()
+
+	categories, err := client.GetFandomCategories()
+	if err != nil {
+		t.Fatal(err.Error())
+	}
+	assert.Equal(t, "Books & Literature", categories[0].Name)
+	assert.Equal(t, "", client.Username())
+
+	if err := client.Authenticate("reader", "hunter2"); err != nil {
+		t.Fatal(err.Error())
+	}
+
+	assert.Equal(t, "reader", client.Username())
+	assert.Equal(t, "session-token", client.csrfToken)
+
+	categories, err = client.GetFandomCategories()
+	if err != nil {
+		t.Fatal(err.Error())
+	}
+	assert.Equal(t, "Restricted", categories[0].Name)
+}
+
+// TestAuthenticateErrors ensures failed logins are returned as typed errors
+func TestAuthenticateErrors(t *testing.T) {
+	client, closeServer := newLoginServer(t)
+	defer closeServer()
+
+	err := client.Authenticate("reader", "wrong")
+	if assert.NotNil(t, err) {
+		assert.True(t, errors.Is(err, ErrBadCredentials))
+		assert.Equal(t, http.StatusUnauthorized, err.Code())
+		assert.Contains(t, err.Error(), "doesn't match our records")
+	}
+
+	err = client.Authenticate("locked", "hunter2")
+	if assert.NotNil(t, err) {
+		assert.True(t, errors.Is(err, ErrAccountLocked))
+		assert.Equal(t, http.StatusLocked, err.Code())
+	}
+
+	err = client.Authenticate("reader", "")
+	if assert.NotNil(t, err) {
+		assert.True(t, errors.Is(err, ErrBadCredentials))
+	}
+
+	assert.Equal(t, "", client.Username())
+}
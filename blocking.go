@@ -0,0 +1,359 @@
+package ao3
+
+import (
+	"bytes"
+	"context"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"github.com/PuerkitoBio/goquery"
+)
+
+// userList is one of the lists of users kept by AO3 for the logged-in user
+type userList struct {
+	// path is the list's path below /users/[user]/, e.g. "muted/users"
+	path string
+	// param is the query parameter naming the user on confirmation pages
+	param string
+	// add and remove are the names of the list's confirmation pages
+	add    string
+	remove string
+}
+
+var mutedUserList = userList{path: "muted/users", param: "muted_id", add: "confirm_mute", remove: "confirm_unmute"}
+var blockedUserList = userList{path: "blocked/users", param: "blocked_id", add: "confirm_block", remove: "confirm_unblock"}
+
+// UserList is a page of the users muted or blocked by the logged-in user. The
+// slug of each user is their user name.
+type UserList struct {
+	Users []Link
+
+	// Pagination-related values
+	IsPaginated bool
+	CurrentPage int
+	LastPage    int
+
+	// Diagnostics describes the users which could not be parsed in lenient
+	// mode
+	Diagnostics []ParseDiagnostic
+}
+
+// ListMutedUsers returns a page of the users muted by the logged-in user,
+// whose works, comments and bookmarks AO3 hides from them
+//
+// Endpoint: https://archiveofourown.org/users/[user]/muted/users?page=[page]
+func (client *AO3Client) ListMutedUsers(page int) (*UserList, *AO3Error) {
+	return client.ListMutedUsersWithContext(context.Background(), page)
+}
+
+// ListMutedUsersWithContext is ListMutedUsers with a context which cancels the
+// request when it is done
+func (client *AO3Client) ListMutedUsersWithContext(ctx context.Context, page int) (*UserList, *AO3Error) {
+	return client.listUsers(ctx, mutedUserList, page, "fetching muted users")
+}
+
+// MuteUser mutes a user by their user name on behalf of the logged-in user
+//
+// Endpoint: https://archiveofourown.org/users/[user]/muted/users
+func (client *AO3Client) MuteUser(username string) *AO3Error {
+	return client.MuteUserWithContext(context.Background(), username)
+}
+
+// MuteUserWithContext is MuteUser with a context which cancels the requests
+// when it is done
+func (client *AO3Client) MuteUserWithContext(ctx context.Context, username string) *AO3Error {
+	if ao3Err := client.changeUserList(ctx, mutedUserList, mutedUserList.add, username, "muting user"); ao3Err != nil {
+		return ao3Err
+	}
+
+	client.setMuted(username, true)
+	return nil
+}
+
+// UnmuteUser unmutes a user by their user name on behalf of the logged-in user
+//
+// Endpoint: https://archiveofourown.org/users/[user]/muted/users/[mute]
+func (client *AO3Client) UnmuteUser(username string) *AO3Error {
+	return client.UnmuteUserWithContext(context.Background(), username)
+}
+
+// UnmuteUserWithContext is UnmuteUser with a context which cancels the
+// requests when it is done
+func (client *AO3Client) UnmuteUserWithContext(ctx context.Context, username string) *AO3Error {
+	if ao3Err := client.changeUserList(ctx, mutedUserList, mutedUserList.remove, username, "unmuting user"); ao3Err != nil {
+		return ao3Err
+	}
+
+	client.setMuted(username, false)
+	return nil
+}
+
+// ListBlockedUsers returns a page of the users blocked by the logged-in user,
+// who may not comment on or reply to their works and comments
+//
+// Endpoint: https://archiveofourown.org/users/[user]/blocked/users?page=[page]
+func (client *AO3Client) ListBlockedUsers(page int) (*UserList, *AO3Error) {
+	return client.ListBlockedUsersWithContext(context.Background(), page)
+}
+
+// ListBlockedUsersWithContext is ListBlockedUsers with a context which cancels
+// the request when it is done
+func (client *AO3Client) ListBlockedUsersWithContext(ctx context.Context, page int) (*UserList, *AO3Error) {
+	return client.listUsers(ctx, blockedUserList, page, "fetching blocked users")
+}
+
+// BlockUser blocks a user by their user name on behalf of the logged-in user
+//
+// Endpoint: https://archiveofourown.org/users/[user]/blocked/users
+func (client *AO3Client) BlockUser(username string) *AO3Error {
+	return client.BlockUserWithContext(context.Background(), username)
+}
+
+// BlockUserWithContext is BlockUser with a context which cancels the requests
+// when it is done
+func (client *AO3Client) BlockUserWithContext(ctx context.Context, username string) *AO3Error {
+	return client.changeUserList(ctx, blockedUserList, blockedUserList.add, username, "blocking user")
+}
+
+// UnblockUser unblocks a user by their user name on behalf of the logged-in
+// user
+//
+// Endpoint: https://archiveofourown.org/users/[user]/blocked/users/[block]
+func (client *AO3Client) UnblockUser(username string) *AO3Error {
+	return client.UnblockUserWithContext(context.Background(), username)
+}
+
+// UnblockUserWithContext is UnblockUser with a context which cancels the
+// requests when it is done
+func (client *AO3Client) UnblockUserWithContext(ctx context.Context, username string) *AO3Error {
+	return client.changeUserList(ctx, blockedUserList, blockedUserList.remove, username, "unblocking user")
+}
+
+// listUsers fetches and parses a page of one of the logged-in user's lists
+func (client *AO3Client) listUsers(ctx context.Context, list userList, page int, action string) (*UserList, *AO3Error) {
+	if ao3Err := client.requireLogin(action); ao3Err != nil {
+		return nil, ao3Err
+	}
+
+	endpoint := client.userListEndpoint(list)
+	if page != 0 {
+		endpoint += "?page=" + strconv.Itoa(page)
+	}
+
+	body, ao3Err := client.get(ctx, endpoint, 0, action)
+	if ao3Err != nil {
+		return nil, ao3Err
+	}
+
+	doc, err := goquery.NewDocumentFromReader(bytes.NewReader(body))
+	if err != nil {
+		return nil, WrapError(http.StatusUnprocessableEntity, err, "parsing user list page with goquery failed")
+	}
+
+	users := UserList{Users: []Link{}}
+	state := client.newParseState(client.endpointURL(endpoint), client.Lenient)
+
+	pages, ao3Err := parsePagination(state, doc, 1)
+	if ao3Err != nil {
+		return nil, ao3Err
+	}
+	users.IsPaginated = pages.isPaginated
+	users.CurrentPage = pages.currentPage
+	users.LastPage = pages.lastPage
+
+	userMatches := doc.Find("li.user")
+	for i := range userMatches.Nodes {
+		node := userMatches.Eq(i)
+
+		user, ao3Err := client.parseListedUser(node)
+		if ao3Err != nil {
+			if state.fail("Users", "li.user h4.heading a", node, ao3Err) {
+				return nil, ao3Err
+			}
+			continue
+		}
+
+		users.Users = append(users.Users, user)
+	}
+
+	users.Diagnostics = state.diagnostics
+
+	return &users, nil
+}
+
+// parseListedUser extracts a user from their entry in a list of users
+func (client *AO3Client) parseListedUser(node *goquery.Selection) (Link, *AO3Error) {
+	user := Link{}
+
+	node.Find("h4.heading a[href]").EachWithBreak(func(_ int, linkNode *goquery.Selection) bool {
+		link, _ := linkNode.Attr("href")
+		if matches := userLinkRegex.FindStringSubmatch(client.relativeLink(link)); len(matches) == 2 {
+			user.Text = strings.TrimSpace(linkNode.Text())
+			user.Slug, _ = url.PathUnescape(matches[1])
+			return false
+		}
+		return true
+	})
+
+	if user.Slug == "" {
+		return user, NewError(http.StatusUnprocessableEntity, "unable to find user link")
+	}
+
+	return user, nil
+}
+
+// changeUserList fetches one of the pages AO3 asks to confirm adding a user to
+// or removing one from a list on, and submits its form
+func (client *AO3Client) changeUserList(ctx context.Context, list userList, confirmation string, username string, action string) *AO3Error {
+	if ao3Err := client.requireLogin(action); ao3Err != nil {
+		return ao3Err
+	}
+
+	listEndpoint := client.userListEndpoint(list)
+	query := url.Values{}
+	query.Set(list.param, username)
+
+	doc, ao3Err := client.fetchForm(ctx, listEndpoint+"/"+confirmation+"?"+query.Encode(), action)
+	if ao3Err != nil {
+		return ao3Err
+	}
+
+	// The confirmation form is the one submitted to the list
+	var formNode *goquery.Selection
+	doc.Find("form[action]").EachWithBreak(func(_ int, node *goquery.Selection) bool {
+		formAction, _ := node.Attr("action")
+		if strings.HasPrefix(client.relativeLink(formAction), listEndpoint) {
+			formNode = node
+			return false
+		}
+		return true
+	})
+	if formNode == nil {
+		if ao3Err := validationError(doc, action); ao3Err != nil {
+			return ao3Err
+		}
+		return NewError(http.StatusUnprocessableEntity, "unable to find confirmation form")
+	}
+
+	form := formValues(formNode)
+	submitNode := formNode.Find(`[type="submit"][name]`).First()
+	if name, ok := submitNode.Attr("name"); ok {
+		value, _ := submitNode.Attr("value")
+		form.Set(name, value)
+	}
+
+	formAction, _ := formNode.Attr("action")
+	res, ao3Err := client.submit(ctx, client.relativeLink(formAction), form, action)
+	if ao3Err != nil {
+		return ao3Err
+	}
+
+	_, ao3Err = checkSubmission(res.body, action)
+	return ao3Err
+}
+
+// userListEndpoint returns the endpoint of one of the logged-in user's lists
+func (client *AO3Client) userListEndpoint(list userList) string {
+	return "/users/" + url.PathEscape(client.Username()) + "/" + list.path
+}
+
+// hideMutedWorks removes the works created by users muted by the logged-in
+// user from works if HideMutedCreators is set, fetching the muted users first
+// if needed. If they cannot be fetched, the works are returned unfiltered with
+// a diagnostic in lenient mode and the error is returned otherwise.
+func (client *AO3Client) hideMutedWorks(ctx context.Context, state *parseState, works []IndexedWork) ([]IndexedWork, *AO3Error) {
+	if !client.HideMutedCreators || client.Username() == "" {
+		return works, nil
+	}
+
+	muted, ao3Err := client.mutedUserSet(ctx)
+	if ao3Err != nil {
+		if state.fail("MutedCreators", "li.user", nil, ao3Err) {
+			return nil, ao3Err
+		}
+		return works, nil
+	}
+	if len(muted) == 0 {
+		return works, nil
+	}
+
+	visible := make([]IndexedWork, 0, len(works))
+	for _, work := range works {
+		isMuted := false
+		for _, author := range work.Authors {
+			if muted[strings.ToLower(author.Slug)] {
+				isMuted = true
+				break
+			}
+		}
+
+		if !isMuted {
+			visible = append(visible, work)
+		}
+	}
+
+	return visible, nil
+}
+
+// mutedUserSet returns the lowercased names of the users muted by the
+// logged-in user, fetching every page of the list once per session
+func (client *AO3Client) mutedUserSet(ctx context.Context) (map[string]bool, *AO3Error) {
+	client.sessionMu.RLock()
+	username := client.username
+	muted := client.mutedUsers
+	client.sessionMu.RUnlock()
+
+	if muted != nil {
+		return muted, nil
+	}
+
+	muted = map[string]bool{}
+	for page := 1; ; page++ {
+		users, ao3Err := client.ListMutedUsersWithContext(ctx, page)
+		if ao3Err != nil {
+			return nil, ao3Err
+		}
+
+		for _, user := range users.Users {
+			muted[strings.ToLower(user.Slug)] = true
+		}
+
+		if !users.IsPaginated || users.CurrentPage >= users.LastPage {
+			break
+		}
+	}
+
+	// Another user may have logged in while the list was fetched, in which
+	// case it is not theirs
+	client.sessionMu.Lock()
+	if client.username == username {
+		client.mutedUsers = muted
+	}
+	client.sessionMu.Unlock()
+
+	return muted, nil
+}
+
+// setMuted updates the muted users fetched for HideMutedCreators, if any,
+// after a user was muted or unmuted
+func (client *AO3Client) setMuted(username string, isMuted bool) {
+	client.sessionMu.Lock()
+	defer client.sessionMu.Unlock()
+
+	if client.mutedUsers == nil {
+		return
+	}
+
+	// The set is replaced rather than modified as it may be in use
+	muted := make(map[string]bool, len(client.mutedUsers)+1)
+	for name := range client.mutedUsers {
+		muted[name] = true
+	}
+	if isMuted {
+		muted[strings.ToLower(username)] = true
+	} else {
+		delete(muted, strings.ToLower(username))
+	}
+	client.mutedUsers = muted
+}
@@ -0,0 +1,238 @@
+package ao3
+
+import (
+	"errors"
+	"net/http"
+	"sort"
+	"strings"
+	"sync"
+	"testing"
+	"github.com/stretchr/testify/assert"
+)
+
+// newBlockingServer returns a client pointed at a fake AO3 on which "reader"
+// muted "Troll" and blocked "spammer", and the tag "Fluff" lists a work by
+// "author" and one by "troll". It also returns how many pages of muted users
+// were requested.
+func newBlockingServer(t *testing.T) (*AO3Client, func() int, func()) {
+	var mu sync.Mutex
+	lists := map[string]map[string]bool{
+		"muted":   {"Troll": true},
+		"blocked": {"spammer": true},
+	}
+	mutedListings := 0
+
+	client, _, closeServer := newTestArchive(t, false, func(w http.ResponseWriter, r *http.Request) {
+		mu.Lock()
+		defer mu.Unlock()
+
+		parts := strings.Split(strings.TrimPrefix(r.URL.Path, "/users/reader/"), "/")
+
+		switch {
+		case r.URL.Path == "/tags/Fluff/works":
+			troll := strings.Replace(testReading("2", "0", ""), `/users/author/pseuds/author">author`, `/users/troll/pseuds/troll">troll`, 1)
+			writeTestPage(w, `<h2 class="heading">1 - 2 of 2 Works in Fluff</h2><ol class="work index group">`+testReading("1", "0", "")+troll+`</ol>`)
+
+		case len(parts) == 2 && parts[1] == "users" && lists[parts[0]] != nil && r.Method == http.MethodGet:
+			if parts[0] == "muted" && r.URL.Query().Get("page") != "" {
+				mutedListings++
+			}
+			names := []string{}
+			for name := range lists[parts[0]] {
+				names = append(names, name)
+			}
+			sort.Strings(names)
+
+			entries := ""
+			for _, name := range names {
+				entries += `<li class="user pseud picture blurb group"><div class="header module"><h4 class="heading"><a href="/users/` + name + `">` + name + `</a></h4></div></li>`
+			}
+			writeTestPage(w, `<ul class="index group">`+entries+`</ul>`)
+
+		case len(parts) == 3 && strings.HasPrefix(parts[2], "confirm_"):
+			name := r.URL.Query().Get(parts[0] + "_id")
+			if name == "nobody" {
+				http.NotFound(w, r)
+				return
+			}
+			if strings.HasPrefix(parts[2], "confirm_un") {
+				writeTestPage(w, `<form action="/users/reader/`+parts[0]+`/users/`+name+`" method="post"><input type="hidden" name="_method" value="delete" /><input type="submit" name="commit" value="Yes" /></form>`)
+				return
+			}
+			writeTestPage(w, `<form action="/users/reader/`+parts[0]+`/users?`+parts[0]+`_id=`+name+`" method="post"><input type="submit" name="commit" value="Yes" /></form>`)
+
+		case len(parts) == 2 && parts[1] == "users" && lists[parts[0]] != nil:
+			if r.PostFormValue("commit") != "Yes" {
+				w.WriteHeader(http.StatusBadRequest)
+				return
+			}
+			lists[parts[0]][r.URL.Query().Get(parts[0]+"_id")] = true
+			http.Redirect(w, r, "/users/reader/"+parts[0]+"/users", http.StatusFound)
+
+		case len(parts) == 3 && lists[parts[0]] != nil && r.PostFormValue("_method") == "delete":
+			delete(lists[parts[0]], parts[2])
+			http.Redirect(w, r, "/users/reader/"+parts[0]+"/users", http.StatusFound)
+
+		default:
+			http.NotFound(w, r)
+		}
+	})
+
+	mutedListingCount := func() int {
+		mu.Lock()
+		defer mu.Unlock()
+		return mutedListings
+	}
+
+	return client, mutedListingCount, closeServer
+}
+
+// TestMuteAndBlockUsers ensures users are listed, added to and removed from
+// the muted and blocked lists
+func TestMuteAndBlockUsers(t *testing.T) {
+	client, _, closeServer := newBlockingServer(t)
+	defer closeServer()
+
+	_, err := client.ListMutedUsers(0)
+	if assert.NotNil(t, err) {
+		assert.True(t, errors.Is(err, ErrRestricted))
+	}
+
+	importTestSession(t, client, "reader")
+
+	muted, err := client.ListMutedUsers(0)
+	if err != nil {
+		t.Fatal(err.Error())
+	}
+	assert.Equal(t, []Link{{Text: "Troll", Slug: "Troll"}}, muted.Users)
+
+	if err := client.MuteUser("lurker"); err != nil {
+		t.Fatal(err.Error())
+	}
+	if err := client.UnmuteUser("Troll"); err != nil {
+		t.Fatal(err.Error())
+	}
+	muted, err = client.ListMutedUsers(0)
+	if err != nil {
+		t.Fatal(err.Error())
+	}
+	assert.Equal(t, []Link{{Text: "lurker", Slug: "lurker"}}, muted.Users)
+
+	if err := client.BlockUser("heckler"); err != nil {
+		t.Fatal(err.Error())
+	}
+	if err := client.UnblockUser("spammer"); err != nil {
+		t.Fatal(err.Error())
+	}
+	blocked, err := client.ListBlockedUsers(0)
+	if err != nil {
+		t.Fatal(err.Error())
+	}
+	assert.Equal(t, []Link{{Text: "heckler", Slug: "heckler"}}, blocked.Users)
+
+	err = client.BlockUser("nobody")
+	if assert.NotNil(t, err) {
+		assert.True(t, errors.Is(err, ErrNotFound))
+	}
+}
+
+// TestHideMutedCreators ensures works by muted users are left out of listings,
+// fetching the muted users once and following later changes
+func TestHideMutedCreators(t *testing.T) {
+	client, mutedListings, closeServer := newBlockingServer(t)
+	defer closeServer()
+
+	importTestSession(t, client, "reader")
+
+	works, err := client.GetTagWorks("Fluff", 0)
+	if err != nil {
+		t.Fatal(err.Error())
+	}
+	assert.Len(t, works.Works, 2)
+	assert.Equal(t, 0, mutedListings())
+
+	client.HideMutedCreators = true
+
+	works, err = client.GetTagWorks("Fluff", 0)
+	if err != nil {
+		t.Fatal(err.Error())
+	}
+	if assert.Len(t, works.Works, 1) {
+		assert.Equal(t, "author", works.Works[0].Authors[0].Slug)
+	}
+
+	if err := client.UnmuteUser("Troll"); err != nil {
+		t.Fatal(err.Error())
+	}
+	if err := client.MuteUser("author"); err != nil {
+		t.Fatal(err.Error())
+	}
+
+	works, err = client.GetTagWorks("Fluff", 0)
+	if err != nil {
+		t.Fatal(err.Error())
+	}
+	if assert.Len(t, works.Works, 1) {
+		assert.Equal(t, "troll", works.Works[0].Authors[0].Slug)
+	}
+	assert.Equal(t, 1, mutedListings())
+}
+
+// TestHideMutedCreatorsFailure ensures works are listed unfiltered in lenient
+// mode when the muted users cannot be fetched
+func TestHideMutedCreatorsFailure(t *testing.T) {
+	client, _, closeServer := newBlockingServer(t)
+	defer closeServer()
+
+	importTestSession(t, client, "reader")
+	client.HideMutedCreators = true
+	client.RetryPolicy = nil
+	client.Use(Middleware{
+		BeforeRequest: func(req *http.Request) error {
+			if strings.Contains(req.URL.Path, "/muted/") {
+				return errors.New("muted users unavailable")
+			}
+			return nil
+		},
+	})
+
+	_, err := client.GetTagWorks("Fluff", 0)
+	assert.NotNil(t, err)
+
+	client.Lenient = true
+
+	works, err := client.GetTagWorks("Fluff", 0)
+	if err != nil {
+		t.Fatal(err.Error())
+	}
+	assert.Len(t, works.Works, 2)
+	if assert.Len(t, works.Diagnostics, 1) {
+		assert.Equal(t, "MutedCreators", works.Diagnostics[0].Field)
+	}
+}
+
+// TestHideMutedCreatorsRelogin ensures muted users fetched for a user are not
+// kept once another user logged in during the fetch
+func TestHideMutedCreatorsRelogin(t *testing.T) {
+	client, _, closeServer := newBlockingServer(t)
+	defer closeServer()
+
+	importTestSession(t, client, "reader")
+	client.HideMutedCreators = true
+	client.Use(Middleware{
+		BeforeRequest: func(req *http.Request) error {
+			if strings.Contains(req.URL.Path, "/muted/") {
+				client.setSession("writer", "")
+			}
+			return nil
+		},
+	})
+
+	if _, err := client.GetTagWorks("Fluff", 0); err != nil {
+		t.Fatal(err.Error())
+	}
+
+	client.sessionMu.RLock()
+	defer client.sessionMu.RUnlock()
+	assert.Nil(t, client.mutedUsers)
+}
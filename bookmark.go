@@ -0,0 +1,194 @@
+package ao3
+
+import (
+	"context"
+	"net/http"
+	"net/url"
+	"regexp"
+	"strings"
+	"github.com/PuerkitoBio/goquery"
+)
+
+var bookmarkLinkRegex = regexp.MustCompile(`^/bookmarks/(\d+)`)
+
+// BookmarkOptions describes a bookmark to create, or the new state of one to
+// update
+type BookmarkOptions struct {
+	// Notes are the bookmarker's notes in HTML, sanitized with the client's
+	// HtmlSanitizer before they are submitted
+	Notes string
+	// Tags are the bookmarker's tags
+	Tags []string
+	// Collections are the names of the collections the bookmark is added to
+	Collections []string
+
+	// IsPrivate hides the bookmark from everyone but the bookmarker
+	IsPrivate bool
+	// IsRec marks the bookmark as a recommendation
+	IsRec bool
+}
+
+// CreateWorkBookmark bookmarks a work as the logged-in user's default pseud
+// and returns the ID of the bookmark. Bookmarks AO3 rejects, e.g. because the
+// notes are too long, return an error matching ErrValidation which wraps a
+// ValidationError.
+//
+// Endpoint: https://archiveofourown.org/works/[work]/bookmarks
+func (client *AO3Client) CreateWorkBookmark(id string, options BookmarkOptions) (string, *AO3Error) {
+	return client.CreateWorkBookmarkWithContext(context.Background(), id, options)
+}
+
+// CreateWorkBookmarkWithContext is CreateWorkBookmark with a context which
+// cancels the requests when it is done
+func (client *AO3Client) CreateWorkBookmarkWithContext(ctx context.Context, id string, options BookmarkOptions) (string, *AO3Error) {
+	return client.createBookmark(ctx, "/works/"+id+"/bookmarks", options)
+}
+
+// CreateSeriesBookmark bookmarks a series as the logged-in user's default
+// pseud and returns the ID of the bookmark, like CreateWorkBookmark
+//
+// Endpoint: https://archiveofourown.org/series/[series]/bookmarks
+func (client *AO3Client) CreateSeriesBookmark(id string, options BookmarkOptions) (string, *AO3Error) {
+	return client.CreateSeriesBookmarkWithContext(context.Background(), id, options)
+}
+
+// CreateSeriesBookmarkWithContext is CreateSeriesBookmark with a context which
+// cancels the requests when it is done
+func (client *AO3Client) CreateSeriesBookmarkWithContext(ctx context.Context, id string, options BookmarkOptions) (string, *AO3Error) {
+	return client.createBookmark(ctx, "/series/"+id+"/bookmarks", options)
+}
+
+// UpdateBookmark replaces the notes, tags, collections and flags of one of the
+// logged-in user's bookmarks with options
+//
+// Endpoint: https://archiveofourown.org/bookmarks/[bookmark]
+func (client *AO3Client) UpdateBookmark(id string, options BookmarkOptions) *AO3Error {
+	return client.UpdateBookmarkWithContext(context.Background(), id, options)
+}
+
+// UpdateBookmarkWithContext is UpdateBookmark with a context which cancels the
+// request when it is done
+func (client *AO3Client) UpdateBookmarkWithContext(ctx context.Context, id string, options BookmarkOptions) *AO3Error {
+	if ao3Err := client.requireLogin("updating bookmark"); ao3Err != nil {
+		return ao3Err
+	}
+
+	form := options.form(client.HtmlSanitizer)
+	form.Set("_method", "patch")
+	form.Set("commit", "Update")
+
+	res, ao3Err := client.submit(ctx, "/bookmarks/"+id, form, "updating bookmark")
+	if ao3Err != nil {
+		return ao3Err
+	}
+
+	_, ao3Err = client.bookmarkID(res, "updating bookmark")
+	return ao3Err
+}
+
+// DeleteBookmark deletes one of the logged-in user's bookmarks
+//
+// Endpoint: https://archiveofourown.org/bookmarks/[bookmark]
+func (client *AO3Client) DeleteBookmark(id string) *AO3Error {
+	return client.DeleteBookmarkWithContext(context.Background(), id)
+}
+
+// DeleteBookmarkWithContext is DeleteBookmark with a context which cancels the
+// request when it is done
+func (client *AO3Client) DeleteBookmarkWithContext(ctx context.Context, id string) *AO3Error {
+	if ao3Err := client.requireLogin("deleting bookmark"); ao3Err != nil {
+		return ao3Err
+	}
+
+	form := url.Values{}
+	form.Set("_method", "delete")
+
+	res, ao3Err := client.submit(ctx, "/bookmarks/"+id, form, "deleting bookmark")
+	if ao3Err != nil {
+		return ao3Err
+	}
+
+	_, ao3Err = checkSubmission(res.body, "deleting bookmark")
+	return ao3Err
+}
+
+// createBookmark fetches the new bookmark form of a work or series for the
+// user's default pseud and submits it to endpoint
+func (client *AO3Client) createBookmark(ctx context.Context, endpoint string, options BookmarkOptions) (string, *AO3Error) {
+	if ao3Err := client.requireLogin("creating bookmark"); ao3Err != nil {
+		return "", ao3Err
+	}
+
+	doc, ao3Err := client.fetchForm(ctx, endpoint+"/new", "fetching bookmark form")
+	if ao3Err != nil {
+		return "", ao3Err
+	}
+
+	pseudID := formPseudID(doc, "bookmark[pseud_id]")
+	if pseudID == "" {
+		return "", NewError(http.StatusUnprocessableEntity, "unable to find pseud in bookmark form")
+	}
+
+	form := options.form(client.HtmlSanitizer)
+	form.Set("bookmark[pseud_id]", pseudID)
+	form.Set("commit", "Create")
+
+	res, ao3Err := client.submit(ctx, endpoint, form, "creating bookmark")
+	if ao3Err != nil {
+		return "", ao3Err
+	}
+
+	return client.bookmarkID(res, "creating bookmark")
+}
+
+// bookmarkID extracts the ID of the bookmark AO3 redirected to after a form
+// was submitted, or the reasons the form was rejected
+func (client *AO3Client) bookmarkID(res *response, action string) (string, *AO3Error) {
+	if _, ao3Err := checkSubmission(res.body, action); ao3Err != nil {
+		return "", ao3Err
+	}
+
+	if res.url != nil {
+		if matches := bookmarkLinkRegex.FindStringSubmatch(client.relativeLink(res.url.String())); len(matches) == 2 {
+			return matches[1], nil
+		}
+	}
+
+	return "", NewError(http.StatusUnprocessableEntity, "unable to find bookmark after "+action)
+}
+
+// form encodes the options as the fields of AO3's bookmark form
+func (options BookmarkOptions) form(sanitizer *Sanitizer) url.Values {
+	form := url.Values{}
+	form.Set("bookmark[bookmarker_notes]", sanitizer.Sanitize(options.Notes))
+	form.Set("bookmark[tag_string]", strings.Join(options.Tags, ","))
+	form.Set("bookmark[collection_names]", strings.Join(options.Collections, ","))
+	form.Set("bookmark[private]", formBool(options.IsPrivate))
+	form.Set("bookmark[rec]", formBool(options.IsRec))
+
+	return form
+}
+
+// formPseudID returns the pseud selected by default in a form's pseud field,
+// which is a select for users with several pseuds and hidden otherwise
+func formPseudID(doc *goquery.Document, name string) string {
+	field := `[name="` + name + `"]`
+
+	if id, ok := doc.Find("select" + field + " option[selected]").First().Attr("value"); ok && id != "" {
+		return id
+	}
+	if id, ok := doc.Find("select" + field + " option").First().Attr("value"); ok && id != "" {
+		return id
+	}
+
+	id, _ := doc.Find("input" + field).First().Attr("value")
+	return id
+}
+
+// formBool encodes a checkbox the way Rails submits it
+func formBool(value bool) string {
+	if value {
+		return "1"
+	}
+	return "0"
+}
@@ -0,0 +1,139 @@
+package ao3
+
+import (
+	"errors"
+	"net/http"
+	"net/url"
+	"testing"
+	"github.com/stretchr/testify/assert"
+)
+
+// newBookmarkServer returns a client pointed at a fake AO3 which lets "reader"
+// bookmark work 1 and series 2 and manage bookmark 100, recording the forms it
+// receives
+func newBookmarkServer(t *testing.T) (*AO3Client, map[string]url.Values, func()) {
+	return newTestArchive(t, false, func(w http.ResponseWriter, r *http.Request) {
+		switch {
+		case r.URL.Path == "/works/1/bookmarks/new":
+			writeTestPage(w, `<form action="/works/1/bookmarks" method="post"><select name="bookmark[pseud_id]" id="bookmark_pseud_id"><option value="41">reader</option><option selected="selected" value="42">alt</option></select></form>`)
+
+		case r.URL.Path == "/series/2/bookmarks/new":
+			writeTestPage(w, `<form action="/series/2/bookmarks" method="post"><input type="hidden" name="bookmark[pseud_id]" value="7" /></form>`)
+
+		case (r.URL.Path == "/works/1/bookmarks" || r.URL.Path == "/series/2/bookmarks") && r.Method == http.MethodPost:
+			if len(r.PostFormValue("bookmark[bookmarker_notes]")) > 40 {
+				w.Write([]byte(`<body><div id="error" class="error"><h4>Sorry! We couldn't save this bookmark because:</h4><ul><li>Notes must be less than 40 characters long.</li><li>Tags are invalid.</li></ul></div></body>`))
+				return
+			}
+			http.Redirect(w, r, "/bookmarks/100", http.StatusFound)
+
+		case r.URL.Path == "/bookmarks/100" && r.Method == http.MethodPost:
+			if r.PostFormValue("_method") == "delete" {
+				http.Redirect(w, r, "/users/reader/bookmarks", http.StatusFound)
+				return
+			}
+			http.Redirect(w, r, "/bookmarks/100", http.StatusFound)
+
+		case r.URL.Path == "/bookmarks/100":
+			w.Write([]byte(`<body><div class="flash notice">Bookmark was successfully created.</div></body>`))
+
+		case r.URL.Path == "/users/reader/bookmarks":
+			w.Write([]byte(`<body><div class="flash notice">Bookmark was successfully deleted.</div></body>`))
+
+		case r.Method == http.MethodPost:
+			w.Write([]byte(`<body><div class="flash error">Sorry, you don't have permission to access the page you were trying to reach.</div></body>`))
+
+		default:
+			http.NotFound(w, r)
+		}
+	})
+}
+
+// TestCreateBookmark ensures bookmarks are created with the options encoded as
+// AO3's form and the notes sanitized
+func TestCreateBookmark(t *testing.T) {
+	client, forms, closeServer := newBookmarkServer(t)
+	defer closeServer()
+
+	options := BookmarkOptions{
+		Notes:       `<p onclick="x()">Lovely</p>`,
+		Tags:        []string{"to reread", "fluff"},
+		Collections: []string{"recs"},
+		IsRec:       true,
+	}
+
+	_, err := client.CreateWorkBookmark("1", options)
+	if assert.NotNil(t, err) {
+		assert.True(t, errors.Is(err, ErrRestricted))
+	}
+
+	importTestSession(t, client, "reader")
+
+	id, err := client.CreateWorkBookmark("1", options)
+	if err != nil {
+		t.Fatal(err.Error())
+	}
+	assert.Equal(t, "100", id)
+
+	form := forms[" /works/1/bookmarks"]
+	assert.Equal(t, "42", form.Get("bookmark[pseud_id]"))
+	assert.Equal(t, "<p>Lovely</p>", form.Get("bookmark[bookmarker_notes]"))
+	assert.Equal(t, "to reread,fluff", form.Get("bookmark[tag_string]"))
+	assert.Equal(t, "recs", form.Get("bookmark[collection_names]"))
+	assert.Equal(t, "0", form.Get("bookmark[private]"))
+	assert.Equal(t, "1", form.Get("bookmark[rec]"))
+
+	id, err = client.CreateSeriesBookmark("2", BookmarkOptions{IsPrivate: true})
+	if err != nil {
+		t.Fatal(err.Error())
+	}
+	assert.Equal(t, "100", id)
+	assert.Equal(t, "7", forms[" /series/2/bookmarks"].Get("bookmark[pseud_id]"))
+	assert.Equal(t, "1", forms[" /series/2/bookmarks"].Get("bookmark[private]"))
+}
+
+// TestCreateBookmarkValidation ensures AO3's validation errors are returned as
+// a ValidationError
+func TestCreateBookmarkValidation(t *testing.T) {
+	client, _, closeServer := newBookmarkServer(t)
+	defer closeServer()
+
+	importTestSession(t, client, "reader")
+
+	_, err := client.CreateWorkBookmark("1", BookmarkOptions{Notes: "This note is far too long to be accepted by the server"})
+	if assert.NotNil(t, err) {
+		assert.True(t, errors.Is(err, ErrValidation))
+		assert.Equal(t, KindValidation, err.Kind())
+
+		var validation *ValidationError
+		if assert.True(t, errors.As(err, &validation)) {
+			assert.Equal(t, []string{"Notes must be less than 40 characters long.", "Tags are invalid."}, validation.Messages)
+		}
+	}
+}
+
+// TestUpdateAndDeleteBookmark ensures bookmarks are updated and deleted with
+// Rails' method override, and failures are reported from the flash
+func TestUpdateAndDeleteBookmark(t *testing.T) {
+	client, forms, closeServer := newBookmarkServer(t)
+	defer closeServer()
+
+	importTestSession(t, client, "reader")
+
+	if err := client.UpdateBookmark("100", BookmarkOptions{Notes: "Updated", IsPrivate: true}); err != nil {
+		t.Fatal(err.Error())
+	}
+	assert.Equal(t, "Updated", forms["patch /bookmarks/100"].Get("bookmark[bookmarker_notes]"))
+	assert.Equal(t, "1", forms["patch /bookmarks/100"].Get("bookmark[private]"))
+
+	if err := client.DeleteBookmark("100"); err != nil {
+		t.Fatal(err.Error())
+	}
+	assert.Contains(t, forms, "delete /bookmarks/100")
+
+	err := client.DeleteBookmark("101")
+	if assert.NotNil(t, err) {
+		assert.True(t, errors.Is(err, ErrValidation))
+		assert.Contains(t, err.Error(), "don't have permission")
+	}
+}
@@ -0,0 +1,95 @@
+package ao3
+
+import (
+	"strings"
+	"time"
+)
+
+// CacheEntry is a cached response body along with the validators AO3 sent
+// with it
+type CacheEntry struct {
+	Body         []byte
+	ETag         string
+	LastModified string
+
+	// Expires is when the entry must be revalidated
+	Expires time.Time
+}
+
+// Cache stores responses keyed by their URL and the authentication state of
+// the client which fetched them, so that logged-in and anonymous views of a
+// page are never mixed up. Implementations must be safe for concurrent use.
+//
+// Expired entries are kept by the client for revalidation, so implementations
+// should only evict entries to bound their size.
+type Cache interface {
+	Get(key string) (*CacheEntry, bool)
+	Set(key string, entry *CacheEntry)
+	Delete(key string)
+}
+
+// CachePurger is implemented by caches which can remove every entry whose key
+// matches, allowing a client to drop all of a user's pages at once, e.g. after
+// UpdatePreferences changed their markup. MemoryCache and DiskCache implement
+// it.
+type CachePurger interface {
+	Purge(match func(key string) bool)
+}
+
+// CacheTTLs are the durations responses from each endpoint are considered
+// fresh for. A zero duration disables caching for the endpoint.
+type CacheTTLs struct {
+	FandomCategories time.Duration
+	FandomCategory   time.Duration
+	TagWorks         time.Duration
+	Series           time.Duration
+	Work             time.Duration
+	Download         time.Duration
+}
+
+// DefaultCacheTTLs returns TTLs suited to how often each page changes: fandom
+// listings rarely change, while kudos and hit counts on works change often.
+func DefaultCacheTTLs() CacheTTLs {
+	return CacheTTLs{
+		FandomCategories: 24 * time.Hour,
+		FandomCategory:   24 * time.Hour,
+		TagWorks:         10 * time.Minute,
+		Series:           30 * time.Minute,
+		Work:             5 * time.Minute,
+		Download:         time.Hour,
+	}
+}
+
+// cacheKey returns the key of a URL for the client's current authentication
+// state, i.e. the logged-in user. Session cookies are left out as AO3 rotates
+// them on almost every response.
+func (client *AO3Client) cacheKey(rawURL string) string {
+	authState := "anonymous"
+	if username := client.Username(); username != "" {
+		authState = "user:" + username
+	}
+
+	return rawURL + " " + authState
+}
+
+// invalidateUser removes every cached response of the logged-in user if the
+// client's cache is a CachePurger
+func (client *AO3Client) invalidateUser() {
+	purger, ok := client.Cache.(CachePurger)
+	if !ok {
+		return
+	}
+
+	suffix := client.cacheKey("")
+	purger.Purge(func(key string) bool {
+		return strings.HasSuffix(key, suffix)
+	})
+}
+
+// invalidate removes the cached response of an endpoint for the client's
+// current authentication state, e.g. after the client changed the page
+func (client *AO3Client) invalidate(endpoint string) {
+	if client.Cache != nil {
+		client.Cache.Delete(client.cacheKey(client.endpointURL(endpoint)))
+	}
+}
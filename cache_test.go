@@ -0,0 +1,120 @@
+package ao3
+
+import (
+	"testing"
+	"time"
+	"net/http"
+	"net/http/cookiejar"
+	"net/url"
+	"sync/atomic"
+	"github.com/stretchr/testify/assert"
+)
+
+const testFandomCategoriesPage = `<div class="medium listbox group"><h3 class="heading"><a href="/media/Books%20*a*%20Literature/fandoms">Books &amp; Literature</a></h3></div>`
+
+// TestGetServesFreshEntriesFromCache ensures repeated requests within the TTL
+// do not reach the server
+func TestGetServesFreshEntriesFromCache(t *testing.T) {
+	var requests int32
+	client, server := newTestClient(t, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		atomic.AddInt32(&requests, 1)
+		w.Write([]byte(testFandomCategoriesPage))
+	}))
+	defer server.Close()
+
+	client.Cache = NewMemoryCache(10)
+
+	for i := 0; i < 3; i++ {
+		categories, err := client.GetFandomCategories()
+		if err != nil {
+			t.Fatal(err.Error())
+		}
+		assert.Equal(t, 1, len(categories))
+	}
+
+	assert.Equal(t, int32(1), atomic.LoadInt32(&requests))
+}
+
+// TestGetRevalidatesStaleEntries ensures expired entries are revalidated with
+// their ETag and reused when AO3 responds with 304 Not Modified
+func TestGetRevalidatesStaleEntries(t *testing.T) {
+	var requests, notModified int32
+	client, server := newTestClient(t, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		atomic.AddInt32(&requests, 1)
+		if r.Header.Get("If-None-Match") == `"v1"` {
+			atomic.AddInt32(&notModified, 1)
+			w.WriteHeader(http.StatusNotModified)
+			return
+		}
+		w.Header().Set("ETag", `"v1"`)
+		w.Write([]byte(testFandomCategoriesPage))
+	}))
+	defer server.Close()
+
+	client.Cache = NewMemoryCache(10)
+	client.CacheTTLs.FandomCategories = time.Nanosecond
+
+	for i := 0; i < 2; i++ {
+		categories, err := client.GetFandomCategories()
+		if err != nil {
+			t.Fatal(err.Error())
+		}
+		assert.Equal(t, 1, len(categories))
+		time.Sleep(time.Millisecond)
+	}
+
+	assert.Equal(t, int32(2), atomic.LoadInt32(&requests))
+	assert.Equal(t, int32(1), atomic.LoadInt32(&notModified))
+}
+
+// TestGetSkipsCacheWithZeroTTL ensures endpoints with a zero TTL are not cached
+func TestGetSkipsCacheWithZeroTTL(t *testing.T) {
+	var requests int32
+	client, server := newTestClient(t, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		atomic.AddInt32(&requests, 1)
+		w.Write([]byte("download"))
+	}))
+	defer server.Close()
+
+	client.Cache = NewMemoryCache(10)
+	client.CacheTTLs.Download = 0
+
+	for i := 0; i < 2; i++ {
+		if _, err := client.DownloadWork("Co/CodenameCarrot/5191202/A.html"); err != nil {
+			t.Fatal(err.Error())
+		}
+	}
+
+	assert.Equal(t, int32(2), atomic.LoadInt32(&requests))
+}
+
+// TestCacheKeyDependsOnAuthState ensures responses fetched as different users
+// are cached separately, while rotated session cookies keep the same key
+func TestCacheKeyDependsOnAuthState(t *testing.T) {
+	client, err := InitAO3Client(nil, AO3Policy)
+	if err != nil {
+		t.Fatal(err.Error())
+	}
+
+	const rawURL = "https://archiveofourown.org/works/1"
+	anonymousKey := client.cacheKey(rawURL)
+
+	jar, _ := cookiejar.New(nil)
+	client.HttpClient.Jar = jar
+	parsedURL, _ := url.Parse(rawURL)
+	jar.SetCookies(parsedURL, []*http.Cookie{{Name: "_otwarchive_session", Value: "a"}})
+	assert.Equal(t, anonymousKey, client.cacheKey(rawURL))
+
+	client.setSession("reader", "")
+	readerKey := client.cacheKey(rawURL)
+	assert.NotEqual(t, anonymousKey, readerKey)
+
+	jar.SetCookies(parsedURL, []*http.Cookie{{Name: "_otwarchive_session", Value: "b"}})
+	assert.Equal(t, readerKey, client.cacheKey(rawURL))
+
+	client.setSession("writer", "")
+	assert.NotEqual(t, readerKey, client.cacheKey(rawURL))
+
+	client.setSession("anonymous", "")
+	assert.NotEqual(t, anonymousKey, client.cacheKey(rawURL))
+}
@@ -0,0 +1,233 @@
+package ao3
+
+import (
+	"bytes"
+	"context"
+	"net/http"
+	"net/url"
+	"regexp"
+	"strings"
+	"github.com/PuerkitoBio/goquery"
+)
+
+var commentAnchorRegex = regexp.MustCompile(`^comment_(\d+)$`)
+var commentLinkRegex = regexp.MustCompile(`^/comments/(\d+)`)
+
+var commentsDisabledRegex = regexp.MustCompile(`(?i)doesn't allow comments|comments (?:are|have been) disabled`)
+var commentsRestrictedRegex = regexp.MustCompile(`(?i)doesn't allow non-Archive users to comment|only registered users`)
+var commentModeratedRegex = regexp.MustCompile(`(?i)after the (?:work )?creator has approved it|comment was received`)
+
+// CommentOptions describes a comment to post
+type CommentOptions struct {
+	// Content is the comment in HTML, sanitized with the client's
+	// HtmlSanitizer before it is submitted
+	Content string
+
+	// Name and Email identify guests and are required unless the client is
+	// authenticated, in which case the comment is posted as the user's default
+	// pseud
+	Name  string
+	Email string
+}
+
+// PostComment comments on a work and returns the ID of the comment. The
+// comment is posted as the logged-in user's default pseud or, if the client is
+// not authenticated, as the guest named by options.
+//
+// Works whose creator disabled comments return an error matching
+// ErrCommentsDisabled, and works only registered users may comment on one
+// matching ErrRestricted. If the comment must be approved by the creator of
+// the work, its ID is returned along with an error matching
+// ErrCommentModerated.
+//
+// Endpoint: https://archiveofourown.org/works/[work]/comments
+func (client *AO3Client) PostComment(workID string, options CommentOptions) (string, *AO3Error) {
+	return client.PostCommentWithContext(context.Background(), workID, options)
+}
+
+// PostCommentWithContext is PostComment with a context which cancels the
+// requests when it is done
+func (client *AO3Client) PostCommentWithContext(ctx context.Context, workID string, options CommentOptions) (string, *AO3Error) {
+	return client.postComment(ctx, "/works/"+workID+"/comments", options)
+}
+
+// PostChapterComment comments on a chapter of a work, like PostComment
+//
+// Endpoint: https://archiveofourown.org/chapters/[chapter]/comments
+func (client *AO3Client) PostChapterComment(chapterID string, options CommentOptions) (string, *AO3Error) {
+	return client.PostChapterCommentWithContext(context.Background(), chapterID, options)
+}
+
+// PostChapterCommentWithContext is PostChapterComment with a context which
+// cancels the requests when it is done
+func (client *AO3Client) PostChapterCommentWithContext(ctx context.Context, chapterID string, options CommentOptions) (string, *AO3Error) {
+	return client.postComment(ctx, "/chapters/"+chapterID+"/comments", options)
+}
+
+// ReplyToComment replies to a comment, continuing its thread, like PostComment
+//
+// Endpoint: https://archiveofourown.org/comments/[comment]/comments
+func (client *AO3Client) ReplyToComment(commentID string, options CommentOptions) (string, *AO3Error) {
+	return client.ReplyToCommentWithContext(context.Background(), commentID, options)
+}
+
+// ReplyToCommentWithContext is ReplyToComment with a context which cancels
+// the requests when it is done
+func (client *AO3Client) ReplyToCommentWithContext(ctx context.Context, commentID string, options CommentOptions) (string, *AO3Error) {
+	return client.postComment(ctx, "/comments/"+commentID+"/comments", options)
+}
+
+// EditComment replaces the content of one of the logged-in user's comments
+//
+// Endpoint: https://archiveofourown.org/comments/[comment]
+func (client *AO3Client) EditComment(commentID string, content string) *AO3Error {
+	return client.EditCommentWithContext(context.Background(), commentID, content)
+}
+
+// EditCommentWithContext is EditComment with a context which cancels the
+// request when it is done
+func (client *AO3Client) EditCommentWithContext(ctx context.Context, commentID string, content string) *AO3Error {
+	if ao3Err := client.requireLogin("editing comment"); ao3Err != nil {
+		return ao3Err
+	}
+
+	form := url.Values{}
+	form.Set("_method", "patch")
+	form.Set("comment[comment_content]", client.HtmlSanitizer.Sanitize(content))
+	form.Set("commit", "Update")
+
+	res, ao3Err := client.submit(ctx, "/comments/"+commentID, form, "editing comment")
+	if ao3Err != nil {
+		return ao3Err
+	}
+
+	_, ao3Err = checkCommentSubmission(res.body, "editing comment")
+	return ao3Err
+}
+
+// DeleteComment deletes one of the logged-in user's comments, or a comment on
+// one of their works
+//
+// Endpoint: https://archiveofourown.org/comments/[comment]
+func (client *AO3Client) DeleteComment(commentID string) *AO3Error {
+	return client.DeleteCommentWithContext(context.Background(), commentID)
+}
+
+// DeleteCommentWithContext is DeleteComment with a context which cancels the
+// request when it is done
+func (client *AO3Client) DeleteCommentWithContext(ctx context.Context, commentID string) *AO3Error {
+	if ao3Err := client.requireLogin("deleting comment"); ao3Err != nil {
+		return ao3Err
+	}
+
+	form := url.Values{}
+	form.Set("_method", "delete")
+
+	res, ao3Err := client.submit(ctx, "/comments/"+commentID, form, "deleting comment")
+	if ao3Err != nil {
+		return ao3Err
+	}
+
+	_, ao3Err = checkSubmission(res.body, "deleting comment")
+	return ao3Err
+}
+
+// postComment fetches the new comment form of a work, chapter or comment and
+// submits it to endpoint
+func (client *AO3Client) postComment(ctx context.Context, endpoint string, options CommentOptions) (string, *AO3Error) {
+	if ao3Err := client.requireCookieJar("posting comment"); ao3Err != nil {
+		return "", ao3Err
+	}
+
+	doc, ao3Err := client.fetchForm(ctx, endpoint+"/new", "fetching comment form")
+	if ao3Err != nil {
+		return "", ao3Err
+	}
+
+	if len(doc.Find(`textarea[name="comment[comment_content]"]`).Nodes) == 0 {
+		if ao3Err := commentStateError(commentFormNotice(doc), "posting comment"); ao3Err != nil {
+			return "", ao3Err
+		}
+		return "", NewError(http.StatusUnprocessableEntity, "unable to find comment form")
+	}
+
+	form := url.Values{}
+	form.Set("comment[comment_content]", client.HtmlSanitizer.Sanitize(options.Content))
+	if pseudID := formPseudID(doc, "comment[pseud_id]"); pseudID != "" {
+		form.Set("comment[pseud_id]", pseudID)
+	} else {
+		form.Set("comment[name]", options.Name)
+		form.Set("comment[email]", options.Email)
+	}
+	form.Set("commit", "Comment")
+
+	res, ao3Err := client.submit(ctx, endpoint, form, "posting comment")
+	if ao3Err != nil {
+		return "", ao3Err
+	}
+
+	resDoc, ao3Err := checkCommentSubmission(res.body, "posting comment")
+	if ao3Err != nil {
+		return "", ao3Err
+	}
+
+	id := ""
+	if res.url != nil {
+		if matches := commentAnchorRegex.FindStringSubmatch(res.url.Fragment); len(matches) == 2 {
+			id = matches[1]
+		} else if matches := commentLinkRegex.FindStringSubmatch(client.relativeLink(res.url.String())); len(matches) == 2 {
+			id = matches[1]
+		}
+	}
+
+	if commentModeratedRegex.MatchString(resDoc.Find(".flash").Text()) {
+		return id, NewError(http.StatusAccepted, "the comment will appear once the creator of the work approves it").withKind(KindCommentModerated)
+	}
+
+	if id == "" {
+		return "", NewError(http.StatusUnprocessableEntity, "unable to find comment after posting it")
+	}
+
+	return id, nil
+}
+
+// checkCommentSubmission is checkSubmission for comment forms, which AO3 also
+// rejects when comments are disabled or restricted to registered users
+func checkCommentSubmission(body []byte, action string) (*goquery.Document, *AO3Error) {
+	doc, err := goquery.NewDocumentFromReader(bytes.NewReader(body))
+	if err != nil {
+		return nil, WrapError(http.StatusUnprocessableEntity, err, "parsing response to "+action+" with goquery failed")
+	}
+
+	if ao3Err := commentStateError(doc.Find(".flash, #error").Text(), action); ao3Err != nil {
+		return nil, ao3Err
+	}
+	if ao3Err := validationError(doc, action); ao3Err != nil {
+		return nil, ao3Err
+	}
+
+	return doc, nil
+}
+
+// commentFormNotice returns the text of AO3's notices on a comment form page,
+// leaving out the works and comments quoted on it, whose text is the users'
+func commentFormNotice(doc *goquery.Document) string {
+	return doc.Find(".flash, #main p.notice").FilterFunction(func(_ int, node *goquery.Selection) bool {
+		return len(node.Closest(".userstuff").Nodes) == 0
+	}).Text()
+}
+
+// commentStateError returns an error if a notice says comments are disabled
+// or restricted to registered users
+func commentStateError(notice string, action string) *AO3Error {
+	notice = strings.Join(strings.Fields(notice), " ")
+
+	switch {
+	case commentsDisabledRegex.MatchString(notice):
+		return NewError(http.StatusForbidden, action+" failed as the creator of the work disabled comments").withKind(KindCommentsDisabled)
+	case commentsRestrictedRegex.MatchString(notice):
+		return NewError(http.StatusUnauthorized, action+" failed as only registered users may comment").withKind(KindRestricted)
+	}
+
+	return nil
+}
@@ -0,0 +1,167 @@
+package ao3
+
+import (
+	"errors"
+	"net/http"
+	"net/url"
+	"strings"
+	"testing"
+	"github.com/stretchr/testify/assert"
+)
+
+const testGuestCommentForm = `<html><head><meta name="csrf-token" content="test-token" /></head><body><div id="main"><form action="/works/1/comments" method="post"><input type="text" name="comment[name]" /><input type="text" name="comment[email]" /><textarea name="comment[comment_content]"></textarea></form></div></body></html>`
+const testUserCommentForm = `<html><head><meta name="csrf-token" content="test-token" /></head><body><div id="main"><form action="/works/1/comments" method="post"><input type="hidden" name="comment[pseud_id]" value="9" /><textarea name="comment[comment_content]"></textarea></form></div></body></html>`
+
+// newCommentServer returns a client pointed at a fake AO3 on which work 1
+// accepts comments from anyone, work 2 from no one, work 3 from registered
+// users and work 4 only after moderation. The forms it receives are recorded.
+func newCommentServer(t *testing.T) (*AO3Client, map[string]url.Values, func()) {
+	return newTestArchive(t, true, func(w http.ResponseWriter, r *http.Request) {
+		loggedIn := isTestReader(r)
+
+		switch {
+		case r.URL.Path == "/works/2/comments/new":
+			w.Write([]byte(`<div id="main"><p class="notice">Sorry, this work doesn't allow comments.</p></div>`))
+
+		case r.URL.Path == "/works/6/comments/new":
+			w.Write([]byte(`<div id="main"><div class="userstuff"><p class="notice">Sorry, this work doesn't allow comments.</p></div><p>Only registered users may comment on this chapter.</p></div>`))
+
+		case r.URL.Path == "/works/3/comments/new" && !loggedIn:
+			w.Write([]byte(`<div id="main"><p class="notice">Sorry, this work doesn't allow non-Archive users to comment.</p></div>`))
+
+		case strings.HasSuffix(r.URL.Path, "/comments/new"):
+			if loggedIn {
+				w.Write([]byte(testUserCommentForm))
+				return
+			}
+			w.Write([]byte(testGuestCommentForm))
+
+		case r.URL.Path == "/works/1/comments" || r.URL.Path == "/works/3/comments":
+			if r.PostFormValue("comment[pseud_id]") == "" && r.PostFormValue("comment[name]") == "" {
+				w.Write([]byte(`<body><div id="error" class="error"><ul><li>Name can't be blank</li></ul></div></body>`))
+				return
+			}
+			http.Redirect(w, r, "/works/1?show_comments=true#comment_42", http.StatusFound)
+
+		case r.URL.Path == "/works/4/comments":
+			http.Redirect(w, r, "/works/4?show_comments=true#comment_77", http.StatusFound)
+
+		case r.URL.Path == "/chapters/5/comments":
+			http.Redirect(w, r, "/works/1/chapters/5?show_comments=true#comment_55", http.StatusFound)
+
+		case r.URL.Path == "/comments/55/comments":
+			http.Redirect(w, r, "/comments/56", http.StatusFound)
+
+		case r.URL.Path == "/comments/42" && r.Method == http.MethodPost:
+			http.Redirect(w, r, "/works/1?show_comments=true", http.StatusFound)
+
+		case r.URL.Path == "/works/4":
+			w.Write([]byte(`<body><div class="flash comment_notice">Your comment was received! It will appear publicly after the work creator has approved it.</div></body>`))
+
+		case r.URL.Path == "/works/1" || r.URL.Path == "/works/1/chapters/5" || r.URL.Path == "/comments/56":
+			w.Write([]byte(`<body><div class="flash comment_notice">Comment created!</div></body>`))
+
+		default:
+			http.NotFound(w, r)
+		}
+	})
+}
+
+// TestPostComment ensures guests and logged-in users can comment on works,
+// chapters and comments, and the ID of the comment is returned
+func TestPostComment(t *testing.T) {
+	client, forms, closeServer := newCommentServer(t)
+	defer closeServer()
+
+	id, err := client.PostComment("1", CommentOptions{Content: `<p onclick="x()">Lovely!</p>`, Name: "guest", Email: "guest@example.com"})
+	if err != nil {
+		t.Fatal(err.Error())
+	}
+	assert.Equal(t, "42", id)
+	assert.Equal(t, "<p>Lovely!</p>", forms[" /works/1/comments"].Get("comment[comment_content]"))
+	assert.Equal(t, "guest", forms[" /works/1/comments"].Get("comment[name]"))
+	assert.Equal(t, "guest@example.com", forms[" /works/1/comments"].Get("comment[email]"))
+
+	_, err = client.PostComment("1", CommentOptions{Content: "Lovely!"})
+	if assert.NotNil(t, err) {
+		assert.True(t, errors.Is(err, ErrValidation))
+		assert.Contains(t, err.Error(), "Name can't be blank")
+	}
+
+	importTestSession(t, client, "reader")
+
+	id, err = client.PostComment("1", CommentOptions{Content: "Lovely!"})
+	if err != nil {
+		t.Fatal(err.Error())
+	}
+	assert.Equal(t, "42", id)
+	assert.Equal(t, "9", forms[" /works/1/comments"].Get("comment[pseud_id]"))
+	assert.Equal(t, "", forms[" /works/1/comments"].Get("comment[name]"))
+
+	id, err = client.PostChapterComment("5", CommentOptions{Content: "Lovely chapter!"})
+	if err != nil {
+		t.Fatal(err.Error())
+	}
+	assert.Equal(t, "55", id)
+
+	id, err = client.ReplyToComment("55", CommentOptions{Content: "Thank you!"})
+	if err != nil {
+		t.Fatal(err.Error())
+	}
+	assert.Equal(t, "56", id)
+}
+
+// TestPostCommentStates ensures works which do not accept the comment return
+// typed errors
+func TestPostCommentStates(t *testing.T) {
+	client, _, closeServer := newCommentServer(t)
+	defer closeServer()
+
+	_, err := client.PostComment("2", CommentOptions{Content: "Lovely!", Name: "guest", Email: "guest@example.com"})
+	if assert.NotNil(t, err) {
+		assert.True(t, errors.Is(err, ErrCommentsDisabled))
+	}
+
+	_, err = client.PostComment("3", CommentOptions{Content: "Lovely!", Name: "guest", Email: "guest@example.com"})
+	if assert.NotNil(t, err) {
+		assert.True(t, errors.Is(err, ErrRestricted))
+	}
+
+	// Text quoting the notices is not mistaken for them
+	_, err = client.PostComment("6", CommentOptions{Content: "Lovely!", Name: "guest", Email: "guest@example.com"})
+	if assert.NotNil(t, err) {
+		assert.False(t, errors.Is(err, ErrCommentsDisabled))
+		assert.False(t, errors.Is(err, ErrRestricted))
+	}
+
+	id, err := client.PostComment("4", CommentOptions{Content: "Lovely!", Name: "guest", Email: "guest@example.com"})
+	if assert.NotNil(t, err) {
+		assert.True(t, errors.Is(err, ErrCommentModerated))
+	}
+	assert.Equal(t, "77", id)
+
+	importTestSession(t, client, "reader")
+
+	if _, err := client.PostComment("3", CommentOptions{Content: "Lovely!"}); err != nil {
+		t.Fatal(err.Error())
+	}
+}
+
+// TestEditAndDeleteComment ensures comments are edited and deleted with Rails'
+// method override
+func TestEditAndDeleteComment(t *testing.T) {
+	client, forms, closeServer := newCommentServer(t)
+	defer closeServer()
+
+	importTestSession(t, client, "reader")
+
+	if err := client.EditComment("42", "<b>Edited</b>"); err != nil {
+		t.Fatal(err.Error())
+	}
+	assert.Equal(t, "<b>Edited</b>", forms["patch /comments/42"].Get("comment[comment_content]"))
+
+	if err := client.DeleteComment("42"); err != nil {
+		t.Fatal(err.Error())
+	}
+	assert.Contains(t, forms, "delete /comments/42")
+}
@@ -0,0 +1,107 @@
+package ao3
+
+import (
+	"strings"
+	"unicode/utf8"
+	"github.com/PuerkitoBio/goquery"
+)
+
+// maxSnippetLength is the length after which the HTML snippets of diagnostics
+// are truncated
+const maxSnippetLength = 300
+
+// ParseDiagnostic describes a field which could not be parsed. Diagnostics are
+// returned in place of errors when AO3Client.Lenient is set, and are passed to
+// the OnParseError hook of the client's Middleware.
+type ParseDiagnostic struct {
+	// Field is the name of the field which was left empty, e.g. "Rating", or
+	// "Metadata" for a missing block whose fields were all left empty
+	Field string
+	// Selector is the CSS selector which failed to match, or whose match could
+	// not be parsed
+	Selector string
+	// Snippet is the HTML of the node that failed to parse or, if the selector
+	// did not match, of the node it was applied to. It is truncated to 300
+	// bytes.
+	Snippet string
+	// Err is the error which would have been returned in strict mode
+	Err error
+}
+
+func (diagnostic ParseDiagnostic) Error() string {
+	return "parsing " + diagnostic.Field + " (" + diagnostic.Selector + ") failed: " + diagnostic.Err.Error()
+}
+
+// parseState collects the diagnostics of a page or listing as it is parsed
+type parseState struct {
+	client  *AO3Client
+	rawURL  string
+	lenient bool
+
+	diagnostics []ParseDiagnostic
+}
+
+// newParseState creates the state for parsing the page at rawURL. Pages which
+// do not support lenient parsing always abort on the first failure.
+func (client *AO3Client) newParseState(rawURL string, lenient bool) *parseState {
+	return &parseState{
+		client:  client,
+		rawURL:  rawURL,
+		lenient: lenient,
+	}
+}
+
+// fail reports a field which could not be parsed to the client's middleware
+// and reports whether parsing should be aborted. Unless parsing is lenient, it
+// should be and the error is returned.
+func (state *parseState) fail(field string, selector string, node *goquery.Selection, err error) bool {
+	diagnostic := ParseDiagnostic{
+		Field:    field,
+		Selector: selector,
+		Snippet:  snippet(node),
+		Err:      err,
+	}
+
+	if state.client != nil {
+		state.client.onParseError(state.rawURL, diagnostic)
+	}
+
+	if !state.lenient {
+		return true
+	}
+
+	state.diagnostics = append(state.diagnostics, diagnostic)
+
+	return false
+}
+
+// abort reports a field which could not be parsed to the client's middleware
+// and returns err, for pages which do not support lenient parsing
+func (state *parseState) abort(field string, selector string, node *goquery.Selection, err *AO3Error) *AO3Error {
+	state.fail(field, selector, node, err)
+	return err
+}
+
+// snippet returns the truncated outer HTML of the node
+func snippet(node *goquery.Selection) string {
+	if node == nil || len(node.Nodes) == 0 {
+		return ""
+	}
+
+	html, err := goquery.OuterHtml(node.First())
+	if err != nil {
+		return ""
+	}
+
+	html = strings.TrimSpace(html)
+	if len(html) > maxSnippetLength {
+		// Avoid cutting a multi-byte character in half
+		cut := maxSnippetLength
+		for cut > 0 && !utf8.RuneStart(html[cut]) {
+			cut--
+		}
+		html = html[:cut] + "..."
+	}
+
+	return html
+}
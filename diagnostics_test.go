@@ -0,0 +1,208 @@
+package ao3
+
+import (
+	"errors"
+	"net/http"
+	"strings"
+	"testing"
+	"unicode/utf8"
+	"github.com/PuerkitoBio/goquery"
+	"github.com/stretchr/testify/assert"
+)
+
+// testBlurb is a listing of a work whose rating symbol has been removed, as if
+// AO3 had changed its markup
+const testBlurb = `<li id="work_1" class="work blurb group" role="article">
+  <div class="header module">
+    <h4 class="heading"><a href="/works/1">A Work</a> by <a rel="author" href="/users/author/pseuds/author">author</a></h4>
+    <h5 class="fandoms heading"><a class="tag" href="/tags/No%20Fandom/works">No Fandom</a></h5>
+    <ul class="required-tags">
+      <li><span class="warnings" title="No Archive Warnings Apply"></span></li>
+      <li><span class="category" title="Gen"></span></li>
+      <li><span class="iswip" title="Complete Work"></span></li>
+    </ul>
+    <p class="datetime">23 Nov 2015</p>
+  </div>
+  <dl class="stats">
+    <dd class="language">English</dd>
+    <dd class="words">1,000</dd>
+    <dd class="chapters">1/1</dd>
+    <dd class="kudos"><a href="/works/1#comments">many</a></dd>
+    <dd class="hits">10</dd>
+  </dl>
+</li>`
+
+const testTagWorksPage = `<div id="main"><h2 class="heading">1 Work in <a class="tag" href="/tags/No%20Fandom/works">No Fandom</a></h2><ol class="work index group">` + testBlurb + `</ol></div>`
+
+// TestLenientTagWorks ensures a broken listing is reported with diagnostics in
+// lenient mode instead of failing the whole page
+func TestLenientTagWorks(t *testing.T) {
+	client, server := newTestClient(t, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(testTagWorksPage))
+	}))
+	defer server.Close()
+
+	_, err := client.GetTagWorks("No%20Fandom", 0)
+	if assert.NotNil(t, err) {
+		assert.Equal(t, KindParse, err.Kind())
+	}
+
+	client.Lenient = true
+
+	tagWorks, err := client.GetTagWorks("No%20Fandom", 0)
+	if err != nil {
+		t.Fatal(err.Error())
+	}
+
+	assert.Equal(t, 1, tagWorks.Count)
+	assert.Empty(t, tagWorks.Diagnostics)
+	if !assert.Len(t, tagWorks.Works, 1) {
+		return
+	}
+
+	work := tagWorks.Works[0]
+	assert.Equal(t, "A Work", work.Title)
+	assert.Equal(t, []Link{{Text: "author", Slug: "author"}}, work.Authors)
+	assert.Equal(t, "", work.Rating)
+	assert.Equal(t, "Gen", work.Category)
+	assert.Equal(t, 1000, work.Words)
+	assert.Equal(t, 0, work.Kudos)
+	assert.Equal(t, 10, work.Hits)
+
+	if assert.Len(t, work.Diagnostics, 2) {
+		assert.Equal(t, "Kudos", work.Diagnostics[0].Field)
+		assert.Equal(t, "dd.kudos > a", work.Diagnostics[0].Selector)
+		assert.Equal(t, `<a href="/works/1#comments">many</a>`, work.Diagnostics[0].Snippet)
+
+		assert.Equal(t, "Rating", work.Diagnostics[1].Field)
+		assert.Equal(t, ".required-tags .rating", work.Diagnostics[1].Selector)
+		assert.True(t, strings.HasPrefix(work.Diagnostics[1].Snippet, `<ul class="required-tags">`))
+		assert.Contains(t, work.Diagnostics[1].Error(), "unable to match symbol rating node")
+	}
+}
+
+// TestLenientWork ensures a work page missing its title and download links is
+// returned with what could be parsed
+func TestLenientWork(t *testing.T) {
+	client, server := newTestClient(t, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(`<div id="main"><dl class="work meta group"><dd class="language">English</dd><dd class="words">2,642</dd><dd class="hits">lots</dd></dl>
+<div id="workskin"><div class="preface group"><h3 class="byline heading">Anonymous</h3></div></div></div>`))
+	}))
+	defer server.Close()
+
+	client.Lenient = true
+
+	work, err := client.GetWork("1")
+	if err != nil {
+		t.Fatal(err.Error())
+	}
+
+	assert.Equal(t, "English", work.Language)
+	assert.Equal(t, 2642, work.Words)
+	assert.True(t, work.IsAnonymous)
+
+	fields := []string{}
+	for _, diagnostic := range work.Diagnostics {
+		fields = append(fields, diagnostic.Field)
+	}
+	assert.Equal(t, []string{"Hits", "Title", "HTMLDownloadSlug"}, fields)
+}
+
+// TestLenientWorkWithoutMetadata ensures a work page missing its metadata box
+// is reported as such rather than as a missing rating
+func TestLenientWorkWithoutMetadata(t *testing.T) {
+	client, server := newTestClient(t, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(`<div id="main"><ul class="work navigation actions"><li class="download"><ul class="expandable secondary"><li><a href="/downloads/1/Bare.html?updated_at=1">HTML</a></li></ul></li></ul>
+<div id="workskin"><div class="preface group"><h2 class="title heading">Bare</h2><h3 class="byline heading"><a rel="author" href="/users/author/pseuds/author">author</a></h3></div></div></div>`))
+	}))
+	defer server.Close()
+
+	_, err := client.GetWork("1")
+	assert.NotNil(t, err)
+
+	client.Lenient = true
+
+	work, err := client.GetWork("1")
+	if err != nil {
+		t.Fatal(err.Error())
+	}
+
+	assert.Equal(t, "Bare", work.Title)
+	assert.Empty(t, work.RatingTags)
+	if assert.Len(t, work.Diagnostics, 1) {
+		assert.Equal(t, "Metadata", work.Diagnostics[0].Field)
+		assert.Equal(t, ".work.meta.group", work.Diagnostics[0].Selector)
+	}
+}
+
+// TestLenientSeries ensures unparseable series metadata is reported with the
+// label of its pair
+func TestLenientSeries(t *testing.T) {
+	client, server := newTestClient(t, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(`<div id="main"><h2 class="heading">A Series</h2><dl class="series meta group"><dt>Series Begun:</dt><dd>2015-11-11</dd><dt>Words:</dt><dd>unknown</dd></dl></div>`))
+	}))
+	defer server.Close()
+
+	client.Lenient = true
+
+	series, err := client.GetSeries("1")
+	if err != nil {
+		t.Fatal(err.Error())
+	}
+
+	assert.Equal(t, "A Series", series.Title)
+	assert.Equal(t, "2015-11-11", series.Begun)
+	if assert.Len(t, series.Diagnostics, 1) {
+		assert.Equal(t, "Words", series.Diagnostics[0].Field)
+		assert.Equal(t, "<dd>unknown</dd>", series.Diagnostics[0].Snippet)
+	}
+}
+
+// TestLenientSeriesWithoutMetadata ensures a series page missing its metadata
+// block is reported as such rather than as missing creators
+func TestLenientSeriesWithoutMetadata(t *testing.T) {
+	client, server := newTestClient(t, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(`<div id="main"><h2 class="heading">A Series</h2></div>`))
+	}))
+	defer server.Close()
+
+	_, err := client.GetSeries("1")
+	assert.NotNil(t, err)
+
+	client.Lenient = true
+
+	series, err := client.GetSeries("1")
+	if err != nil {
+		t.Fatal(err.Error())
+	}
+
+	assert.Equal(t, "A Series", series.Title)
+	if assert.Len(t, series.Diagnostics, 1) {
+		assert.Equal(t, "Metadata", series.Diagnostics[0].Field)
+		assert.Equal(t, "dl.series.meta.group", series.Diagnostics[0].Selector)
+	}
+}
+
+// TestSnippetTruncation ensures long snippets are truncated without splitting
+// multi-byte characters
+func TestSnippetTruncation(t *testing.T) {
+	doc, err := goquery.NewDocumentFromReader(strings.NewReader("<p>" + strings.Repeat("é", maxSnippetLength) + "</p>"))
+	if err != nil {
+		t.Fatal(err.Error())
+	}
+
+	state := &parseState{lenient: true}
+	assert.False(t, state.fail("Summary", "p", doc.Find("p"), errors.New("unable to parse summary")))
+
+	if assert.Len(t, state.diagnostics, 1) {
+		snippet := state.diagnostics[0].Snippet
+		assert.True(t, strings.HasPrefix(snippet, "<p>é"))
+		assert.True(t, strings.HasSuffix(snippet, "..."))
+		assert.True(t, len(snippet) <= maxSnippetLength+len("..."))
+		assert.True(t, utf8.ValidString(snippet))
+	}
+
+	strict := &parseState{}
+	assert.True(t, strict.fail("Summary", "p", doc.Find("p"), errors.New("unable to parse summary")))
+	assert.Empty(t, strict.diagnostics)
+}
@@ -0,0 +1,116 @@
+package ao3
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+)
+
+// DiskCache is a Cache storing each entry as a file in a directory, allowing
+// responses to be reused across restarts. Entries are never evicted; remove
+// the directory's files to reclaim space.
+type DiskCache struct {
+	dir string
+}
+
+// diskCacheFile is the contents of an entry's file. The key is kept so that
+// entries can be purged, as file names only contain its hash.
+type diskCacheFile struct {
+	Key string `json:"key"`
+	CacheEntry
+}
+
+// NewDiskCache creates a DiskCache in dir, creating the directory if needed
+func NewDiskCache(dir string) (*DiskCache, error) {
+	if err := os.MkdirAll(dir, 0700); err != nil {
+		return nil, err
+	}
+
+	return &DiskCache{dir: dir}, nil
+}
+
+// Get returns the entry for key. Unreadable or corrupt files are treated as
+// missing entries.
+func (cache *DiskCache) Get(key string) (*CacheEntry, bool) {
+	contents, err := ioutil.ReadFile(cache.path(key))
+	if err != nil {
+		return nil, false
+	}
+
+	var file diskCacheFile
+	if err := json.Unmarshal(contents, &file); err != nil {
+		return nil, false
+	}
+
+	return &file.CacheEntry, true
+}
+
+// Set writes the entry for key. The file is written atomically so that
+// concurrent readers never see a partial entry; write errors are ignored as
+// the cache is best effort.
+func (cache *DiskCache) Set(key string, entry *CacheEntry) {
+	contents, err := json.Marshal(diskCacheFile{Key: key, CacheEntry: *entry})
+	if err != nil {
+		return
+	}
+
+	file, err := ioutil.TempFile(cache.dir, "tmp-")
+	if err != nil {
+		return
+	}
+
+	_, writeErr := file.Write(contents)
+	closeErr := file.Close()
+	if writeErr != nil || closeErr != nil {
+		os.Remove(file.Name())
+		return
+	}
+
+	if err := os.Rename(file.Name(), cache.path(key)); err != nil {
+		os.Remove(file.Name())
+	}
+}
+
+func (cache *DiskCache) Delete(key string) {
+	os.Remove(cache.path(key))
+}
+
+// Purge removes every entry whose key matches. Entries written before keys
+// were stored in their files are left in place.
+func (cache *DiskCache) Purge(match func(key string) bool) {
+	files, err := ioutil.ReadDir(cache.dir)
+	if err != nil {
+		return
+	}
+
+	for _, info := range files {
+		if info.IsDir() || filepath.Ext(info.Name()) != ".json" {
+			continue
+		}
+
+		path := filepath.Join(cache.dir, info.Name())
+		contents, err := ioutil.ReadFile(path)
+		if err != nil {
+			continue
+		}
+
+		var file diskCacheFile
+		if err := json.Unmarshal(contents, &file); err != nil || file.Key == "" {
+			continue
+		}
+
+		if match(file.Key) {
+			os.Remove(path)
+		}
+	}
+}
+
+// path returns the file an entry is stored in, hashing the key as URLs contain
+// characters which are not valid in file names
+func (cache *DiskCache) path(key string) string {
+	hash := sha256.Sum256([]byte(key))
+	return filepath.Join(cache.dir, hex.EncodeToString(hash[:])+".json")
+}
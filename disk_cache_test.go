@@ -0,0 +1,79 @@
+package ao3
+
+import (
+	"testing"
+	"time"
+	"io/ioutil"
+	"os"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestDiskCacheRoundTrip(t *testing.T) {
+	dir, err := ioutil.TempDir("", "ao3-cache")
+	if err != nil {
+		t.Fatal(err.Error())
+	}
+	defer os.RemoveAll(dir)
+
+	cache, err := NewDiskCache(dir)
+	if err != nil {
+		t.Fatal(err.Error())
+	}
+
+	const key = "https://archiveofourown.org/tags/Action*s*Adventure/works?page=2 anonymous"
+	expected := &CacheEntry{
+		Body:         []byte("<html></html>"),
+		ETag:         `W/"abc"`,
+		LastModified: "Mon, 01 Jan 2018 00:00:00 GMT",
+		Expires:      time.Date(2018, 1, 1, 0, 5, 0, 0, time.UTC),
+	}
+
+	_, ok := cache.Get(key)
+	assert.False(t, ok)
+
+	cache.Set(key, expected)
+
+	// A second cache over the same directory sees the entry
+	reopened, err := NewDiskCache(dir)
+	if err != nil {
+		t.Fatal(err.Error())
+	}
+
+	actual, ok := reopened.Get(key)
+	assert.True(t, ok)
+	assert.Equal(t, expected.Body, actual.Body)
+	assert.Equal(t, expected.ETag, actual.ETag)
+	assert.Equal(t, expected.LastModified, actual.LastModified)
+	assert.True(t, expected.Expires.Equal(actual.Expires))
+
+	reopened.Delete(key)
+	_, ok = cache.Get(key)
+	assert.False(t, ok)
+}
+
+func TestDiskCachePurge(t *testing.T) {
+	dir, err := ioutil.TempDir("", "ao3-cache")
+	if err != nil {
+		t.Fatal(err.Error())
+	}
+	defer os.RemoveAll(dir)
+
+	cache, err := NewDiskCache(dir)
+	if err != nil {
+		t.Fatal(err.Error())
+	}
+
+	cache.Set("/works/1 user:reader", &CacheEntry{Body: []byte("1")})
+	cache.Set("/works/1 anonymous", &CacheEntry{Body: []byte("2")})
+
+	cache.Purge(func(key string) bool {
+		return key == "/works/1 user:reader"
+	})
+
+	_, ok := cache.Get("/works/1 user:reader")
+	assert.False(t, ok)
+	entry, ok := cache.Get("/works/1 anonymous")
+	if assert.True(t, ok) {
+		assert.Equal(t, "2", string(entry.Body))
+	}
+}
@@ -0,0 +1,121 @@
+package ao3
+
+import (
+	"bytes"
+	"net/http"
+	"net/url"
+	"regexp"
+	"github.com/PuerkitoBio/goquery"
+)
+
+// errorPage describes a page AO3 serves in place of the requested one, often
+// with 200 OK or after redirecting
+type errorPage struct {
+	kind ErrorKind
+	code int
+
+	// selector and pattern match the page's notice
+	selector string
+	pattern  *regexp.Regexp
+
+	// description completes the error message, e.g. "fetching work failed as
+	// the page does not exist"
+	description string
+}
+
+var errorPages = []errorPage{
+	// Works and series use the same heading for their titles, so only the
+	// heading of the error page's #main counts
+	{
+		kind:        KindNotFound,
+		code:        http.StatusNotFound,
+		selector:    "#main.error-404 > h2.heading",
+		pattern:     regexp.MustCompile(`^\s*Error 404\s*$`),
+		description: "the page does not exist",
+	},
+	{
+		kind:        KindAdultGate,
+		code:        http.StatusForbidden,
+		selector:    "#main p.caution",
+		pattern:     regexp.MustCompile(`could have adult content`),
+		description: "AO3 asked for confirmation before showing adult content",
+	},
+	{
+		kind:        KindRestricted,
+		code:        http.StatusUnauthorized,
+		selector:    ".flash, #main p.notice",
+		pattern:     regexp.MustCompile(`only available to registered users`),
+		description: "the page is only available to logged-in users",
+	},
+	// AO3 throttles clients with a bare "Retry later" page, which is not
+	// always served with 429 Too Many Requests. Unlike the archive's pages,
+	// it has no #main.
+	{
+		kind:        KindThrottled,
+		code:        http.StatusTooManyRequests,
+		selector:    "html:not(:has(#main)) > head > title, body:not(:has(#main))",
+		pattern:     regexp.MustCompile(`^\s*Retry later\.?\s*$`),
+		description: "AO3 throttled the client (retry later)",
+	},
+	{
+		kind:        KindDeleted,
+		code:        http.StatusGone,
+		selector:    ".flash",
+		pattern:     regexp.MustCompile(`(?i)(?:has been hidden by|couldn't find the work)`),
+		description: "the page was deleted or hidden by AO3's administrators",
+	},
+}
+
+// errorPageMarkers are searched for before parsing a page, as most pages are
+// not error pages
+var errorPageMarkers = [][]byte{
+	[]byte("Error 404"),
+	[]byte(`class="caution"`),
+	[]byte(`class="flash`),
+	[]byte("registered users"),
+	[]byte("Retry later"),
+}
+
+// classifyPage recognises AO3's error pages and interstitials, returning the
+// matching errorPage or nil if the page is an ordinary one. finalURL is the URL
+// of the page after following redirects.
+func classifyPage(finalURL *url.URL, body []byte) *errorPage {
+	// Restricted works redirect logged-out users to the login page
+	if finalURL != nil && finalURL.Query().Get("restricted") == "true" {
+		for i := range errorPages {
+			if errorPages[i].kind == KindRestricted {
+				return &errorPages[i]
+			}
+		}
+	}
+
+	hasMarker := false
+	for _, marker := range errorPageMarkers {
+		if bytes.Contains(body, marker) {
+			hasMarker = true
+			break
+		}
+	}
+	if !hasMarker {
+		return nil
+	}
+
+	doc, err := goquery.NewDocumentFromReader(bytes.NewReader(body))
+	if err != nil {
+		return nil
+	}
+
+	for i, page := range errorPages {
+		matched := false
+		doc.Find(page.selector).EachWithBreak(func(_ int, node *goquery.Selection) bool {
+			matched = page.pattern.MatchString(node.Text())
+			return !matched
+		})
+
+		if matched {
+			return &errorPages[i]
+		}
+	}
+
+	return nil
+}
@@ -0,0 +1,91 @@
+package ao3
+
+import (
+	"errors"
+	"net/http"
+	"testing"
+	"github.com/stretchr/testify/assert"
+)
+
+const (
+	testNotFoundPage = `<div id="main" class="error-404 region"><h2 class="heading">Error 404</h2><h3 class="heading">The page you were looking for doesn't exist.</h3></div>`
+	testAdultPage    = `<div id="main" class="works-show region"><p class="caution">This work could have adult content. If you continue, you have agreed that you are willing to see such content.</p><ul class="actions"><li><a href="/works/1?view_adult=true">Yes, Continue</a></li></ul></div>`
+	testLoginPage    = `<div id="main" class="sessions-new region"><p class="notice">This work is only available to registered users of the Archive. If you already have an Archive of Our Own account, log in now.</p></div>`
+	testHiddenPage   = `<div class="flash error">This work has been hidden by an administrator.</div><div id="main" class="works-index region"></div>`
+)
+
+// TestClassifyPage ensures AO3's error pages are recognised without mistaking
+// works which quote them for error pages
+func TestClassifyPage(t *testing.T) {
+	tests := []struct {
+		body string
+		kind ErrorKind
+	}{
+		{testNotFoundPage, KindNotFound},
+		{testAdultPage, KindAdultGate},
+		{testLoginPage, KindRestricted},
+		{testHiddenPage, KindDeleted},
+		{"Retry later\n", KindThrottled},
+		{`<html><head><title>Retry later</title></head><body><h1>Retry later</h1></body></html>`, KindThrottled},
+		{`<div id="main"><div class="userstuff"><p>Retry later</p></div></div>`, KindUnknown},
+		{`<div id="main"><div class="userstuff"><p>Error 404: This work could have adult content. It has been hidden by the author.</p></div></div>`, KindUnknown},
+		{testFandomCategoriesPage, KindUnknown},
+		{`<div id="main" class="works-show region"><div id="workskin"><div class="preface group"><h2 class="title heading">Error 404</h2></div></div></div>`, KindUnknown},
+		{`<div id="main" class="series-show region"><h2 class="heading">Error 404</h2></div>`, KindUnknown},
+	}
+
+	for _, test := range tests {
+		page := classifyPage(nil, []byte(test.body))
+		if test.kind == KindUnknown {
+			assert.Nil(t, page, test.body)
+			continue
+		}
+
+		if assert.NotNil(t, page, test.body) {
+			assert.Equal(t, test.kind, page.kind)
+		}
+	}
+}
+
+// TestErrorPagesAreErrors ensures error pages served with 200 OK, or reached
+// by following a redirect, are returned as errors of the matching kind
+func TestErrorPagesAreErrors(t *testing.T) {
+	client, server := newTestClient(t, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/works/1":
+			w.Write([]byte(testAdultPage))
+		case "/works/2":
+			http.Redirect(w, r, "/users/login?restricted=true", http.StatusFound)
+		case "/works/3":
+			w.Write([]byte(testHiddenPage))
+		case "/works/5":
+			w.Write([]byte("Retry later\n"))
+		case "/users/login":
+			w.Write([]byte(`<div id="main" class="sessions-new region"></div>`))
+		default:
+			w.WriteHeader(http.StatusNotFound)
+			w.Write([]byte(testNotFoundPage))
+		}
+	}))
+	defer server.Close()
+
+	tests := []struct {
+		id       string
+		sentinel error
+		code     int
+	}{
+		{"1", ErrAdultGate, http.StatusForbidden},
+		{"2", ErrRestricted, http.StatusUnauthorized},
+		{"3", ErrDeleted, http.StatusGone},
+		{"4", ErrNotFound, http.StatusNotFound},
+		{"5", ErrThrottled, http.StatusTooManyRequests},
+	}
+
+	for _, test := range tests {
+		_, err := client.GetWork(test.id)
+		if assert.NotNil(t, err, test.id) {
+			assert.True(t, errors.Is(err, test.sentinel), err.Error())
+			assert.Equal(t, test.code, err.Code())
+		}
+	}
+}
@@ -1,6 +1,8 @@
 package ao3
 
 import (
+	"bytes"
+	"context"
 	"regexp"
 	"net/http"
 	"github.com/PuerkitoBio/goquery"
@@ -15,26 +17,29 @@ type FandomCategory struct {
 //
 // Endpoint: https://archiveofourown.org/media
 func (client *AO3Client) GetFandomCategories() ([]FandomCategory, *AO3Error) {
+	return client.GetFandomCategoriesWithContext(context.Background())
+}
+
+// GetFandomCategoriesWithContext is GetFandomCategories with a context which
+// cancels the request when it is done
+func (client *AO3Client) GetFandomCategoriesWithContext(ctx context.Context) ([]FandomCategory, *AO3Error) {
 	const endpoint = "media"
 
 	slugRegex := regexp.MustCompile("^/media/(.+)/fandoms$")
 
 	// Fetch the HTML page and load the document
-	res, err := client.HttpClient.Get(baseURL + endpoint)
-	if err != nil {
-		return nil, WrapError(http.StatusServiceUnavailable, err, "unable to fetch fandom categories page")
-	}
-	defer res.Body.Close()
-	if res.StatusCode != http.StatusOK {
-		return nil, NewError(res.StatusCode, "fetching fandom categories returned a non-200 status code")
+	body, ao3Err := client.get(ctx, endpoint, client.CacheTTLs.FandomCategories, "fetching fandom categories")
+	if ao3Err != nil {
+		return nil, ao3Err
 	}
 
-	doc, err := goquery.NewDocumentFromReader(res.Body)
+	doc, err := goquery.NewDocumentFromReader(bytes.NewReader(body))
 	if err != nil {
 		return nil, WrapError(http.StatusUnprocessableEntity, err, "unable to parse fandom categories page")
 	}
 
 	var fandomCategories []FandomCategory
+	state := client.newParseState(client.endpointURL(endpoint), false)
 
 	// Match all the sections, then iterate through them
 	categoryMatches := doc.Find(".medium.listbox.group > h3 > a")
@@ -50,12 +55,12 @@ func (client *AO3Client) GetFandomCategories() ([]FandomCategory, *AO3Error) {
 		// Extract and parse the slug (e.g., "Anime%20*a*%20Manga")
 		link, ok := categoryNode.Attr("href")
 		if !ok {
-			return nil, NewError(http.StatusUnprocessableEntity, "unable to find href attribute in category link")
+			return nil, state.abort("Slug", ".medium.listbox.group > h3 > a", categoryNode, NewError(http.StatusUnprocessableEntity, "unable to find href attribute in category link"))
 		}
 
-		slug := slugRegex.FindStringSubmatch(link)
+		slug := slugRegex.FindStringSubmatch(client.relativeLink(link))
 		if len(slug) != 2 {
-			return nil, NewError(http.StatusUnprocessableEntity, "unable to process category link: "+link)
+			return nil, state.abort("Slug", ".medium.listbox.group > h3 > a", categoryNode, NewError(http.StatusUnprocessableEntity, "unable to process category link: "+link))
 		}
 		fandomCategory.Slug = slug[1]
 
@@ -11,10 +11,7 @@ func TestGetFandomCategories(t *testing.T) {
 		Slug: "Anime%20*a*%20Manga",
 	}
 
-	client, err := InitAO3Client(nil, AO3Policy)
-	if err != nil {
-		t.Fatal(err.Error())
-	}
+	client := newFixtureClient(t)
 
 	categories, err := client.GetFandomCategories()
 	if err != nil {
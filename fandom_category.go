@@ -1,6 +1,8 @@
 package ao3
 
 import (
+	"bytes"
+	"context"
 	"regexp"
 	"strconv"
 	"net/http"
@@ -19,6 +21,12 @@ type Fandom struct {
 // Endpoint: https://archiveofourown.org/media/[category]/fandoms
 // Example: https://archiveofourown.org/media/Anime%20*a*%20Manga/fandoms
 func (client *AO3Client) GetFandomCategory(category string) ([]Fandom, *AO3Error) {
+	return client.GetFandomCategoryWithContext(context.Background(), category)
+}
+
+// GetFandomCategoryWithContext is GetFandomCategory with a context which
+// cancels the request when it is done
+func (client *AO3Client) GetFandomCategoryWithContext(ctx context.Context, category string) ([]Fandom, *AO3Error) {
 	endpoint := "media/" + category + "/fandoms"
 
 	slugRegex := regexp.MustCompile("^/tags/(.+)/works$")
@@ -26,21 +34,18 @@ func (client *AO3Client) GetFandomCategory(category string) ([]Fandom, *AO3Error
 	countRegex := regexp.MustCompile("(?s)^.*\\((\\S+)\\)[\\n\\r\\s]*$")
 
 	// Fetch the HTML page and load the document
-	res, err := client.HttpClient.Get(baseURL + endpoint)
-	if err != nil {
-		return nil, WrapError(http.StatusServiceUnavailable, err, "unable to fetch fandom category page")
-	}
-	defer res.Body.Close()
-	if res.StatusCode != http.StatusOK {
-		return nil, NewError(res.StatusCode, "fetching fandom category returned a non-200 status code")
+	body, ao3Err := client.get(ctx, endpoint, client.CacheTTLs.FandomCategory, "fetching fandom category")
+	if ao3Err != nil {
+		return nil, ao3Err
 	}
 
-	doc, err := goquery.NewDocumentFromReader(res.Body)
+	doc, err := goquery.NewDocumentFromReader(bytes.NewReader(body))
 	if err != nil {
 		return nil, WrapError(http.StatusUnprocessableEntity, err, "unable to parse fandom category page")
 	}
 
 	var fandoms []Fandom
+	state := client.newParseState(client.endpointURL(endpoint), false)
 
 	// Although all the fandoms in a category are under a single page, they are
 	// separated by sections corresponding to the first alphanumeric letter of
@@ -53,13 +58,13 @@ func (client *AO3Client) GetFandomCategory(category string) ([]Fandom, *AO3Error
 		// Retrieve and parse the letter of the section (e.g., "A")
 		letterNodeMatches := categorySectionNode.Find("h3")
 		if len(letterNodeMatches.Nodes) != 1 {
-			return nil, NewError(http.StatusUnprocessableEntity, "unable to match fandom category letter node")
+			return nil, state.abort("Letter", "h3", categorySectionNode, NewError(http.StatusUnprocessableEntity, "unable to match fandom category letter node"))
 		}
 		letterNode := letterNodeMatches.First()
 
 		matchedLetter := letterRegex.FindStringSubmatch(letterNode.Text())
 		if len(matchedLetter) != 2 {
-			return nil, NewError(http.StatusUnprocessableEntity, "unable to parse fandom category letter: "+letterNode.Text())
+			return nil, state.abort("Letter", "h3", letterNode, NewError(http.StatusUnprocessableEntity, "unable to parse fandom category letter: "+letterNode.Text()))
 		}
 		letter := matchedLetter[1]
 
@@ -74,19 +79,19 @@ func (client *AO3Client) GetFandomCategory(category string) ([]Fandom, *AO3Error
 			// Extract and parse fandom works count (e.g., 468)
 			matchedCount := countRegex.FindStringSubmatch(fandomNode.Text())
 			if len(matchedCount) < 1 {
-				return nil, NewError(http.StatusUnprocessableEntity, "unable to parse fandom category work count: "+fandomNode.Text())
+				return nil, state.abort("Count", "ul > li", fandomNode, NewError(http.StatusUnprocessableEntity, "unable to parse fandom category work count: "+fandomNode.Text()))
 			}
 
 			count, err := strconv.Atoi(matchedCount[len(matchedCount)-1])
 			if err != nil {
-				return nil, WrapError(http.StatusUnprocessableEntity, err, "unable to convert fandom category work count to integer: "+fandomNode.Text())
+				return nil, state.abort("Count", "ul > li", fandomNode, WrapError(http.StatusUnprocessableEntity, err, "unable to convert fandom category work count to integer: "+fandomNode.Text()))
 			}
 			fandom.Count = count
 
 			// Extract the node containing the name and slug of the fandom
 			fandomLinkNodeMatches := fandomNode.Find("a")
 			if len(fandomLinkNodeMatches.Nodes) != 1 {
-				return nil, NewError(http.StatusUnprocessableEntity, "unable to match fandom category fandom's name/slug node")
+				return nil, state.abort("Name", "ul > li > a", fandomNode, NewError(http.StatusUnprocessableEntity, "unable to match fandom category fandom's name/slug node"))
 			}
 			fandomLinkNode := fandomLinkNodeMatches.First()
 
@@ -96,12 +101,12 @@ func (client *AO3Client) GetFandomCategory(category string) ([]Fandom, *AO3Error
 			// Extract and parse the slug (e.g., "Artemis%20Fowl%20-%20Eoin%20Colfer")
 			matchedFandomLink, ok := fandomLinkNode.Attr("href")
 			if !ok {
-				return nil, NewError(http.StatusUnprocessableEntity, "unable to extract href attribute from fandom category fandom's link")
+				return nil, state.abort("Slug", "ul > li > a", fandomLinkNode, NewError(http.StatusUnprocessableEntity, "unable to extract href attribute from fandom category fandom's link"))
 			}
 
-			matchedSlug := slugRegex.FindStringSubmatch(matchedFandomLink)
+			matchedSlug := slugRegex.FindStringSubmatch(client.relativeLink(matchedFandomLink))
 			if len(matchedSlug) != 2 {
-				return nil, NewError(http.StatusUnprocessableEntity, "unable to parse href attribute of fandom category fandom link: "+matchedFandomLink)
+				return nil, state.abort("Slug", "ul > li > a", fandomLinkNode, NewError(http.StatusUnprocessableEntity, "unable to parse href attribute of fandom category fandom link: "+matchedFandomLink))
 			}
 			fandom.Slug = matchedSlug[1]
 
@@ -14,10 +14,7 @@ func TestGetFandomCategory(t *testing.T) {
 	}
 	const expectedMinFandomCount = 300
 
-	client, err := InitAO3Client(nil, AO3Policy)
-	if err != nil {
-		t.Fatal(err.Error())
-	}
+	client := newFixtureClient(t)
 
 	category, err := client.GetFandomCategory(exampleCategory)
 	if err != nil {
@@ -0,0 +1,257 @@
+package ao3
+
+import (
+	"bytes"
+	"context"
+	"net/http"
+	"net/url"
+	"regexp"
+	"strconv"
+	"strings"
+	"github.com/PuerkitoBio/goquery"
+)
+
+var lastVisitedRegex = regexp.MustCompile(`Last visited:\s*(\d{1,2} \w{3} \d{4})`)
+var visitsRegex = regexp.MustCompile(`Visited\s+(once|[\d,]+ times)`)
+var readingLinkRegex = regexp.MustCompile(`^/users/[^/]+/readings/(\d+)$`)
+
+// Reading is a work in the logged-in user's reading history
+type Reading struct {
+	// ID identifies the entry in the history, e.g. to delete it
+	ID   string
+	Work IndexedWork
+
+	// IsDeleted reports whether the work was deleted since it was read, in
+	// which case Work is empty
+	IsDeleted bool
+
+	LastVisited string
+	Visits      int
+
+	// IsUpdated reports whether the work was updated since it was last visited
+	IsUpdated        bool
+	IsMarkedForLater bool
+}
+
+// ReadingHistory is a page of the logged-in user's reading history, or of the
+// works they marked for later
+type ReadingHistory struct {
+	Readings []Reading
+
+	// Pagination-related values
+	IsPaginated bool
+	CurrentPage int
+	LastPage    int
+
+	// Diagnostics describes the fields of the page which could not be parsed
+	// in lenient mode. Diagnostics of individual works are kept in the works.
+	Diagnostics []ParseDiagnostic
+}
+
+// GetReadingHistory returns a page of the logged-in user's reading history,
+// most recently visited first
+//
+// Endpoint: https://archiveofourown.org/users/[user]/readings?page=[page]
+func (client *AO3Client) GetReadingHistory(page int) (*ReadingHistory, *AO3Error) {
+	return client.GetReadingHistoryWithContext(context.Background(), page)
+}
+
+// GetReadingHistoryWithContext is GetReadingHistory with a context which
+// cancels the request when it is done
+func (client *AO3Client) GetReadingHistoryWithContext(ctx context.Context, page int) (*ReadingHistory, *AO3Error) {
+	return client.getReadings(ctx, url.Values{}, page, "fetching reading history")
+}
+
+// GetMarkedForLater returns a page of the works the logged-in user marked for
+// later
+//
+// Endpoint: https://archiveofourown.org/users/[user]/readings?show=to-read&page=[page]
+func (client *AO3Client) GetMarkedForLater(page int) (*ReadingHistory, *AO3Error) {
+	return client.GetMarkedForLaterWithContext(context.Background(), page)
+}
+
+// GetMarkedForLaterWithContext is GetMarkedForLater with a context which
+// cancels the request when it is done
+func (client *AO3Client) GetMarkedForLaterWithContext(ctx context.Context, page int) (*ReadingHistory, *AO3Error) {
+	query := url.Values{}
+	query.Set("show", "to-read")
+
+	return client.getReadings(ctx, query, page, "fetching works marked for later")
+}
+
+// MarkForLater adds a work to the logged-in user's Marked for Later list
+//
+// Endpoint: https://archiveofourown.org/works/[work]/mark_for_later
+func (client *AO3Client) MarkForLater(id string) *AO3Error {
+	return client.MarkForLaterWithContext(context.Background(), id)
+}
+
+// MarkForLaterWithContext is MarkForLater with a context which cancels the
+// request when it is done
+func (client *AO3Client) MarkForLaterWithContext(ctx context.Context, id string) *AO3Error {
+	return client.markWork(ctx, "/works/"+id+"/mark_for_later", "marking work for later")
+}
+
+// UnmarkForLater removes a work from the logged-in user's Marked for Later
+// list by marking it as read
+//
+// Endpoint: https://archiveofourown.org/works/[work]/mark_as_read
+func (client *AO3Client) UnmarkForLater(id string) *AO3Error {
+	return client.UnmarkForLaterWithContext(context.Background(), id)
+}
+
+// UnmarkForLaterWithContext is UnmarkForLater with a context which cancels
+// the request when it is done
+func (client *AO3Client) UnmarkForLaterWithContext(ctx context.Context, id string) *AO3Error {
+	return client.markWork(ctx, "/works/"+id+"/mark_as_read", "marking work as read")
+}
+
+// DeleteReading deletes an entry from the logged-in user's reading history by
+// its ID, as given by Reading.ID
+//
+// Endpoint: https://archiveofourown.org/users/[user]/readings/[reading]
+func (client *AO3Client) DeleteReading(id string) *AO3Error {
+	return client.DeleteReadingWithContext(context.Background(), id)
+}
+
+// DeleteReadingWithContext is DeleteReading with a context which cancels the
+// request when it is done
+func (client *AO3Client) DeleteReadingWithContext(ctx context.Context, id string) *AO3Error {
+	if ao3Err := client.requireLogin("deleting reading"); ao3Err != nil {
+		return ao3Err
+	}
+
+	form := url.Values{}
+	form.Set("_method", "delete")
+
+	res, ao3Err := client.submit(ctx, "/users/"+url.PathEscape(client.Username())+"/readings/"+id, form, "deleting reading")
+	if ao3Err != nil {
+		return ao3Err
+	}
+
+	_, ao3Err = checkSubmission(res.body, "deleting reading")
+	return ao3Err
+}
+
+// markWork follows one of the links AO3 marks works for later or as read with,
+// which are plain GET requests
+func (client *AO3Client) markWork(ctx context.Context, endpoint string, action string) *AO3Error {
+	if ao3Err := client.requireLogin(action); ao3Err != nil {
+		return ao3Err
+	}
+
+	body, ao3Err := client.get(ctx, endpoint, 0, action)
+	if ao3Err != nil {
+		return ao3Err
+	}
+
+	_, ao3Err = checkSubmission(body, action)
+	return ao3Err
+}
+
+// getReadings fetches and parses a page of the logged-in user's readings
+func (client *AO3Client) getReadings(ctx context.Context, query url.Values, page int, action string) (*ReadingHistory, *AO3Error) {
+	if ao3Err := client.requireLogin(action); ao3Err != nil {
+		return nil, ao3Err
+	}
+
+	if page != 0 {
+		query.Set("page", strconv.Itoa(page))
+	}
+	endpoint := "/users/" + url.PathEscape(client.Username()) + "/readings"
+	if len(query) > 0 {
+		endpoint += "?" + query.Encode()
+	}
+
+	body, ao3Err := client.get(ctx, endpoint, 0, action)
+	if ao3Err != nil {
+		return nil, ao3Err
+	}
+
+	doc, err := goquery.NewDocumentFromReader(bytes.NewReader(body))
+	if err != nil {
+		return nil, WrapError(http.StatusUnprocessableEntity, err, "parsing readings page with goquery failed")
+	}
+
+	history := ReadingHistory{Readings: []Reading{}}
+	state := client.newParseState(client.endpointURL(endpoint), client.Lenient)
+
+	pages, ao3Err := parsePagination(state, doc, 1)
+	if ao3Err != nil {
+		return nil, ao3Err
+	}
+	history.IsPaginated = pages.isPaginated
+	history.CurrentPage = pages.currentPage
+	history.LastPage = pages.lastPage
+
+	readingMatches := doc.Find("li.reading")
+	for i := range readingMatches.Nodes {
+		node := readingMatches.Eq(i)
+
+		var reading Reading
+		reading.IsDeleted = node.HasClass("deleted") || len(node.Find(".header").Nodes) == 0
+		if !reading.IsDeleted {
+			work, err := client.parseIndexedWorkNode(state.rawURL, node)
+			if err != nil {
+				return nil, WrapError(http.StatusUnprocessableEntity, err, "parsing reading failed")
+			}
+			reading.Work = *work
+		}
+
+		if ao3Err := client.parseReadingVisits(&reading, node); ao3Err != nil {
+			if state.fail("Readings", "h4.viewed.heading", node.Find("div.user"), ao3Err) {
+				return nil, ao3Err
+			}
+		}
+
+		history.Readings = append(history.Readings, reading)
+	}
+
+	history.Diagnostics = state.diagnostics
+
+	return &history, nil
+}
+
+// parseReadingVisits extracts the ID of a reading and the user's visits to the
+// work from the box below its blurb, e.g. "Last visited: 05 Oct 2023 (Update
+// available.) Visited 3 times"
+func (client *AO3Client) parseReadingVisits(reading *Reading, node *goquery.Selection) *AO3Error {
+	node.Find(`a[href*="/readings/"]`).EachWithBreak(func(_ int, linkNode *goquery.Selection) bool {
+		link, _ := linkNode.Attr("href")
+		if matches := readingLinkRegex.FindStringSubmatch(client.relativeLink(link)); len(matches) == 2 {
+			reading.ID = matches[1]
+			return false
+		}
+		return true
+	})
+	if reading.ID == "" {
+		return NewError(http.StatusUnprocessableEntity, "unable to find reading delete link")
+	}
+
+	viewed := strings.Join(strings.Fields(node.Find("h4.viewed.heading").Text()), " ")
+
+	lastVisitedMatches := lastVisitedRegex.FindStringSubmatch(viewed)
+	if len(lastVisitedMatches) != 2 {
+		return NewError(http.StatusUnprocessableEntity, "unable to parse last visited date")
+	}
+	reading.LastVisited = lastVisitedMatches[1]
+
+	visitsMatches := visitsRegex.FindStringSubmatch(viewed)
+	if len(visitsMatches) != 2 {
+		return NewError(http.StatusUnprocessableEntity, "unable to parse visit count")
+	}
+	if visitsMatches[1] == "once" {
+		reading.Visits = 1
+	} else {
+		visits, err := AtoiWithComma(strings.TrimSuffix(visitsMatches[1], " times"))
+		if err != nil {
+			return WrapError(http.StatusUnprocessableEntity, err, "parsing visit count failed")
+		}
+		reading.Visits = visits
+	}
+
+	reading.IsUpdated = strings.Contains(viewed, "(Update available.)")
+	reading.IsMarkedForLater = strings.Contains(viewed, "(Marked for Later.)")
+
+	return nil
+}
@@ -0,0 +1,161 @@
+package ao3
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"testing"
+	"github.com/stretchr/testify/assert"
+)
+
+// testReading renders an entry of the reading history of "reader"
+func testReading(workID string, readingID string, viewed string) string {
+	return fmt.Sprintf(`<li id="work_%s" class="reading work blurb group" role="article">
+  <div class="header module">
+    <h4 class="heading"><a href="/works/%s">Work %s</a> by <a rel="author" href="/users/author/pseuds/author">author</a></h4>
+    <h5 class="fandoms heading"><a class="tag" href="/tags/No%%20Fandom/works">No Fandom</a></h5>
+    <ul class="required-tags">
+      <li><span class="rating-general-audience rating" title="General Audiences"></span></li>
+      <li><span class="warnings" title="No Archive Warnings Apply"></span></li>
+      <li><span class="category" title="Gen"></span></li>
+      <li><span class="iswip" title="Complete Work"></span></li>
+    </ul>
+    <p class="datetime">23 Nov 2015</p>
+  </div>
+  <dl class="stats">
+    <dd class="language">English</dd>
+    <dd class="words">1,000</dd>
+    <dd class="chapters">1/1</dd>
+    <dd class="hits">10</dd>
+  </dl>
+  <div class="user module group">
+    <h4 class="viewed heading"><span>Last visited:</span> %s</h4>
+    <ul class="actions" role="navigation">
+      <li><a data-method="delete" rel="nofollow" href="/users/reader/readings/%s">Delete from History</a></li>
+    </ul>
+  </div>
+</li>`, workID, workID, workID, viewed, readingID)
+}
+
+const testDeletedReading = `<li class="deleted reading work blurb group">
+  <p>This has been deleted, sorry!</p>
+  <div class="user module group">
+    <h4 class="viewed heading"><span>Last visited:</span> 01 Jan 2020 Visited once</h4>
+    <ul class="actions"><li><a data-method="delete" href="/users/reader/readings/503">Delete from History</a></li></ul>
+  </div>
+</li>`
+
+// newHistoryServer returns a client pointed at a fake AO3 serving the reading
+// history of "reader", recording the requests which change it
+func newHistoryServer(t *testing.T) (*AO3Client, *[]string, func()) {
+	requests := []string{}
+
+	client, _, closeServer := newTestArchive(t, false, func(w http.ResponseWriter, r *http.Request) {
+		switch {
+		case r.URL.Path == "/users/reader/readings" && r.Method == http.MethodGet:
+			if r.URL.Query().Get("show") == "to-read" {
+				w.Write([]byte(`<div id="main"><ol class="reading work index group">` + testReading("2", "502", "10 Feb 2021 (Marked for Later.) (Latest version.) Visited 12 times") + `</ol></div>`))
+				return
+			}
+			w.Write([]byte(`<div id="main"><ol class="reading work index group">` +
+				testReading("1", "501", "05 Oct 2023 (Update available.) Visited 1,204 times") +
+				testReading("2", "502", "10 Feb 2021 (Marked for Later.) (Latest version.) Visited 12 times") +
+				testDeletedReading + `</ol></div>`))
+
+		case r.URL.Path == "/users/reader/readings/501" && r.Method == http.MethodPost:
+			if r.PostFormValue("_method") != "delete" {
+				w.WriteHeader(http.StatusUnprocessableEntity)
+				return
+			}
+			requests = append(requests, "delete 501")
+			http.Redirect(w, r, "/users/reader/readings", http.StatusFound)
+
+		case r.URL.Path == "/works/1/mark_for_later" || r.URL.Path == "/works/2/mark_as_read":
+			requests = append(requests, r.URL.Path)
+			w.Write([]byte(`<body><div class="flash notice">This work was updated.</div></body>`))
+
+		case r.URL.Path == "/works/3/mark_for_later":
+			w.Write([]byte(`<body><div class="flash error">Sorry, you don't have permission to access the page you were trying to reach.</div></body>`))
+
+		default:
+			http.NotFound(w, r)
+		}
+	})
+
+	return client, &requests, closeServer
+}
+
+// TestGetReadingHistory ensures visits are parsed alongside the work blurbs,
+// including entries of deleted works
+func TestGetReadingHistory(t *testing.T) {
+	client, _, closeServer := newHistoryServer(t)
+	defer closeServer()
+
+	_, err := client.GetReadingHistory(0)
+	if assert.NotNil(t, err) {
+		assert.True(t, errors.Is(err, ErrRestricted))
+	}
+
+	importTestSession(t, client, "reader")
+
+	history, err := client.GetReadingHistory(0)
+	if err != nil {
+		t.Fatal(err.Error())
+	}
+	assert.False(t, history.IsPaginated)
+
+	if assert.Len(t, history.Readings, 3) {
+		first := history.Readings[0]
+		assert.Equal(t, "501", first.ID)
+		assert.Equal(t, "1", first.Work.Slug)
+		assert.Equal(t, "Work 1", first.Work.Title)
+		assert.Equal(t, "05 Oct 2023", first.LastVisited)
+		assert.Equal(t, 1204, first.Visits)
+		assert.True(t, first.IsUpdated)
+		assert.False(t, first.IsMarkedForLater)
+
+		second := history.Readings[1]
+		assert.Equal(t, 12, second.Visits)
+		assert.False(t, second.IsUpdated)
+		assert.True(t, second.IsMarkedForLater)
+
+		deleted := history.Readings[2]
+		assert.True(t, deleted.IsDeleted)
+		assert.Equal(t, "503", deleted.ID)
+		assert.Equal(t, 1, deleted.Visits)
+		assert.Equal(t, "", deleted.Work.Slug)
+	}
+
+	marked, err := client.GetMarkedForLater(0)
+	if err != nil {
+		t.Fatal(err.Error())
+	}
+	if assert.Len(t, marked.Readings, 1) {
+		assert.Equal(t, "2", marked.Readings[0].Work.Slug)
+	}
+}
+
+// TestReadingActions ensures works are marked and history entries deleted, and
+// refusals are reported
+func TestReadingActions(t *testing.T) {
+	client, requests, closeServer := newHistoryServer(t)
+	defer closeServer()
+
+	importTestSession(t, client, "reader")
+
+	if err := client.MarkForLater("1"); err != nil {
+		t.Fatal(err.Error())
+	}
+	if err := client.UnmarkForLater("2"); err != nil {
+		t.Fatal(err.Error())
+	}
+	if err := client.DeleteReading("501"); err != nil {
+		t.Fatal(err.Error())
+	}
+	assert.Equal(t, []string{"/works/1/mark_for_later", "/works/2/mark_as_read", "delete 501"}, *requests)
+
+	err := client.MarkForLater("3")
+	if assert.NotNil(t, err) {
+		assert.True(t, errors.Is(err, ErrValidation))
+	}
+}
@@ -0,0 +1,254 @@
+package ao3
+
+import (
+	"bytes"
+	"context"
+	"net/http"
+	"net/url"
+	"regexp"
+	"strconv"
+	"strings"
+	"github.com/PuerkitoBio/goquery"
+)
+
+var inboxPseudRegex = regexp.MustCompile(`^/users/([^/]+)/pseuds/[^/]+$`)
+var inboxTargetRegex = regexp.MustCompile(`^/works/(\d+)(?:/chapters/(\d+))?`)
+var inboxCommentRegex = regexp.MustCompile(`(?:#comment_|/comments/)(\d+)`)
+
+// InboxFilter selects the comments listed by GetInbox
+type InboxFilter int
+
+const (
+	// InboxAll lists every comment in the inbox
+	InboxAll InboxFilter = iota
+	// InboxUnread lists the comments which have not been marked as read
+	InboxUnread
+	// InboxRead lists the comments which have been marked as read
+	InboxRead
+)
+
+// InboxComment is a comment received by the logged-in user
+type InboxComment struct {
+	// ID identifies the comment in the inbox, e.g. to mark it as read
+	ID string
+
+	// Commenter is the pseud of the commenter, whose slug is the name of the
+	// user. Guests have no slug.
+	Commenter Link
+
+	// Work is the work commented on, whose slug is its ID. Comments on a
+	// chapter also have its ID.
+	Work      Link
+	ChapterID string
+	CommentID string
+
+	Posted string
+	Body   string
+	IsRead bool
+}
+
+// Inbox is a page of the comments received by the logged-in user
+type Inbox struct {
+	Comments []InboxComment
+
+	// Pagination-related values
+	IsPaginated bool
+	CurrentPage int
+	LastPage    int
+
+	// Diagnostics describes the comments which could not be parsed in lenient
+	// mode
+	Diagnostics []ParseDiagnostic
+}
+
+// GetInbox returns a page of the comments received by the logged-in user. The
+// bodies of the comments are sanitized according to the sanitization policy.
+//
+// Endpoint: https://archiveofourown.org/users/[user]/inbox?filters[read]=[read]&page=[page]
+func (client *AO3Client) GetInbox(filter InboxFilter, page int) (*Inbox, *AO3Error) {
+	return client.GetInboxWithContext(context.Background(), filter, page)
+}
+
+// GetInboxWithContext is GetInbox with a context which cancels the request
+// when it is done
+func (client *AO3Client) GetInboxWithContext(ctx context.Context, filter InboxFilter, page int) (*Inbox, *AO3Error) {
+	if ao3Err := client.requireLogin("fetching inbox"); ao3Err != nil {
+		return nil, ao3Err
+	}
+
+	query := url.Values{}
+	switch filter {
+	case InboxUnread:
+		query.Set("filters[read]", "false")
+	case InboxRead:
+		query.Set("filters[read]", "true")
+	}
+	if page != 0 {
+		query.Set("page", strconv.Itoa(page))
+	}
+
+	endpoint := client.inboxEndpoint()
+	if len(query) > 0 {
+		endpoint += "?" + query.Encode()
+	}
+
+	body, ao3Err := client.get(ctx, endpoint, 0, "fetching inbox")
+	if ao3Err != nil {
+		return nil, ao3Err
+	}
+
+	doc, err := goquery.NewDocumentFromReader(bytes.NewReader(body))
+	if err != nil {
+		return nil, WrapError(http.StatusUnprocessableEntity, err, "parsing inbox page with goquery failed")
+	}
+
+	inbox := Inbox{Comments: []InboxComment{}}
+	state := client.newParseState(client.endpointURL(endpoint), client.Lenient)
+
+	pages, ao3Err := parsePagination(state, doc, 1)
+	if ao3Err != nil {
+		return nil, ao3Err
+	}
+	inbox.IsPaginated = pages.isPaginated
+	inbox.CurrentPage = pages.currentPage
+	inbox.LastPage = pages.lastPage
+
+	commentMatches := doc.Find("ol.comment.index > li.comment")
+	for i := range commentMatches.Nodes {
+		node := commentMatches.Eq(i)
+
+		comment, ao3Err := client.parseInboxComment(node)
+		if ao3Err != nil {
+			if state.fail("Comments", "ol.comment.index > li.comment", node, ao3Err) {
+				return nil, ao3Err
+			}
+			continue
+		}
+
+		inbox.Comments = append(inbox.Comments, *comment)
+	}
+
+	inbox.Diagnostics = state.diagnostics
+
+	return &inbox, nil
+}
+
+// MarkInboxRead marks comments in the logged-in user's inbox as read by their
+// IDs, as given by InboxComment.ID
+//
+// Endpoint: https://archiveofourown.org/users/[user]/inbox
+func (client *AO3Client) MarkInboxRead(ids ...string) *AO3Error {
+	return client.MarkInboxReadWithContext(context.Background(), ids...)
+}
+
+// MarkInboxReadWithContext is MarkInboxRead with a context which cancels the
+// request when it is done
+func (client *AO3Client) MarkInboxReadWithContext(ctx context.Context, ids ...string) *AO3Error {
+	return client.updateInbox(ctx, ids, "read", "Mark Read", "marking comments as read")
+}
+
+// MarkInboxUnread marks comments in the logged-in user's inbox as unread by
+// their IDs, as given by InboxComment.ID
+//
+// Endpoint: https://archiveofourown.org/users/[user]/inbox
+func (client *AO3Client) MarkInboxUnread(ids ...string) *AO3Error {
+	return client.MarkInboxUnreadWithContext(context.Background(), ids...)
+}
+
+// MarkInboxUnreadWithContext is MarkInboxUnread with a context which cancels
+// the request when it is done
+func (client *AO3Client) MarkInboxUnreadWithContext(ctx context.Context, ids ...string) *AO3Error {
+	return client.updateInbox(ctx, ids, "unread", "Mark Unread", "marking comments as unread")
+}
+
+// DeleteInboxComments deletes comments from the logged-in user's inbox by their
+// IDs, as given by InboxComment.ID. The comments themselves are not deleted.
+//
+// Endpoint: https://archiveofourown.org/users/[user]/inbox
+func (client *AO3Client) DeleteInboxComments(ids ...string) *AO3Error {
+	return client.DeleteInboxCommentsWithContext(context.Background(), ids...)
+}
+
+// DeleteInboxCommentsWithContext is DeleteInboxComments with a context which
+// cancels the request when it is done
+func (client *AO3Client) DeleteInboxCommentsWithContext(ctx context.Context, ids ...string) *AO3Error {
+	return client.updateInbox(ctx, ids, "delete", "Delete From Inbox", "deleting comments from inbox")
+}
+
+// updateInbox submits the inbox form for the selected comments with the
+// button named button
+func (client *AO3Client) updateInbox(ctx context.Context, ids []string, button string, label string, action string) *AO3Error {
+	if ao3Err := client.requireLogin(action); ao3Err != nil {
+		return ao3Err
+	}
+	if len(ids) == 0 {
+		return nil
+	}
+
+	form := url.Values{}
+	form.Set("_method", "put")
+	form["inbox_comments[]"] = ids
+	form.Set(button, label)
+
+	res, ao3Err := client.submit(ctx, client.inboxEndpoint(), form, action)
+	if ao3Err != nil {
+		return ao3Err
+	}
+
+	_, ao3Err = checkSubmission(res.body, action)
+	return ao3Err
+}
+
+// inboxEndpoint returns the endpoint of the logged-in user's inbox
+func (client *AO3Client) inboxEndpoint() string {
+	return "/users/" + url.PathEscape(client.Username()) + "/inbox"
+}
+
+// parseInboxComment parses a comment listed in the inbox
+func (client *AO3Client) parseInboxComment(node *goquery.Selection) (*InboxComment, *AO3Error) {
+	comment := InboxComment{IsRead: !node.HasClass("unread")}
+
+	id, ok := node.Find(`input[name="inbox_comments[]"]`).First().Attr("value")
+	if !ok || id == "" {
+		return nil, NewError(http.StatusUnprocessableEntity, "unable to find inbox comment ID")
+	}
+	comment.ID = id
+
+	headingNode := node.Find("h4.heading").First()
+
+	// The commenter is a link to their pseud, or the name of a guest
+	if linkNode := headingNode.Children().Filter("a").First(); len(linkNode.Nodes) > 0 {
+		link, _ := linkNode.Attr("href")
+		comment.Commenter.Text = strings.TrimSpace(linkNode.Text())
+		if matches := inboxPseudRegex.FindStringSubmatch(client.relativeLink(link)); len(matches) == 2 {
+			comment.Commenter.Slug, _ = url.PathUnescape(matches[1])
+		}
+	} else {
+		guestNode := headingNode.Clone()
+		guestNode.Find(".parent, .unread, .datetime, input").Remove()
+		comment.Commenter.Text = strings.Join(strings.Fields(guestNode.Text()), " ")
+	}
+
+	// The work is linked after "on", pointing to the comment in context
+	targetNode := headingNode.Find(".parent a[href]").First()
+	target, _ := targetNode.Attr("href")
+	targetMatches := inboxTargetRegex.FindStringSubmatch(client.relativeLink(target))
+	if len(targetMatches) != 3 {
+		return nil, NewError(http.StatusUnprocessableEntity, "unable to parse inbox comment work link")
+	}
+	comment.Work = Link{Text: strings.TrimSpace(targetNode.Text()), Slug: targetMatches[1]}
+	comment.ChapterID = targetMatches[2]
+	if matches := inboxCommentRegex.FindStringSubmatch(target); len(matches) == 2 {
+		comment.CommentID = matches[1]
+	}
+
+	comment.Posted = strings.TrimSpace(node.Find(".datetime").First().Text())
+
+	bodyHTML, err := node.Find("blockquote.userstuff").First().Html()
+	if err != nil {
+		return nil, WrapError(http.StatusUnprocessableEntity, err, "unable to extract inbox comment HTML")
+	}
+	comment.Body = client.HtmlSanitizer.Sanitize(strings.TrimSpace(bodyHTML))
+
+	return &comment, nil
+}
@@ -0,0 +1,153 @@
+package ao3
+
+import (
+	"errors"
+	"net/http"
+	"net/url"
+	"testing"
+	"github.com/stretchr/testify/assert"
+)
+
+const testUnreadInboxComment = `<li class="unread comment group" role="article">
+  <h4 class="heading byline">
+    <a href="/users/commenter/pseuds/Alt%20Name">Alt Name</a>
+    <span class="parent">on <a href="/works/1/chapters/5?show_comments=true#comment_42">Work 1</a></span>
+    <span class="unread">Unread</span>
+  </h4>
+  <span class="posted datetime">Fri 01 Mar 2024 10:15AM UTC</span>
+  <blockquote class="userstuff"><p onclick="x()">Loved it!</p></blockquote>
+  <ul class="actions"><li><input type="checkbox" name="inbox_comments[]" id="inbox_comments_901" value="901" /></li></ul>
+</li>`
+
+const testReadInboxComment = `<li class="read comment group" role="article">
+  <h4 class="heading byline">
+    guest
+    <span class="parent">on <a href="/works/2/comments/43">Work 2</a></span>
+  </h4>
+  <span class="posted datetime">Thu 29 Feb 2024 09:00PM UTC</span>
+  <blockquote class="userstuff"><p>Thanks for writing!</p></blockquote>
+  <ul class="actions"><li><input type="checkbox" name="inbox_comments[]" id="inbox_comments_902" value="902" /></li></ul>
+</li>`
+
+// newInboxServer returns a client pointed at a fake AO3 serving the inbox of
+// "reader", recording the forms which change it
+func newInboxServer(t *testing.T) (*AO3Client, *[]url.Values, func()) {
+	forms := []url.Values{}
+
+	client, _, closeServer := newTestArchive(t, false, func(w http.ResponseWriter, r *http.Request) {
+		switch {
+		case r.URL.Path == "/users/reader/inbox" && r.Method == http.MethodGet:
+			comments := testUnreadInboxComment + testReadInboxComment
+			switch r.URL.Query().Get("filters[read]") {
+			case "false":
+				comments = testUnreadInboxComment
+			case "true":
+				comments = testReadInboxComment
+			}
+			w.Write([]byte(`<div id="main"><form action="/users/reader/inbox" method="post"><ol class="comment index group">` + comments + `</ol></form>` +
+				`<ol class="pagination actions"><li class="previous"><span class="disabled">&#8592; Previous</span></li><li><span class="current">1</span></li><li><a href="/users/reader/inbox?page=2">2</a></li><li class="next"><a href="/users/reader/inbox?page=2">Next &#8594;</a></li></ol></div>`))
+
+		case r.URL.Path == "/users/reader/inbox" && r.Method == http.MethodPost:
+			if r.PostFormValue("_method") != "put" {
+				w.WriteHeader(http.StatusUnprocessableEntity)
+				return
+			}
+			forms = append(forms, r.PostForm)
+			http.Redirect(w, r, "/users/reader/inbox", http.StatusFound)
+
+		default:
+			http.NotFound(w, r)
+		}
+	})
+
+	return client, &forms, closeServer
+}
+
+// TestGetInbox ensures inbox comments are parsed, sanitized and filtered by
+// whether they were read
+func TestGetInbox(t *testing.T) {
+	client, _, closeServer := newInboxServer(t)
+	defer closeServer()
+
+	_, err := client.GetInbox(InboxAll, 0)
+	if assert.NotNil(t, err) {
+		assert.True(t, errors.Is(err, ErrRestricted))
+	}
+
+	importTestSession(t, client, "reader")
+
+	inbox, err := client.GetInbox(InboxAll, 0)
+	if err != nil {
+		t.Fatal(err.Error())
+	}
+	assert.True(t, inbox.IsPaginated)
+	assert.Equal(t, 1, inbox.CurrentPage)
+	assert.Equal(t, 2, inbox.LastPage)
+
+	if assert.Len(t, inbox.Comments, 2) {
+		unread := inbox.Comments[0]
+		assert.Equal(t, "901", unread.ID)
+		assert.Equal(t, Link{Text: "Alt Name", Slug: "commenter"}, unread.Commenter)
+		assert.Equal(t, Link{Text: "Work 1", Slug: "1"}, unread.Work)
+		assert.Equal(t, "5", unread.ChapterID)
+		assert.Equal(t, "42", unread.CommentID)
+		assert.Equal(t, "Fri 01 Mar 2024 10:15AM UTC", unread.Posted)
+		assert.Equal(t, "<p>Loved it!</p>", unread.Body)
+		assert.False(t, unread.IsRead)
+
+		read := inbox.Comments[1]
+		assert.Equal(t, "902", read.ID)
+		assert.Equal(t, Link{Text: "guest"}, read.Commenter)
+		assert.Equal(t, Link{Text: "Work 2", Slug: "2"}, read.Work)
+		assert.Equal(t, "", read.ChapterID)
+		assert.Equal(t, "43", read.CommentID)
+		assert.True(t, read.IsRead)
+	}
+
+	unread, err := client.GetInbox(InboxUnread, 0)
+	if err != nil {
+		t.Fatal(err.Error())
+	}
+	if assert.Len(t, unread.Comments, 1) {
+		assert.Equal(t, "901", unread.Comments[0].ID)
+	}
+
+	read, err := client.GetInbox(InboxRead, 0)
+	if err != nil {
+		t.Fatal(err.Error())
+	}
+	if assert.Len(t, read.Comments, 1) {
+		assert.Equal(t, "902", read.Comments[0].ID)
+	}
+}
+
+// TestInboxActions ensures the inbox form is submitted with the selected
+// comments and the button of each action
+func TestInboxActions(t *testing.T) {
+	client, forms, closeServer := newInboxServer(t)
+	defer closeServer()
+
+	importTestSession(t, client, "reader")
+
+	if err := client.MarkInboxRead("901", "902"); err != nil {
+		t.Fatal(err.Error())
+	}
+	if err := client.MarkInboxUnread("901"); err != nil {
+		t.Fatal(err.Error())
+	}
+	if err := client.DeleteInboxComments("902"); err != nil {
+		t.Fatal(err.Error())
+	}
+	if err := client.DeleteInboxComments(); err != nil {
+		t.Fatal(err.Error())
+	}
+
+	if assert.Len(t, *forms, 3) {
+		assert.Equal(t, []string{"901", "902"}, (*forms)[0]["inbox_comments[]"])
+		assert.Equal(t, "Mark Read", (*forms)[0].Get("read"))
+		assert.Equal(t, []string{"901"}, (*forms)[1]["inbox_comments[]"])
+		assert.Equal(t, "Mark Unread", (*forms)[1].Get("unread"))
+		assert.Equal(t, []string{"902"}, (*forms)[2]["inbox_comments[]"])
+		assert.Equal(t, "Delete From Inbox", (*forms)[2].Get("delete"))
+	}
+}
@@ -18,6 +18,9 @@ type IndexedWork struct {
 	Authors     []Link
 	Recipients  []Link
 
+	// IsRestricted reports whether the work is only visible to logged-in users
+	IsRestricted bool
+
 	Rating   string
 	Warnings string
 	Category string
@@ -29,6 +32,12 @@ type IndexedWork struct {
 	CharacterTags    []Link
 	FreeformTags     []Link
 
+	// HasHiddenWarnings and HasHiddenFreeformTags report whether the
+	// logged-in user's preferences hide the work's warnings or additional
+	// tags behind a "Show" link, leaving WarningTags or FreeformTags empty
+	HasHiddenWarnings     bool
+	HasHiddenFreeformTags bool
+
 	IsSeries   bool
 	Series     Link
 	SeriesPart int
@@ -42,11 +51,19 @@ type IndexedWork struct {
 	Kudos     int
 	Bookmarks int
 	Hits      int
+
+	// Diagnostics describes the fields which could not be parsed in lenient
+	// mode
+	Diagnostics []ParseDiagnostic
 }
 
 // parseIndexedWorkNode parses the standardised listing of a work displayed in the
 // search results. The summary is sanitized according to the sanitization policy.
-func (client *AO3Client) parseIndexedWorkNode(node *goquery.Selection) (*IndexedWork, error) {
+//
+// In lenient mode, fields which cannot be parsed are described by the work's
+// Diagnostics instead of failing the whole listing. rawURL is the URL of the
+// page listing the work.
+func (client *AO3Client) parseIndexedWorkNode(rawURL string, node *goquery.Selection) (*IndexedWork, error) {
 	workSlugRegex := regexp.MustCompile("^work_(\\S+)$")
 	archivistRegex := regexp.MustCompile("(?m).*by\\s*(.+ \\[archived by .+])")
 	userSlugRegex := regexp.MustCompile("^/(?:users/(.+)/(?:pseuds|gifts).*)|(?:gifts\\?recipient=(.+)\\s*)$")
@@ -55,31 +72,46 @@ func (client *AO3Client) parseIndexedWorkNode(node *goquery.Selection) (*Indexed
 	tagRegex := regexp.MustCompile("(?m)<a class=\"tag\" href=\".*/tags/(.+)/works\">(.+)</a>")
 
 	work := IndexedWork{}
+	state := client.newParseState(rawURL, client.Lenient)
 
 	// Extract the work slug by matching against the ID
 	workLink, ok := node.Attr("id")
 	if !ok {
-		return nil, errors.New("unable to extract ID attribute from work node")
-	}
-
-	workSlugMatches := workSlugRegex.FindStringSubmatch(workLink)
-	if len(workSlugMatches) != 2 {
-		return nil, errors.New(": " + workLink)
+		err := errors.New("unable to extract ID attribute from work node")
+		if state.fail("Slug", "[id]", node, err) {
+			return nil, err
+		}
+	} else {
+		workSlugMatches := workSlugRegex.FindStringSubmatch(workLink)
+		if len(workSlugMatches) != 2 {
+			err := errors.New(": " + workLink)
+			if state.fail("Slug", "[id]", node, err) {
+				return nil, err
+			}
+		} else {
+			work.Slug = workSlugMatches[1]
+		}
 	}
-	work.Slug = workSlugMatches[1]
 
 	// Extract the last updated string by matching against the datetime class
 	lastUpdatedMatches := node.Find(".datetime")
 	if len(lastUpdatedMatches.Nodes) != 1 {
-		return nil, errors.New("unable to match last updated node")
+		err := errors.New("unable to match last updated node")
+		if state.fail("LastUpdated", ".datetime", node, err) {
+			return nil, err
+		}
+	} else {
+		work.LastUpdated = lastUpdatedMatches.First().Text()
 	}
-	work.LastUpdated = lastUpdatedMatches.First().Text()
 
 	// Extract the fandoms
 	work.FandomTags = []Link{}
 	fandomMatches := node.Find(".fandoms.heading > a")
 	if len(fandomMatches.Nodes) < 1 {
-		return nil, errors.New("unable to match fandom metadata node")
+		err := errors.New("unable to match fandom metadata node")
+		if state.fail("FandomTags", ".fandoms.heading > a", node, err) {
+			return nil, err
+		}
 	}
 
 	for i := 0; i < len(fandomMatches.Nodes); i++ {
@@ -87,12 +119,20 @@ func (client *AO3Client) parseIndexedWorkNode(node *goquery.Selection) (*Indexed
 
 		fandomLink, ok := fandomNode.Attr("href")
 		if !ok {
-			return nil, errors.New("unable to extract href attribute from fandom metadata")
+			err := errors.New("unable to extract href attribute from fandom metadata")
+			if state.fail("FandomTags", ".fandoms.heading > a", fandomNode, err) {
+				return nil, err
+			}
+			continue
 		}
 
 		fandomSlugMatches := fandomSlugRegex.FindStringSubmatch(fandomLink)
 		if len(fandomSlugMatches) != 2 {
-			return nil, errors.New("unable to parse fandom link")
+			err := errors.New("unable to parse fandom link")
+			if state.fail("FandomTags", ".fandoms.heading > a", fandomNode, err) {
+				return nil, err
+			}
+			continue
 		}
 
 		work.FandomTags = append(work.FandomTags, Link{
@@ -104,42 +144,56 @@ func (client *AO3Client) parseIndexedWorkNode(node *goquery.Selection) (*Indexed
 	// Extract the language string by matching against <dd class="language">
 	languageMatches := node.Find("dd.language")
 	if len(languageMatches.Nodes) != 1 {
-		return nil, errors.New("unable to match language node")
+		err := errors.New("unable to match language node")
+		if state.fail("Language", "dd.language", node, err) {
+			return nil, err
+		}
+	} else {
+		work.Language = languageMatches.First().Text()
 	}
-	work.Language = languageMatches.First().Text()
 
 	// Extract the words string by matching against <dd class="words">
 	// Note that the word count may contain commas (e.g., 3,884) or may not
 	// contain any count at all.
 	wordsMatches := node.Find("dd.words")
 	if len(wordsMatches.Nodes) != 1 {
-		return nil, errors.New("unable to match word count node")
+		err := errors.New("unable to match word count node")
+		if state.fail("Words", "dd.words", node, err) {
+			return nil, err
+		}
+	} else {
+		// Extracting the count deviates from the standard if statement pattern as
+		// the word count node may be present but the word count itself may be missing.
+		wordMatch := wordsMatches.First().Text()
+		wordCount, err := AtoiWithComma(wordMatch)
+		if err == nil {
+			work.Words = wordCount
+		}
 	}
 
 	var err error
 
-	// Extracting the count deviates from the standard if statement pattern as
-	// the word count node may be present but the word count itself may be missing.
-	wordMatch := wordsMatches.First().Text()
-	wordCount, err := AtoiWithComma(wordMatch)
-	if err == nil {
-		work.Words = wordCount
-	}
-
 	// Extract the chapters string by matching against <dd class="chapters">
 	// Examples: "1/1", "3/?"
 	chaptersMatches := node.Find("dd.chapters")
 	if len(chaptersMatches.Nodes) != 1 {
-		return nil, errors.New("unable to match work chapters count node")
+		err := errors.New("unable to match work chapters count node")
+		if state.fail("Chapters", "dd.chapters", node, err) {
+			return nil, err
+		}
+	} else {
+		work.Chapters = chaptersMatches.First().Text()
 	}
-	work.Chapters = chaptersMatches.First().Text()
 
 	// Extract the optional comments count by matching against <dd class="comments">
 	commentsMatches := node.Find("dd.comments > a")
 	if len(commentsMatches.Nodes) == 1 {
 		work.Comments, err = AtoiWithComma(commentsMatches.First().Text())
 		if err != nil {
-			return nil, errors.New("unable to convert bookmarks count to integer")
+			err := errors.New("unable to convert bookmarks count to integer")
+			if state.fail("Comments", "dd.comments > a", commentsMatches, err) {
+				return nil, err
+			}
 		}
 	}
 
@@ -149,7 +203,10 @@ func (client *AO3Client) parseIndexedWorkNode(node *goquery.Selection) (*Indexed
 	if len(kudosMatches.Nodes) == 1 {
 		work.Kudos, err = AtoiWithComma(kudosMatches.First().Text())
 		if err != nil {
-			return nil, errors.New("unable to convert kudos count to integer")
+			err := errors.New("unable to convert kudos count to integer")
+			if state.fail("Kudos", "dd.kudos > a", kudosMatches, err) {
+				return nil, err
+			}
 		}
 	}
 
@@ -158,7 +215,10 @@ func (client *AO3Client) parseIndexedWorkNode(node *goquery.Selection) (*Indexed
 	if len(bookmarksMatches.Nodes) == 1 {
 		work.Bookmarks, err = AtoiWithComma(bookmarksMatches.First().Text())
 		if err != nil {
-			return nil, errors.New("unable to convert bookmarks count to integer")
+			err := errors.New("unable to convert bookmarks count to integer")
+			if state.fail("Bookmarks", "dd.bookmarks > a", bookmarksMatches, err) {
+				return nil, err
+			}
 		}
 	}
 
@@ -167,7 +227,10 @@ func (client *AO3Client) parseIndexedWorkNode(node *goquery.Selection) (*Indexed
 	if len(hitsMatches.Nodes) == 1 {
 		work.Hits, err = AtoiWithComma(hitsMatches.First().Text())
 		if err != nil {
-			return nil, errors.New("unable to convert hits count to integer")
+			err := errors.New("unable to convert hits count to integer")
+			if state.fail("Hits", "dd.hits", hitsMatches, err) {
+				return nil, err
+			}
 		}
 	}
 
@@ -175,24 +238,10 @@ func (client *AO3Client) parseIndexedWorkNode(node *goquery.Selection) (*Indexed
 	work.IsSeries = false
 	seriesMatches := node.Find(".series > li")
 	if len(seriesMatches.Nodes) == 1 {
-		// Extract the HTML with format "Part <strong>PART</strong> of <a href="/series/SLUG">TITLE</a>"
-		// and use a regex to extract the three relevant parts
-		seriesHTML, err := seriesMatches.Html()
-		if err != nil {
-			return nil, errors.New("unable to extract HTML attribute from series node")
-		}
-
-		seriesValueMatches := seriesRegex.FindStringSubmatch(seriesHTML)
-		if len(seriesValueMatches) != 4 {
-			return nil, errors.New("unable to parse series metadata from series node HTML")
-		}
-
-		work.IsSeries = true
-		work.Series.Slug = seriesValueMatches[2]
-		work.Series.Text = seriesValueMatches[3]
-		work.SeriesPart, err = strconv.Atoi(seriesValueMatches[1])
-		if err != nil {
-			return nil, errors.New("unable to convert series part to integer")
+		if err := parseIndexedWorkSeries(&work, seriesMatches, seriesRegex); err != nil {
+			if state.fail("Series", ".series > li", seriesMatches, err) {
+				return nil, err
+			}
 		}
 	}
 
@@ -205,34 +254,10 @@ func (client *AO3Client) parseIndexedWorkNode(node *goquery.Selection) (*Indexed
 	for i := range optionalTagMatches.Nodes {
 		tagNode := optionalTagMatches.Eq(i)
 
-		// Retrieve the Slug and Text from the nested link
-		tagNodeHtml, err := tagNode.Html()
-		if err != nil {
-			return nil, errors.New("unable to extract HTML from optional tag node")
-		}
-
-		tagMatches := tagRegex.FindStringSubmatch(tagNodeHtml)
-		if len(tagMatches) != 3 {
-			return nil, errors.New("unable to extract metadata from optional tag node HTML")
-		}
-		link := Link{Slug: tagMatches[1], Text: tagMatches[2]}
-
-		// Retrieve the type of tag
-		tagType, ok := tagNode.Attr("class")
-		if !ok {
-			return nil, errors.New("unable to extract class attribute from tag node")
-		}
-
-		if strings.Contains(tagType, "warnings") {
-			work.WarningTags = append(work.WarningTags, link)
-		} else if strings.Contains(tagType, "relationships") {
-			work.RelationshipTags = append(work.RelationshipTags, link)
-		} else if strings.Contains(tagType, "characters") {
-			work.CharacterTags = append(work.CharacterTags, link)
-		} else if strings.Contains(tagType, "freeforms") {
-			work.FreeformTags = append(work.FreeformTags, link)
-		} else {
-			return nil, errors.New("unable to infer tag type from HTML")
+		if err := parseIndexedWorkTag(&work, tagNode, tagRegex); err != nil {
+			if state.fail("Tags", "ul.tags.commas > li", tagNode, err) {
+				return nil, err
+			}
 		}
 	}
 
@@ -240,48 +265,45 @@ func (client *AO3Client) parseIndexedWorkNode(node *goquery.Selection) (*Indexed
 	// and whether a work is in progress (iswip)
 	symbolMatches := node.Find(".required-tags")
 	if len(symbolMatches.Nodes) != 1 {
-		return nil, errors.New("unable to match symbols node")
-	}
-	symbolNode := symbolMatches.First()
-
-	symbolRatingMatches := symbolNode.Find(".rating")
-	if len(symbolRatingMatches.Nodes) != 1 {
-		return nil, errors.New("unable to match symbol rating node")
-	}
-
-	work.Rating, ok = symbolRatingMatches.First().Attr("title")
-	if !ok {
-		return nil, errors.New("unable to extract title attribute from symbol rating node")
-	}
-
-	symbolWarningsMatches := symbolNode.Find(".warnings")
-	if len(symbolWarningsMatches.Nodes) != 1 {
-		return nil, errors.New("unable to match symbol warnings node")
-	}
-
-	work.Warnings, ok = symbolWarningsMatches.First().Attr("title")
-	if !ok {
-		return nil, errors.New("unable to extract title attribute from symbol warnings node")
-	}
-
-	symbolCategoryMatches := symbolNode.Find(".category")
-	if len(symbolCategoryMatches.Nodes) != 1 {
-		return nil, errors.New("unable to match symbol category node")
-	}
-
-	work.Category, ok = symbolCategoryMatches.First().Attr("title")
-	if !ok {
-		return nil, errors.New("unable to extract title attribute from symbol category node")
-	}
+		err := errors.New("unable to match symbols node")
+		if state.fail("Rating", ".required-tags", node, err) {
+			return nil, err
+		}
+	} else {
+		symbolNode := symbolMatches.First()
+
+		symbols := []struct {
+			field    string
+			selector string
+			name     string
+			value    *string
+		}{
+			{"Rating", ".rating", "rating", &work.Rating},
+			{"Warnings", ".warnings", "warnings", &work.Warnings},
+			{"Category", ".category", "category", &work.Category},
+			{"Status", ".iswip", "complete", &work.Status},
+		}
 
-	symbolCompleteMatches := symbolNode.Find(".iswip")
-	if len(symbolCompleteMatches.Nodes) != 1 {
-		return nil, errors.New("unable to match symbol complete node")
-	}
+		for _, symbol := range symbols {
+			symbolValueMatches := symbolNode.Find(symbol.selector)
+			if len(symbolValueMatches.Nodes) != 1 {
+				err := errors.New("unable to match symbol " + symbol.name + " node")
+				if state.fail(symbol.field, ".required-tags "+symbol.selector, symbolNode, err) {
+					return nil, err
+				}
+				continue
+			}
 
-	work.Status, ok = symbolCompleteMatches.First().Attr("title")
-	if !ok {
-		return nil, errors.New("unable to extract title attribute from symbol complete node")
+			title, ok := symbolValueMatches.First().Attr("title")
+			if !ok {
+				err := errors.New("unable to extract title attribute from symbol " + symbol.name + " node")
+				if state.fail(symbol.field, ".required-tags "+symbol.selector, symbolValueMatches, err) {
+					return nil, err
+				}
+				continue
+			}
+			*symbol.value = title
+		}
 	}
 
 	// Retrieve the summary and sanitize the HTML tags
@@ -289,9 +311,13 @@ func (client *AO3Client) parseIndexedWorkNode(node *goquery.Selection) (*Indexed
 	if len(summaryMatches.Nodes) == 1 {
 		summaryHTML, err := summaryMatches.First().Html()
 		if err != nil {
-			return nil, errors.New("unable to fetch HTML from summary node")
+			err := errors.New("unable to fetch HTML from summary node")
+			if state.fail("Summary", "blockquote.summary", summaryMatches, err) {
+				return nil, err
+			}
+		} else {
+			work.Summary = client.HtmlSanitizer.Sanitize(summaryHTML)
 		}
-		work.Summary = client.HtmlSanitizer.Sanitize(summaryHTML)
 	}
 
 	// Retrieve the the header which contains the title, authors and recipients.
@@ -307,84 +333,179 @@ func (client *AO3Client) parseIndexedWorkNode(node *goquery.Selection) (*Indexed
 	// In this case, the name should be "AUTHOR_NAME [archived by ARCHIVIST_NAME]"
 	// and the link should be the link of the archivist.
 	// In all other cases, it suffices to iterate through `a[rel="author"]` nodes.
+	work.Authors = []Link{}
+	work.Recipients = []Link{}
+
 	headingMatches := node.Find(".header.module > h4.heading")
 	if len(headingMatches.Nodes) < 1 {
-		return nil, errors.New("unable to extract heading metadata node")
+		err := errors.New("unable to extract heading metadata node")
+		if state.fail("Title", ".header.module > h4.heading", node, err) {
+			return nil, err
+		}
+	} else if err := parseIndexedWorkHeading(&work, headingMatches.First(), archivistRegex, userSlugRegex); err != nil {
+		if state.fail("Authors", ".header.module > h4.heading", headingMatches, err) {
+			return nil, err
+		}
+	}
+
+	work.Diagnostics = state.diagnostics
+
+	return &work, nil
+}
+
+// parseIndexedWorkSeries extracts the HTML with format "Part <strong>PART</strong>
+// of <a href="/series/SLUG">TITLE</a>" and uses a regex to extract the three
+// relevant parts
+func parseIndexedWorkSeries(work *IndexedWork, seriesNode *goquery.Selection, seriesRegex *regexp.Regexp) error {
+	seriesHTML, err := seriesNode.Html()
+	if err != nil {
+		return errors.New("unable to extract HTML attribute from series node")
+	}
+
+	seriesValueMatches := seriesRegex.FindStringSubmatch(seriesHTML)
+	if len(seriesValueMatches) != 4 {
+		return errors.New("unable to parse series metadata from series node HTML")
+	}
+
+	seriesPart, err := strconv.Atoi(seriesValueMatches[1])
+	if err != nil {
+		return errors.New("unable to convert series part to integer")
+	}
+
+	work.IsSeries = true
+	work.Series.Slug = seriesValueMatches[2]
+	work.Series.Text = seriesValueMatches[3]
+	work.SeriesPart = seriesPart
+
+	return nil
+}
+
+// parseIndexedWorkTag appends an optional tag to the matching list of tags
+func parseIndexedWorkTag(work *IndexedWork, tagNode *goquery.Selection, tagRegex *regexp.Regexp) error {
+	// Tags hidden by the user's preferences are replaced by a link showing them
+	if len(tagNode.Find(hiddenTagsSelector).Nodes) > 0 {
+		tagType, _ := tagNode.Attr("class")
+		if strings.Contains(tagType, "warnings") {
+			work.HasHiddenWarnings = true
+		} else if strings.Contains(tagType, "freeforms") {
+			work.HasHiddenFreeformTags = true
+		} else {
+			return errors.New("unable to infer hidden tag type from HTML")
+		}
+		return nil
+	}
+
+	// Retrieve the Slug and Text from the nested link
+	tagNodeHtml, err := tagNode.Html()
+	if err != nil {
+		return errors.New("unable to extract HTML from optional tag node")
+	}
+
+	tagMatches := tagRegex.FindStringSubmatch(tagNodeHtml)
+	if len(tagMatches) != 3 {
+		return errors.New("unable to extract metadata from optional tag node HTML")
 	}
-	headingNode := headingMatches.First()
+	link := Link{Slug: tagMatches[1], Text: tagMatches[2]}
 
+	// Retrieve the type of tag
+	tagType, ok := tagNode.Attr("class")
+	if !ok {
+		return errors.New("unable to extract class attribute from tag node")
+	}
+
+	if strings.Contains(tagType, "warnings") {
+		work.WarningTags = append(work.WarningTags, link)
+	} else if strings.Contains(tagType, "relationships") {
+		work.RelationshipTags = append(work.RelationshipTags, link)
+	} else if strings.Contains(tagType, "characters") {
+		work.CharacterTags = append(work.CharacterTags, link)
+	} else if strings.Contains(tagType, "freeforms") {
+		work.FreeformTags = append(work.FreeformTags, link)
+	} else {
+		return errors.New("unable to infer tag type from HTML")
+	}
+
+	return nil
+}
+
+// parseIndexedWorkHeading extracts the title, authors and recipients from the
+// heading of a listing
+func parseIndexedWorkHeading(work *IndexedWork, headingNode *goquery.Selection, archivistRegex *regexp.Regexp, userSlugRegex *regexp.Regexp) error {
 	titleLinkMatches := headingNode.Find("a")
 	if len(titleLinkMatches.Nodes) < 1 {
-		return nil, errors.New("unable to extract work title header node")
+		return errors.New("unable to extract work title header node")
 	}
 
 	// Extract the title from the header of the box
 	work.Title = titleLinkMatches.First().Text()
+	work.IsRestricted = hasRestrictedIcon(headingNode)
 
 	work.IsAnonymous = len(titleLinkMatches.Nodes) == 1
-	work.Authors = []Link{}
-	work.Recipients = []Link{}
-	if !work.IsAnonymous {
-		if strings.Contains(headingNode.Text(), "[archived by") {
-			// Extract archivist as detailed above
-			archivistNameMatches := archivistRegex.FindStringSubmatch(headingNode.Text())
-			if len(archivistNameMatches) < 1 {
-				return nil, errors.New("parsing archivist name failed")
-			}
+	if work.IsAnonymous {
+		return nil
+	}
 
-			archivistNode := titleLinkMatches.Eq(1)
-			archivistLink, ok := archivistNode.Attr("href")
-			if !ok {
-				return nil, errors.New("unable to extract href attribute from archivist link")
-			}
+	if strings.Contains(headingNode.Text(), "[archived by") {
+		// Extract archivist as detailed above
+		archivistNameMatches := archivistRegex.FindStringSubmatch(headingNode.Text())
+		if len(archivistNameMatches) < 1 {
+			return errors.New("parsing archivist name failed")
+		}
 
-			archivistSlugMatches := userSlugRegex.FindStringSubmatch(archivistLink)
-			if len(archivistSlugMatches) != 3 {
-				return nil, errors.New("unable to extract slug from archivist link")
-			}
+		archivistNode := titleLinkMatches.Eq(1)
+		archivistLink, ok := archivistNode.Attr("href")
+		if !ok {
+			return errors.New("unable to extract href attribute from archivist link")
+		}
 
-			work.Authors = []Link{{
-				Text: archivistNameMatches[1],
-				Slug: archivistSlugMatches[1],
-			}}
-		} else {
-			// Extract authors and recipients
+		archivistSlugMatches := userSlugRegex.FindStringSubmatch(archivistLink)
+		if len(archivistSlugMatches) != 3 {
+			return errors.New("unable to extract slug from archivist link")
+		}
 
-			// There can be multiple authors and recipients, hence we need to
-			// loop through all nodes. Authors will have a "rel" attr with value
-			// "author", so we can use that to figure out whether each user is
-			// an author or recipient
-			for i := 1; i < len(titleLinkMatches.Nodes); i++ {
-				userNode := titleLinkMatches.Eq(i)
+		work.Authors = []Link{{
+			Text: archivistNameMatches[1],
+			Slug: archivistSlugMatches[1],
+		}}
 
-				user := Link{}
+		return nil
+	}
 
-				// Extract user name
-				user.Text = userNode.Text()
+	// Extract authors and recipients
 
-				// Extract user slug
-				userLink, ok := userNode.Attr("href")
-				if !ok {
-					return nil, errors.New("unable to extract href attribute from user link")
-				}
+	// There can be multiple authors and recipients, hence we need to
+	// loop through all nodes. Authors will have a "rel" attr with value
+	// "author", so we can use that to figure out whether each user is
+	// an author or recipient
+	for i := 1; i < len(titleLinkMatches.Nodes); i++ {
+		userNode := titleLinkMatches.Eq(i)
 
-				userSlugMatches := userSlugRegex.FindStringSubmatch(userLink)
-				if len(userSlugMatches) != 3 {
-					return nil, errors.New("unable to parse metadata from work user link node: " + userLink)
-				}
+		user := Link{}
 
-				// Append to the correct type of user
-				userRel, ok := userNode.Attr("rel")
-				if ok && userRel == "author" {
-					user.Slug = userSlugMatches[1]
-					work.Authors = append(work.Authors, user)
-				} else {
-					user.Slug = userSlugMatches[1]
-					work.Recipients = append(work.Recipients, user)
-				}
-			}
+		// Extract user name
+		user.Text = userNode.Text()
+
+		// Extract user slug
+		userLink, ok := userNode.Attr("href")
+		if !ok {
+			return errors.New("unable to extract href attribute from user link")
+		}
+
+		userSlugMatches := userSlugRegex.FindStringSubmatch(userLink)
+		if len(userSlugMatches) != 3 {
+			return errors.New("unable to parse metadata from work user link node: " + userLink)
+		}
+
+		// Append to the correct type of user
+		userRel, ok := userNode.Attr("rel")
+		if ok && userRel == "author" {
+			user.Slug = userSlugMatches[1]
+			work.Authors = append(work.Authors, user)
+		} else {
+			user.Slug = userSlugMatches[1]
+			work.Recipients = append(work.Recipients, user)
 		}
 	}
 
-	return &work, nil
+	return nil
 }
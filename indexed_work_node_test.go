@@ -3,6 +3,7 @@ package ao3
 import (
 	"testing"
 	"net/http"
+	"strings"
 	"github.com/PuerkitoBio/goquery"
 	"github.com/stretchr/testify/assert"
 )
@@ -79,12 +80,9 @@ func TestIndexedWorkNode(t *testing.T) {
 	}
 
 	// Fetch the work
-	client, ao3Err := InitAO3Client(nil, AO3Policy)
-	if ao3Err != nil {
-		t.Fatal(ao3Err.Error())
-	}
+	client := newFixtureClient(t)
 
-	res, err := client.HttpClient.Get(baseURL + endpoint)
+	res, err := client.HttpClient.Get(client.endpointURL(endpoint))
 	if err != nil {
 		t.Fatal(err.Error())
 	}
@@ -103,7 +101,7 @@ func TestIndexedWorkNode(t *testing.T) {
 		t.Fatal("number of work results is not one")
 	}
 
-	work, err := client.parseIndexedWorkNode(workNodeMatches.First())
+	work, err := client.parseIndexedWorkNode("", workNodeMatches.First())
 	if err != nil {
 		t.Fatal(err.Error())
 	}
@@ -167,12 +165,9 @@ func TestIndexedWorkNodeWithArchivist(t *testing.T) {
 	}
 
 	// Fetch the work
-	client, ao3Err := InitAO3Client(nil, AO3Policy)
-	if ao3Err != nil {
-		t.Fatal(ao3Err.Error())
-	}
+	client := newFixtureClient(t)
 
-	res, err := client.HttpClient.Get(baseURL + endpoint)
+	res, err := client.HttpClient.Get(client.endpointURL(endpoint))
 	if err != nil {
 		t.Fatal(err.Error())
 	}
@@ -191,7 +186,7 @@ func TestIndexedWorkNodeWithArchivist(t *testing.T) {
 		t.Fatal("unable to find work nodes")
 	}
 
-	work, err := client.parseIndexedWorkNode(workNodes.First())
+	work, err := client.parseIndexedWorkNode("", workNodes.First())
 	if err != nil {
 		t.Fatal(err.Error())
 	}
@@ -226,12 +221,9 @@ func TestIndexedWorkNodeWithMultipleAuthorsAndRecipients(t *testing.T) {
 	}
 
 	// Fetch the work
-	client, ao3Err := InitAO3Client(nil, AO3Policy)
-	if ao3Err != nil {
-		t.Fatal(ao3Err.Error())
-	}
+	client := newFixtureClient(t)
 
-	res, err := client.HttpClient.Get(baseURL + endpoint)
+	res, err := client.HttpClient.Get(client.endpointURL(endpoint))
 	if err != nil {
 		t.Fatal(err.Error())
 	}
@@ -250,7 +242,7 @@ func TestIndexedWorkNodeWithMultipleAuthorsAndRecipients(t *testing.T) {
 		t.Fatal("number of work results is not one")
 	}
 
-	work, err := client.parseIndexedWorkNode(workNodeMatches.First())
+	work, err := client.parseIndexedWorkNode("", workNodeMatches.First())
 	if err != nil {
 		t.Fatal(err.Error())
 	}
@@ -264,12 +256,9 @@ func TestIndexedWorkNodeWithAnonymous(t *testing.T) {
 	const endpoint = "/works/search?utf8=%E2%9C%93&work_search%5Btitle%5D=Serious+Business&work_search%5Bcreators%5D=Anonymous&work_search%5Bfandom_names%5D=Doctor+Who&work_search%5Bwarning_ids%5D%5B%5D=16&work_search%5Bcategory_ids%5D%5B%5D=21"
 
 	// Fetch the work
-	client, ao3Err := InitAO3Client(nil, AO3Policy)
-	if ao3Err != nil {
-		t.Fatal(ao3Err.Error())
-	}
+	client := newFixtureClient(t)
 
-	res, err := client.HttpClient.Get(baseURL + endpoint)
+	res, err := client.HttpClient.Get(client.endpointURL(endpoint))
 	if err != nil {
 		t.Fatal(err.Error())
 	}
@@ -288,7 +277,7 @@ func TestIndexedWorkNodeWithAnonymous(t *testing.T) {
 		t.Fatal("number of work results is not one")
 	}
 
-	work, err := client.parseIndexedWorkNode(workNodeMatches.First())
+	work, err := client.parseIndexedWorkNode("", workNodeMatches.First())
 	if err != nil {
 		t.Fatal(err.Error())
 	}
@@ -301,12 +290,9 @@ func TestIndexedWorkNodeWithSeries(t *testing.T) {
 	const endpoint = "/works/search?utf8=%E2%9C%93&work_search%5Btitle%5D=Winnipeg&work_search%5Bcreators%5D=Molly&work_search%5Bfandom_names%5D=Highlander%3A+The+Series&work_search%5Bwarning_ids%5D%5B%5D=16&work_search%5Bcategory_ids%5D%5B%5D=23"
 
 	// Fetch the work
-	client, ao3Err := InitAO3Client(nil, AO3Policy)
-	if ao3Err != nil {
-		t.Fatal(ao3Err.Error())
-	}
+	client := newFixtureClient(t)
 
-	res, err := client.HttpClient.Get(baseURL + endpoint)
+	res, err := client.HttpClient.Get(client.endpointURL(endpoint))
 	if err != nil {
 		t.Fatal(err.Error())
 	}
@@ -325,7 +311,7 @@ func TestIndexedWorkNodeWithSeries(t *testing.T) {
 		t.Fatal("number of work results is not one")
 	}
 
-	work, err := client.parseIndexedWorkNode(workNodeMatches.First())
+	work, err := client.parseIndexedWorkNode("", workNodeMatches.First())
 	if err != nil {
 		t.Fatal(err.Error())
 	}
@@ -334,3 +320,46 @@ func TestIndexedWorkNodeWithSeries(t *testing.T) {
 	assert.Equal(t, Link{Slug: "62", Text: "Canadian Shack"}, work.Series)
 	assert.Equal(t, 1, work.SeriesPart)
 }
+
+const testHiddenTagsBlurb = `<li id="work_5" class="work blurb group" role="article">
+  <div class="header module">
+    <h4 class="heading"><a href="/works/5">Hidden</a> by <a rel="author" href="/users/author/pseuds/author">author</a></h4>
+    <h5 class="fandoms heading"><a class="tag" href="/tags/No%20Fandom/works">No Fandom</a></h5>
+    <ul class="required-tags">
+      <li><span class="rating-general-audience rating" title="General Audiences"></span></li>
+      <li><span class="warnings" title="Choose Not To Use Archive Warnings"></span></li>
+      <li><span class="category" title="Gen"></span></li>
+      <li><span class="iswip" title="Complete Work"></span></li>
+    </ul>
+    <p class="datetime">23 Nov 2015</p>
+  </div>
+  <ul class="tags commas">
+    <li class="warnings" id="work_5_category_warnings"><strong><a class="warnings" data-remote="true" href="/tags/show_hidden?creation_id=5&amp;creation_type=Work&amp;tag_type=warnings">Show warnings</a></strong></li>
+    <li class="characters"><a class="tag" href="/tags/A/works">A</a></li>
+    <li class="freeforms" id="work_5_category_freeforms"><strong><a class="freeforms" data-remote="true" href="/tags/show_hidden?creation_id=5&amp;creation_type=Work&amp;tag_type=freeforms">Show additional tags</a></strong></li>
+  </ul>
+  <dl class="stats"><dd class="language">English</dd><dd class="words">1,000</dd><dd class="chapters">1/1</dd></dl>
+</li>`
+
+// TestIndexedWorkNodeWithHiddenTags ensures warnings and additional tags
+// hidden by the user's preferences are reported instead of failing the listing
+func TestIndexedWorkNodeWithHiddenTags(t *testing.T) {
+	doc, err := goquery.NewDocumentFromReader(strings.NewReader(`<ol class="work index group">` + testHiddenTagsBlurb + `</ol>`))
+	if err != nil {
+		t.Fatal(err.Error())
+	}
+
+	client := newFixtureClient(t)
+
+	work, err := client.parseIndexedWorkNode("", doc.Find(".work.blurb.group").First())
+	if err != nil {
+		t.Fatal(err.Error())
+	}
+
+	assert.True(t, work.HasHiddenWarnings)
+	assert.True(t, work.HasHiddenFreeformTags)
+	assert.Empty(t, work.WarningTags)
+	assert.Empty(t, work.FreeformTags)
+	assert.Equal(t, []Link{{Text: "A", Slug: "A"}}, work.CharacterTags)
+	assert.Empty(t, work.Diagnostics)
+}
@@ -0,0 +1,100 @@
+package ao3
+
+import (
+	"bytes"
+	"context"
+	"net/http"
+	"net/url"
+	"regexp"
+	"strings"
+	"github.com/PuerkitoBio/goquery"
+)
+
+// KudosResult is the outcome of leaving kudos on a work
+type KudosResult int
+
+const (
+	// KudosLeft means the kudos were left
+	KudosLeft KudosResult = iota + 1
+	// KudosAlreadyLeft means the user, or a guest with the same IP address, has
+	// already left kudos on the work
+	KudosAlreadyLeft
+	// KudosOwnWork means the logged-in user is a creator of the work
+	KudosOwnWork
+)
+
+var kudosResultNames = map[KudosResult]string{
+	KudosLeft:        "kudos left",
+	KudosAlreadyLeft: "already left kudos",
+	KudosOwnWork:     "own work",
+}
+
+func (result KudosResult) String() string {
+	if name, ok := kudosResultNames[result]; ok {
+		return name
+	}
+	return "unknown"
+}
+
+// kudosOutcomes match the flash messages AO3 shows after kudos are submitted
+var kudosOutcomes = []struct {
+	result  KudosResult
+	pattern *regexp.Regexp
+}{
+	{result: KudosLeft, pattern: regexp.MustCompile(`(?i)thank you for leaving kudos`)},
+	{result: KudosAlreadyLeft, pattern: regexp.MustCompile(`(?i)already left kudos`)},
+	{result: KudosOwnWork, pattern: regexp.MustCompile(`(?i)can't leave kudos on your own work`)},
+}
+
+// AddKudos leaves kudos on a work, as a guest or, if the client is
+// authenticated, as the logged-in user. Kudos which were already left, or which
+// cannot be left on the user's own work, are reported by the result rather
+// than as errors.
+//
+// Endpoint: https://archiveofourown.org/works/[work]/kudos
+func (client *AO3Client) AddKudos(id string) (KudosResult, *AO3Error) {
+	return client.AddKudosWithContext(context.Background(), id)
+}
+
+// AddKudosWithContext is AddKudos with a context which cancels the requests
+// when it is done
+func (client *AO3Client) AddKudosWithContext(ctx context.Context, id string) (KudosResult, *AO3Error) {
+	if ao3Err := client.requireCookieJar("leaving kudos"); ao3Err != nil {
+		return 0, ao3Err
+	}
+
+	// The work page is fetched for its authenticity token, which guests need
+	// as much as logged-in users
+	token, ao3Err := client.fetchCSRFToken(ctx, workEndpoint(id), "fetching work")
+	if ao3Err != nil {
+		return 0, ao3Err
+	}
+
+	form := url.Values{}
+	form.Set("authenticity_token", token)
+	form.Set("kudo[commentable_id]", id)
+	form.Set("kudo[commentable_type]", "Work")
+
+	res, ao3Err := client.post(ctx, "/works/"+id+"/kudos", form, "leaving kudos")
+	if ao3Err != nil {
+		return 0, ao3Err
+	}
+
+	doc, err := goquery.NewDocumentFromReader(bytes.NewReader(res.body))
+	if err != nil {
+		return 0, WrapError(http.StatusUnprocessableEntity, err, "parsing kudos response with goquery failed")
+	}
+
+	message := strings.TrimSpace(doc.Find(".flash").Text())
+	for _, outcome := range kudosOutcomes {
+		if outcome.pattern.MatchString(message) {
+			return outcome.result, nil
+		}
+	}
+
+	if message != "" {
+		return 0, NewError(http.StatusUnprocessableEntity, "leaving kudos failed: "+message).withKind(KindUnknown)
+	}
+
+	return 0, NewError(http.StatusUnprocessableEntity, "unable to find the outcome of leaving kudos")
+}
@@ -0,0 +1,94 @@
+package ao3
+
+import (
+	"errors"
+	"net/http"
+	"testing"
+	"github.com/stretchr/testify/assert"
+)
+
+// newKudosServer returns a client pointed at a fake AO3 on which kudos can be
+// left once per session on work 1, and never by "reader" on their own work 2
+func newKudosServer(t *testing.T) (*AO3Client, func()) {
+	kudos := map[string]bool{}
+
+	client, server := newTestClient(t, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		session, _ := r.Cookie("_otwarchive_session")
+		if session == nil {
+			session = &http.Cookie{Name: "_otwarchive_session", Value: "guest", Path: "/"}
+			http.SetCookie(w, session)
+		}
+
+		switch {
+		case (r.URL.Path == "/works/1" || r.URL.Path == "/works/2") && r.Method == http.MethodGet:
+			w.Write([]byte(`<html><head><meta name="csrf-token" content="token-` + session.Value + `" /></head><body></body></html>`))
+
+		case (r.URL.Path == "/works/1/kudos" || r.URL.Path == "/works/2/kudos") && r.Method == http.MethodPost:
+			if r.PostFormValue("authenticity_token") != "token-"+session.Value || r.PostFormValue("kudo[commentable_type]") != "Work" {
+				w.WriteHeader(http.StatusUnprocessableEntity)
+				return
+			}
+
+			id := r.PostFormValue("kudo[commentable_id]")
+			switch {
+			case id == "2" && session.Value == "reader":
+				w.Write([]byte(`<body><div class="flash kudos_error">You can't leave kudos on your own work.</div></body>`))
+			case kudos[session.Value+"/"+id]:
+				w.Write([]byte(`<body><div class="flash kudos_error">You have already left kudos here. :)</div></body>`))
+			default:
+				kudos[session.Value+"/"+id] = true
+				w.Write([]byte(`<body><div class="flash comment_notice">Thank you for leaving kudos!</div></body>`))
+			}
+
+		default:
+			http.NotFound(w, r)
+		}
+	}))
+
+	return client, server.Close
+}
+
+// TestAddKudos ensures each outcome of leaving kudos is distinguished, for
+// guests and logged-in users alike
+func TestAddKudos(t *testing.T) {
+	client, closeServer := newKudosServer(t)
+	defer closeServer()
+
+	result, err := client.AddKudos("1")
+	if err != nil {
+		t.Fatal(err.Error())
+	}
+	assert.Equal(t, KudosLeft, result)
+
+	result, err = client.AddKudos("1")
+	if err != nil {
+		t.Fatal(err.Error())
+	}
+	assert.Equal(t, KudosAlreadyLeft, result)
+	assert.Equal(t, "already left kudos", result.String())
+
+	importTestSession(t, client, "reader")
+
+	result, err = client.AddKudos("1")
+	if err != nil {
+		t.Fatal(err.Error())
+	}
+	assert.Equal(t, KudosLeft, result)
+
+	result, err = client.AddKudos("2")
+	if err != nil {
+		t.Fatal(err.Error())
+	}
+	assert.Equal(t, KudosOwnWork, result)
+}
+
+// TestAddKudosNotFound ensures kudos on a missing work return ErrNotFound
+func TestAddKudosNotFound(t *testing.T) {
+	client, closeServer := newKudosServer(t)
+	defer closeServer()
+
+	_, err := client.AddKudos("3")
+	if assert.NotNil(t, err) {
+		assert.True(t, errors.Is(err, ErrNotFound))
+	}
+}
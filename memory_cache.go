@@ -0,0 +1,108 @@
+package ao3
+
+import (
+	"container/list"
+	"sync"
+)
+
+// MemoryCache is an in-memory Cache which evicts the least recently used entry
+// once it holds more than its capacity
+type MemoryCache struct {
+	mu       sync.Mutex
+	capacity int
+	entries  map[string]*list.Element
+	order    *list.List
+}
+
+type memoryCacheItem struct {
+	key   string
+	entry CacheEntry
+}
+
+// NewMemoryCache creates a MemoryCache holding up to capacity entries
+func NewMemoryCache(capacity int) *MemoryCache {
+	if capacity < 1 {
+		capacity = 1
+	}
+
+	return &MemoryCache{
+		capacity: capacity,
+		entries:  map[string]*list.Element{},
+		order:    list.New(),
+	}
+}
+
+// Get returns a copy of the entry for key, marking it as recently used
+func (cache *MemoryCache) Get(key string) (*CacheEntry, bool) {
+	cache.mu.Lock()
+	defer cache.mu.Unlock()
+
+	element, ok := cache.entries[key]
+	if !ok {
+		return nil, false
+	}
+	cache.order.MoveToFront(element)
+
+	return copyCacheEntry(&element.Value.(*memoryCacheItem).entry), true
+}
+
+// Set stores a copy of entry, evicting the least recently used entry if the
+// cache is full
+func (cache *MemoryCache) Set(key string, entry *CacheEntry) {
+	cache.mu.Lock()
+	defer cache.mu.Unlock()
+
+	if element, ok := cache.entries[key]; ok {
+		element.Value.(*memoryCacheItem).entry = *copyCacheEntry(entry)
+		cache.order.MoveToFront(element)
+		return
+	}
+
+	cache.entries[key] = cache.order.PushFront(&memoryCacheItem{key: key, entry: *copyCacheEntry(entry)})
+
+	for cache.order.Len() > cache.capacity {
+		oldest := cache.order.Back()
+		cache.order.Remove(oldest)
+		delete(cache.entries, oldest.Value.(*memoryCacheItem).key)
+	}
+}
+
+func (cache *MemoryCache) Delete(key string) {
+	cache.mu.Lock()
+	defer cache.mu.Unlock()
+
+	if element, ok := cache.entries[key]; ok {
+		cache.order.Remove(element)
+		delete(cache.entries, key)
+	}
+}
+
+// Purge removes every entry whose key matches
+func (cache *MemoryCache) Purge(match func(key string) bool) {
+	cache.mu.Lock()
+	defer cache.mu.Unlock()
+
+	for key, element := range cache.entries {
+		if match(key) {
+			cache.order.Remove(element)
+			delete(cache.entries, key)
+		}
+	}
+}
+
+// Len returns the number of entries in the cache
+func (cache *MemoryCache) Len() int {
+	cache.mu.Lock()
+	defer cache.mu.Unlock()
+
+	return cache.order.Len()
+}
+
+// copyCacheEntry copies an entry so that callers modifying a returned body do
+// not modify the cache
+func copyCacheEntry(entry *CacheEntry) *CacheEntry {
+	copied := *entry
+	copied.Body = append([]byte(nil), entry.Body...)
+
+	return &copied
+}
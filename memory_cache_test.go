@@ -0,0 +1,65 @@
+package ao3
+
+import (
+	"strings"
+	"testing"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestMemoryCacheEvictsLeastRecentlyUsed(t *testing.T) {
+	cache := NewMemoryCache(2)
+
+	cache.Set("a", &CacheEntry{Body: []byte("a")})
+	cache.Set("b", &CacheEntry{Body: []byte("b")})
+
+	// Using "a" makes "b" the least recently used entry
+	_, ok := cache.Get("a")
+	assert.True(t, ok)
+
+	cache.Set("c", &CacheEntry{Body: []byte("c")})
+
+	_, ok = cache.Get("b")
+	assert.False(t, ok)
+
+	entry, ok := cache.Get("a")
+	assert.True(t, ok)
+	assert.Equal(t, "a", string(entry.Body))
+	assert.Equal(t, 2, cache.Len())
+
+	cache.Delete("a")
+	_, ok = cache.Get("a")
+	assert.False(t, ok)
+	assert.Equal(t, 1, cache.Len())
+}
+
+func TestMemoryCacheCopiesEntries(t *testing.T) {
+	cache := NewMemoryCache(1)
+
+	body := []byte("body")
+	cache.Set("a", &CacheEntry{Body: body})
+	body[0] = 'B'
+
+	entry, _ := cache.Get("a")
+	entry.Body[1] = 'O'
+
+	entry, _ = cache.Get("a")
+	assert.Equal(t, "body", string(entry.Body))
+}
+
+func TestMemoryCachePurge(t *testing.T) {
+	cache := NewMemoryCache(10)
+
+	cache.Set("/works/1 user:reader", &CacheEntry{Body: []byte("1")})
+	cache.Set("/works/2 user:reader", &CacheEntry{Body: []byte("2")})
+	cache.Set("/works/1 anonymous", &CacheEntry{Body: []byte("3")})
+
+	cache.Purge(func(key string) bool {
+		return strings.HasSuffix(key, " user:reader")
+	})
+
+	_, ok := cache.Get("/works/1 user:reader")
+	assert.False(t, ok)
+	_, ok = cache.Get("/works/1 anonymous")
+	assert.True(t, ok)
+	assert.Equal(t, 1, cache.Len())
+}
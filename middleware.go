@@ -0,0 +1,134 @@
+package ao3
+
+import (
+	"log"
+	"net/http"
+	"time"
+)
+
+// DefaultUserAgent identifies the package to AO3. Use UserAgentMiddleware to
+// send it, ideally with contact details appended so that AO3's admins can get
+// in touch about misbehaving scrapers.
+const DefaultUserAgent = "ao3-go (+https://github.com/kz/ao3-go)"
+
+// Middleware hooks into the requests made by a client. Any of the hooks may be
+// nil. Middleware is added with AO3Client.Use and runs in the order it was
+// added.
+//
+// Responses served from the client's Cache do not pass through middleware.
+type Middleware struct {
+	// BeforeRequest is called before each attempt of a request, including
+	// retries, and may modify the request. Returning an error aborts the
+	// request.
+	BeforeRequest func(req *http.Request) error
+
+	// AfterResponse is called after each attempt with the response, whose
+	// body must not be read or closed, or the error if no response was
+	// received. elapsed is the time taken to receive the response's headers.
+	AfterResponse func(req *http.Request, res *http.Response, err error, elapsed time.Duration)
+
+	// OnParseError is called with the URL of a page and a description of the
+	// field which could not be parsed. In lenient mode, it is called for each
+	// of the result's Diagnostics; otherwise, it is called once with the field
+	// whose error is returned.
+	OnParseError func(rawURL string, diagnostic ParseDiagnostic)
+}
+
+// Use appends middleware to the client's chain. It must not be called while
+// requests are in flight.
+func (client *AO3Client) Use(middleware ...Middleware) {
+	client.Middleware = append(client.Middleware, middleware...)
+}
+
+// UserAgentMiddleware sets the User-Agent header of every request, e.g. to
+// DefaultUserAgent
+func UserAgentMiddleware(userAgent string) Middleware {
+	return Middleware{
+		BeforeRequest: func(req *http.Request) error {
+			req.Header.Set("User-Agent", userAgent)
+			return nil
+		},
+	}
+}
+
+// HeaderMiddleware sets the given headers on every request, replacing any
+// values already set
+func HeaderMiddleware(header http.Header) Middleware {
+	// The header is copied so that later changes by the caller do not race
+	// with requests
+	copied := header.Clone()
+
+	return Middleware{
+		BeforeRequest: func(req *http.Request) error {
+			for name, values := range copied {
+				req.Header[name] = append([]string(nil), values...)
+			}
+			return nil
+		},
+	}
+}
+
+// LoggingMiddleware logs every request and parse error to logger, e.g.
+//
+//     GET https://archiveofourown.org/works/1?view_adult=true: 200 OK (312ms)
+//     parsing Rating (.required-tags .rating) failed: unable to match symbol rating node at https://archiveofourown.org/tags/HTML/works
+func LoggingMiddleware(logger *log.Logger) Middleware {
+	return Middleware{
+		AfterResponse: func(req *http.Request, res *http.Response, err error, elapsed time.Duration) {
+			elapsed = elapsed.Round(time.Millisecond)
+
+			if err != nil {
+				logger.Printf("%s %s: %v (%s)", req.Method, req.URL, err, elapsed)
+				return
+			}
+			logger.Printf("%s %s: %s (%s)", req.Method, req.URL, res.Status, elapsed)
+		},
+		OnParseError: func(rawURL string, diagnostic ParseDiagnostic) {
+			logger.Printf("%s at %s", diagnostic.Error(), rawURL)
+		},
+	}
+}
+
+// TimingMiddleware calls record with the time taken by each attempt of a
+// request, e.g. to export it as a metric. The response is nil if the attempt
+// failed.
+func TimingMiddleware(record func(req *http.Request, res *http.Response, elapsed time.Duration)) Middleware {
+	return Middleware{
+		AfterResponse: func(req *http.Request, res *http.Response, err error, elapsed time.Duration) {
+			record(req, res, elapsed)
+		},
+	}
+}
+
+// beforeRequest runs the BeforeRequest hooks of the client's middleware,
+// stopping at the first error
+func (client *AO3Client) beforeRequest(req *http.Request) error {
+	for _, middleware := range client.Middleware {
+		if middleware.BeforeRequest == nil {
+			continue
+		}
+		if err := middleware.BeforeRequest(req); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// afterResponse runs the AfterResponse hooks of the client's middleware
+func (client *AO3Client) afterResponse(req *http.Request, res *http.Response, err error, elapsed time.Duration) {
+	for _, middleware := range client.Middleware {
+		if middleware.AfterResponse != nil {
+			middleware.AfterResponse(req, res, err, elapsed)
+		}
+	}
+}
+
+// onParseError runs the OnParseError hooks of the client's middleware
+func (client *AO3Client) onParseError(rawURL string, diagnostic ParseDiagnostic) {
+	for _, middleware := range client.Middleware {
+		if middleware.OnParseError != nil {
+			middleware.OnParseError(rawURL, diagnostic)
+		}
+	}
+}
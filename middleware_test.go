@@ -0,0 +1,157 @@
+package ao3
+
+import (
+	"bytes"
+	"errors"
+	"log"
+	"net/http"
+	"strings"
+	"testing"
+	"time"
+	"github.com/stretchr/testify/assert"
+)
+
+// TestHeaderMiddleware ensures the built-in middleware sets headers on the
+// requests received by AO3
+func TestHeaderMiddleware(t *testing.T) {
+	var userAgent, language string
+	client, server := newTestClient(t, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		userAgent = r.Header.Get("User-Agent")
+		language = r.Header.Get("Accept-Language")
+		w.Write([]byte(testFandomCategoriesPage))
+	}))
+	defer server.Close()
+
+	header := http.Header{}
+	header.Set("Accept-Language", "en-GB")
+
+	client.Use(
+		UserAgentMiddleware(DefaultUserAgent+" contact@example.com"),
+		HeaderMiddleware(header),
+	)
+
+	// Changes after the middleware is created must not affect it
+	header.Set("Accept-Language", "fr")
+
+	if _, err := client.GetFandomCategories(); err != nil {
+		t.Fatal(err.Error())
+	}
+
+	assert.Equal(t, "ao3-go (+https://github.com/kz/ao3-go) contact@example.com", userAgent)
+	assert.Equal(t, "en-GB", language)
+}
+
+// TestBeforeRequestError ensures a request rejected by middleware is never sent
+func TestBeforeRequestError(t *testing.T) {
+	requests := 0
+	client, server := newTestClient(t, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests++
+	}))
+	defer server.Close()
+
+	rejected := errors.New("outside of permitted hours")
+	client.Use(Middleware{
+		BeforeRequest: func(req *http.Request) error {
+			return rejected
+		},
+	})
+
+	_, err := client.GetFandomCategories()
+	if assert.NotNil(t, err) {
+		assert.Equal(t, http.StatusBadRequest, err.Code())
+		assert.True(t, errors.Is(err, rejected))
+	}
+	assert.Equal(t, 0, requests)
+}
+
+// TestAfterResponseRunsForEachAttempt ensures retried requests pass through
+// middleware once per attempt, in the order the middleware was added
+func TestAfterResponseRunsForEachAttempt(t *testing.T) {
+	requests := 0
+	client, server := newTestClient(t, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests++
+		if requests == 1 {
+			w.WriteHeader(http.StatusServiceUnavailable)
+			return
+		}
+		w.Write([]byte(testFandomCategoriesPage))
+	}))
+	defer server.Close()
+
+	client.RetryPolicy = retryPolicyFunc(func(attempt int, res *http.Response, err error) (time.Duration, bool) {
+		return 0, attempt < 2
+	})
+
+	var calls []string
+	client.Use(
+		Middleware{
+			AfterResponse: func(req *http.Request, res *http.Response, err error, elapsed time.Duration) {
+				calls = append(calls, "first "+res.Status)
+			},
+		},
+		TimingMiddleware(func(req *http.Request, res *http.Response, elapsed time.Duration) {
+			assert.True(t, elapsed > 0)
+			calls = append(calls, "timing "+req.URL.Path)
+		}),
+	)
+
+	if _, err := client.GetFandomCategories(); err != nil {
+		t.Fatal(err.Error())
+	}
+
+	assert.Equal(t, []string{
+		"first 503 Service Unavailable",
+		"timing /media",
+		"first 200 OK",
+		"timing /media",
+	}, calls)
+}
+
+// TestLoggingMiddleware ensures requests and parse errors are logged
+func TestLoggingMiddleware(t *testing.T) {
+	client, server := newTestClient(t, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(testTagWorksPage))
+	}))
+	defer server.Close()
+
+	var output bytes.Buffer
+	client.Use(LoggingMiddleware(log.New(&output, "", 0)))
+
+	_, err := client.GetTagWorks("No%20Fandom", 0)
+	assert.NotNil(t, err)
+
+	lines := strings.Split(strings.TrimSpace(output.String()), "\n")
+	if assert.Len(t, lines, 2) {
+		assert.Regexp(t, `^GET http://127\.0\.0\.1:\d+/tags/No%20Fandom/works: 200 OK \(\d+m?s\)$`, lines[0])
+		assert.Regexp(t, `^parsing Kudos \(dd\.kudos > a\) failed: unable to convert kudos count to integer at http://127\.0\.0\.1:\d+/tags/No%20Fandom/works$`, lines[1])
+	}
+}
+
+// TestOnParseError ensures parse errors are reported once in strict mode and
+// once per diagnostic in lenient mode
+func TestOnParseError(t *testing.T) {
+	client, server := newTestClient(t, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(testTagWorksPage))
+	}))
+	defer server.Close()
+
+	var fields []string
+	client.Use(Middleware{
+		OnParseError: func(rawURL string, diagnostic ParseDiagnostic) {
+			assert.Equal(t, server.URL+"/tags/No%20Fandom/works", rawURL)
+			fields = append(fields, diagnostic.Field)
+		},
+	})
+
+	_, err := client.GetTagWorks("No%20Fandom", 0)
+	assert.NotNil(t, err)
+	assert.Equal(t, []string{"Kudos"}, fields)
+
+	fields = nil
+	client.Lenient = true
+
+	if _, err := client.GetTagWorks("No%20Fandom", 0); err != nil {
+		t.Fatal(err.Error())
+	}
+	assert.Equal(t, []string{"Kudos", "Rating"}, fields)
+}
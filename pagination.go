@@ -0,0 +1,68 @@
+package ao3
+
+import (
+	"net/http"
+	"github.com/PuerkitoBio/goquery"
+)
+
+// pagination is the position of a page among the pages of a listing
+type pagination struct {
+	isPaginated bool
+	currentPage int
+	lastPage    int
+}
+
+// parsePagination extracts the current and last page numbers from the
+// pagination bars of a listing, of which a paginated listing has bars.
+// Listings of works have a bar above and below the works, both of which are
+// identical, while other listings only have one below their entries. Listings
+// which fit on a single page have no bar and are not paginated.
+func parsePagination(state *parseState, doc *goquery.Document, bars int) (pagination, *AO3Error) {
+	var pages pagination
+	var err error
+
+	paginationMatches := doc.Find("ol.pagination")
+	pages.isPaginated = len(paginationMatches.Nodes) == bars
+	if !pages.isPaginated {
+		return pages, nil
+	}
+
+	paginationNode := paginationMatches.First()
+
+	// Get the current page number
+	currentMatches := paginationNode.Find("span.current")
+	if len(currentMatches.Nodes) != 1 {
+		ao3Err := NewError(http.StatusUnprocessableEntity, "unable to match current page")
+		if state.fail("CurrentPage", "ol.pagination span.current", paginationNode, ao3Err) {
+			return pages, ao3Err
+		}
+	} else if pages.currentPage, err = AtoiWithComma(currentMatches.First().Text()); err != nil {
+		ao3Err := NewError(http.StatusUnprocessableEntity, "unable to parse current page number")
+		if state.fail("CurrentPage", "ol.pagination span.current", currentMatches, ao3Err) {
+			return pages, ao3Err
+		}
+	}
+
+	// Get the last page number
+	// The last page is always the penultimate <li> tag in the <ol> list.
+	// Therefore, we assume there must be at least three <li> tags: the
+	// previous page link, first page and next page link.
+	paginationLinkNodes := paginationNode.Find("li")
+	if len(paginationLinkNodes.Nodes) < 3 {
+		ao3Err := NewError(http.StatusUnprocessableEntity, "unable to parse current page number")
+		if state.fail("LastPage", "ol.pagination li", paginationNode, ao3Err) {
+			return pages, ao3Err
+		}
+	} else {
+		lastWorkNode := paginationLinkNodes.Eq(len(paginationLinkNodes.Nodes) - 2)
+		pages.lastPage, err = AtoiWithComma(lastWorkNode.Text())
+		if err != nil {
+			ao3Err := NewError(http.StatusUnprocessableEntity, "unable to parse last page number")
+			if state.fail("LastPage", "ol.pagination li", lastWorkNode, ao3Err) {
+				return pages, ao3Err
+			}
+		}
+	}
+
+	return pages, nil
+}
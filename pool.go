@@ -0,0 +1,258 @@
+package ao3
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"sync"
+	"time"
+)
+
+// Credentials are the login (user name or email address) and password of an
+// AO3 account
+type Credentials struct {
+	Login    string
+	Password string
+}
+
+// CredentialsFunc returns the credentials of the AO3 account of a user of a
+// SessionPool. It is called whenever the account must log in, i.e. on first use
+// and after its session expired or was evicted.
+type CredentialsFunc func(ctx context.Context, userID string) (Credentials, error)
+
+// SessionPool manages the logged-in clients of many AO3 accounts, keyed by the
+// ID of the user each account belongs to. Accounts log in lazily with the
+// credentials returned by Credentials and have their own cookie jar, session
+// and rate limiter. A SessionPool is safe for concurrent use.
+type SessionPool struct {
+	// Credentials returns the credentials of an account when it must log in
+	Credentials CredentialsFunc
+
+	// IdleTimeout is how long an account is kept without being used before it
+	// is evicted from the pool. Zero keeps accounts until they are removed.
+	IdleTimeout time.Duration
+
+	// AccountRequestsPerSecond and AccountBurst limit the rate of requests of
+	// each account, as in NewRateLimiter, on top of the template's RateLimiter.
+	// A non-positive rate only applies the template's limit.
+	AccountRequestsPerSecond float64
+	AccountBurst             int
+
+	template *AO3Client
+
+	mu       sync.Mutex
+	accounts map[string]*poolAccount
+}
+
+// poolAccount is the client of an account in a SessionPool
+type poolAccount struct {
+	// loginMu ensures concurrent users of the account log in only once
+	loginMu sync.Mutex
+	client  *AO3Client
+
+	// lastUsed is guarded by the pool's mu
+	lastUsed time.Time
+}
+
+// NewSessionPool creates a pool whose clients are configured like template,
+// e.g. its base URL, HttpClient timeouts and transport, retry policy and cache.
+// The template's RateLimiter, if set, is shared by every account as a global
+// limit. Each client gets its own cookie jar, so the template's is not used.
+// The template's Cache is shared too, which is safe only because cache entries
+// are keyed by the logged-in user name, so accounts never see each other's
+// pages.
+func NewSessionPool(template *AO3Client, credentials CredentialsFunc) *SessionPool {
+	return &SessionPool{
+		Credentials: credentials,
+		template:    template,
+		accounts:    map[string]*poolAccount{},
+	}
+}
+
+// Client returns the logged-in client of the account of a user, logging in
+// first if the account has no session
+func (pool *SessionPool) Client(userID string) (*AO3Client, *AO3Error) {
+	return pool.ClientWithContext(context.Background(), userID)
+}
+
+// ClientWithContext is Client with a context which cancels the requests when
+// it is done
+func (pool *SessionPool) ClientWithContext(ctx context.Context, userID string) (*AO3Client, *AO3Error) {
+	account, ao3Err := pool.account(userID, time.Now())
+	if ao3Err != nil {
+		return nil, ao3Err
+	}
+
+	if ao3Err := pool.login(ctx, userID, account); ao3Err != nil {
+		return nil, ao3Err
+	}
+
+	return account.client, nil
+}
+
+// Do calls fn with the logged-in client of the account of a user. If fn fails
+// with an error matching ErrRestricted because the session expired, the account
+// logs in again and fn is called once more.
+func (pool *SessionPool) Do(userID string, fn func(client *AO3Client) *AO3Error) *AO3Error {
+	return pool.DoWithContext(context.Background(), userID, fn)
+}
+
+// DoWithContext is Do with a context which cancels the requests made to log in
+// and check the session when it is done
+func (pool *SessionPool) DoWithContext(ctx context.Context, userID string, fn func(client *AO3Client) *AO3Error) *AO3Error {
+	account, ao3Err := pool.account(userID, time.Now())
+	if ao3Err != nil {
+		return ao3Err
+	}
+	if ao3Err := pool.login(ctx, userID, account); ao3Err != nil {
+		return ao3Err
+	}
+
+	ao3Err = fn(account.client)
+	if ao3Err == nil || !errors.Is(ao3Err, ErrRestricted) {
+		return ao3Err
+	}
+
+	// The page may be restricted to other users, or the session may have
+	// expired, in which case IsLoggedIn forgets it
+	loggedIn, checkErr := account.client.IsLoggedInWithContext(ctx)
+	if checkErr != nil || loggedIn {
+		return ao3Err
+	}
+
+	if loginErr := pool.login(ctx, userID, account); loginErr != nil {
+		return loginErr
+	}
+
+	return fn(account.client)
+}
+
+// Remove drops the account of a user from the pool. Its session is not logged
+// out, which may be done with the client's Logout first.
+func (pool *SessionPool) Remove(userID string) {
+	pool.mu.Lock()
+	defer pool.mu.Unlock()
+
+	delete(pool.accounts, userID)
+}
+
+// EvictIdle drops the accounts which were not used for IdleTimeout and returns
+// how many were dropped. Idle accounts are also evicted whenever an account is
+// used, so calling EvictIdle is only needed to release memory sooner.
+func (pool *SessionPool) EvictIdle() int {
+	pool.mu.Lock()
+	defer pool.mu.Unlock()
+
+	return pool.evictIdle(time.Now())
+}
+
+// Len returns the number of accounts in the pool
+func (pool *SessionPool) Len() int {
+	pool.mu.Lock()
+	defer pool.mu.Unlock()
+
+	return len(pool.accounts)
+}
+
+// account returns the account of a user, adding it to the pool if needed, and
+// marks it as used
+func (pool *SessionPool) account(userID string, now time.Time) (*poolAccount, *AO3Error) {
+	pool.mu.Lock()
+	defer pool.mu.Unlock()
+
+	pool.evictIdle(now)
+
+	account, ok := pool.accounts[userID]
+	if !ok {
+		client, ao3Err := pool.newClient()
+		if ao3Err != nil {
+			return nil, ao3Err
+		}
+		account = &poolAccount{client: client}
+		pool.accounts[userID] = account
+	}
+	account.lastUsed = now
+
+	return account, nil
+}
+
+// evictIdle drops the accounts last used more than IdleTimeout before now. The
+// pool's mu must be held.
+func (pool *SessionPool) evictIdle(now time.Time) int {
+	if pool.IdleTimeout <= 0 {
+		return 0
+	}
+
+	evicted := 0
+	for userID, account := range pool.accounts {
+		if now.Sub(account.lastUsed) > pool.IdleTimeout {
+			delete(pool.accounts, userID)
+			evicted++
+		}
+	}
+
+	return evicted
+}
+
+// login logs the account in with the user's credentials unless it already has
+// a session
+func (pool *SessionPool) login(ctx context.Context, userID string, account *poolAccount) *AO3Error {
+	account.loginMu.Lock()
+	defer account.loginMu.Unlock()
+
+	if account.client.Username() != "" {
+		return nil
+	}
+
+	if pool.Credentials == nil {
+		return NewError(http.StatusInternalServerError, "session pool has no credentials callback")
+	}
+
+	credentials, err := pool.Credentials(ctx, userID)
+	if err != nil {
+		return WrapError(http.StatusInternalServerError, err, "unable to get credentials of account")
+	}
+
+	return account.client.AuthenticateWithContext(ctx, credentials.Login, credentials.Password)
+}
+
+// newClient creates a client configured like the pool's template with its own
+// cookie jar, session and rate limiter. The cache is shared, relying on
+// cacheKey to include the user name.
+func (pool *SessionPool) newClient() (*AO3Client, *AO3Error) {
+	template := pool.template.HttpClient
+	if template != nil {
+		withoutJar := *template
+		withoutJar.Jar = nil
+		template = &withoutJar
+	}
+
+	httpClient, err := newHTTPClient(template)
+	if err != nil {
+		return nil, WrapError(http.StatusInternalServerError, err, "unable to create cookie jar")
+	}
+
+	var limiter *RateLimiter
+	switch {
+	case pool.AccountRequestsPerSecond > 0 && pool.template.RateLimiter != nil:
+		limiter = pool.template.RateLimiter.Child(pool.AccountRequestsPerSecond, pool.AccountBurst)
+	case pool.AccountRequestsPerSecond > 0:
+		limiter = NewRateLimiter(pool.AccountRequestsPerSecond, pool.AccountBurst)
+	default:
+		limiter = pool.template.RateLimiter
+	}
+
+	return &AO3Client{
+		HttpClient:        httpClient,
+		HtmlSanitizer:     pool.template.HtmlSanitizer,
+		RateLimiter:       limiter,
+		MaxThrottleWait:   pool.template.MaxThrottleWait,
+		RetryPolicy:       pool.template.RetryPolicy,
+		Cache:             pool.template.Cache,
+		CacheTTLs:         pool.template.CacheTTLs,
+		Lenient:           pool.template.Lenient,
+		Middleware:        append([]Middleware(nil), pool.template.Middleware...),
+		HideMutedCreators: pool.template.HideMutedCreators,
+		baseURL:           pool.template.baseURL,
+	}, nil
+}
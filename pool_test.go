@@ -0,0 +1,265 @@
+package ao3
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+	"github.com/stretchr/testify/assert"
+)
+
+// testPoolServer is a fake AO3 on which "alice" and "bob" may log in. Work 1
+// is restricted to logged-in users and work 2 to its creator. /media lists a
+// category named after the user reading it.
+type testPoolServer struct {
+	mu       sync.Mutex
+	sessions map[string]bool
+	logins   map[string]int
+	media    int
+}
+
+// expire ends the session of a user on the server
+func (server *testPoolServer) expire(username string) {
+	server.mu.Lock()
+	defer server.mu.Unlock()
+
+	delete(server.sessions, username)
+}
+
+// loginCount returns how many times a user logged in
+func (server *testPoolServer) loginCount(username string) int {
+	server.mu.Lock()
+	defer server.mu.Unlock()
+
+	return server.logins[username]
+}
+
+func (server *testPoolServer) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	server.mu.Lock()
+	defer server.mu.Unlock()
+
+	username := ""
+	if session, _ := r.Cookie("_otwarchive_session"); session != nil && server.sessions[session.Value] {
+		username = session.Value
+	}
+	loggedInPage := `<html><head><meta name="csrf-token" content="session-token" /></head><body class="logged-in"><div id="greeting"><a href="/users/` + username + `">Hi, ` + username + `!</a></div></body></html>`
+
+	switch {
+	case r.URL.Path == "/users/login" && r.Method == http.MethodGet:
+		if username == "" {
+			http.SetCookie(w, &http.Cookie{Name: "_otwarchive_session", Value: "anonymous", Path: "/"})
+		}
+		w.Write([]byte(testLoginFormPage))
+
+	case r.URL.Path == "/users/login" && r.Method == http.MethodPost:
+		passwords := map[string]string{"alice": "alice-password", "bob": "bob-password"}
+		login := r.PostFormValue("user[login]")
+		if r.PostFormValue("authenticity_token") != "form-token" || passwords[login] == "" || passwords[login] != r.PostFormValue("user[password]") {
+			w.Write([]byte(`<body class="logged-out"><div class="flash error">The password or user name you entered doesn't match our records.</div></body>`))
+			return
+		}
+		server.sessions[login] = true
+		server.logins[login]++
+		http.SetCookie(w, &http.Cookie{Name: "_otwarchive_session", Value: login, Path: "/"})
+		http.Redirect(w, r, "/users/"+login, http.StatusFound)
+
+	case r.URL.Path == "/" || strings.HasPrefix(r.URL.Path, "/users/"):
+		if username == "" {
+			w.Write([]byte(testLoginFormPage))
+			return
+		}
+		w.Write([]byte(loggedInPage))
+
+	case r.URL.Path == "/media":
+		server.media++
+		w.Write([]byte(`<div class="medium listbox group"><h3 class="heading"><a href="/media/` + username + `/fandoms">` + username + `</a></h3></div>`))
+
+	case r.URL.Path == "/works/1" && username != "":
+		w.Write([]byte(testRestrictedWorkPage))
+
+	case r.URL.Path == "/works/1" || r.URL.Path == "/works/2":
+		http.Redirect(w, r, "/users/login?restricted=true", http.StatusFound)
+
+	default:
+		http.NotFound(w, r)
+	}
+}
+
+// newTestPool returns a pool of clients pointed at a testPoolServer
+func newTestPool(t *testing.T) (*SessionPool, *testPoolServer, func()) {
+	poolServer := &testPoolServer{sessions: map[string]bool{}, logins: map[string]int{}}
+	template, server := newTestClient(t, poolServer)
+
+	pool := NewSessionPool(template, func(ctx context.Context, userID string) (Credentials, error) {
+		switch userID {
+		case "user-1":
+			return Credentials{Login: "alice", Password: "alice-password"}, nil
+		case "user-2":
+			return Credentials{Login: "bob", Password: "bob-password"}, nil
+		case "user-3":
+			return Credentials{Login: "bob", Password: "wrong"}, nil
+		}
+		return Credentials{}, errors.New("unknown user")
+	})
+
+	return pool, poolServer, server.Close
+}
+
+// TestSessionPoolClient ensures each account logs in once, even when used
+// concurrently, and keeps its own session
+func TestSessionPoolClient(t *testing.T) {
+	pool, poolServer, closeServer := newTestPool(t)
+	defer closeServer()
+
+	var wg sync.WaitGroup
+	for i := 0; i < 8; i++ {
+		wg.Add(1)
+		go func(userID string) {
+			defer wg.Done()
+			if _, err := pool.Client(userID); err != nil {
+				t.Error(err.Error())
+			}
+		}([]string{"user-1", "user-2"}[i%2])
+	}
+	wg.Wait()
+
+	assert.Equal(t, 1, poolServer.loginCount("alice"))
+	assert.Equal(t, 1, poolServer.loginCount("bob"))
+	assert.Equal(t, 2, pool.Len())
+
+	alice, err := pool.Client("user-1")
+	if err != nil {
+		t.Fatal(err.Error())
+	}
+	bob, err := pool.Client("user-2")
+	if err != nil {
+		t.Fatal(err.Error())
+	}
+	assert.Equal(t, "alice", alice.Username())
+	assert.Equal(t, "bob", bob.Username())
+	assert.NotSame(t, alice.HttpClient.Jar, bob.HttpClient.Jar)
+
+	_, err = pool.Client("user-3")
+	if assert.NotNil(t, err) {
+		assert.True(t, errors.Is(err, ErrBadCredentials))
+	}
+
+	_, err = pool.Client("user-4")
+	if assert.NotNil(t, err) {
+		assert.Contains(t, err.Error(), "unknown user")
+	}
+}
+
+// TestSessionPoolDoReauthenticates ensures expired sessions log in again while
+// pages restricted to other users are reported
+func TestSessionPoolDoReauthenticates(t *testing.T) {
+	pool, poolServer, closeServer := newTestPool(t)
+	defer closeServer()
+
+	getWork := func(id string) func(client *AO3Client) *AO3Error {
+		return func(client *AO3Client) *AO3Error {
+			_, err := client.GetWork(id)
+			return err
+		}
+	}
+
+	if err := pool.Do("user-1", getWork("1")); err != nil {
+		t.Fatal(err.Error())
+	}
+
+	poolServer.expire("alice")
+	if err := pool.Do("user-1", getWork("1")); err != nil {
+		t.Fatal(err.Error())
+	}
+	assert.Equal(t, 2, poolServer.loginCount("alice"))
+
+	err := pool.Do("user-1", getWork("2"))
+	if assert.NotNil(t, err) {
+		assert.True(t, errors.Is(err, ErrRestricted))
+	}
+	assert.Equal(t, 2, poolServer.loginCount("alice"))
+}
+
+// TestSessionPoolEvictsIdleAccounts ensures accounts unused for IdleTimeout are
+// dropped and log in again when next used
+func TestSessionPoolEvictsIdleAccounts(t *testing.T) {
+	pool, poolServer, closeServer := newTestPool(t)
+	defer closeServer()
+
+	pool.IdleTimeout = time.Minute
+
+	if _, err := pool.Client("user-1"); err != nil {
+		t.Fatal(err.Error())
+	}
+	if _, err := pool.Client("user-2"); err != nil {
+		t.Fatal(err.Error())
+	}
+	assert.Equal(t, 0, pool.EvictIdle())
+
+	// Using bob's account an hour later evicts alice's
+	pool.account("user-2", time.Now().Add(time.Hour))
+	assert.Equal(t, 1, pool.Len())
+
+	pool.Remove("user-2")
+	assert.Equal(t, 0, pool.Len())
+
+	if _, err := pool.Client("user-1"); err != nil {
+		t.Fatal(err.Error())
+	}
+	assert.Equal(t, 2, poolServer.loginCount("alice"))
+}
+
+// TestSessionPoolRateLimits ensures each account gets its own limiter whose
+// parent is the template's
+func TestSessionPoolRateLimits(t *testing.T) {
+	pool, _, closeServer := newTestPool(t)
+	defer closeServer()
+
+	pool.template.RateLimiter = NewRateLimiter(100, 10)
+	pool.AccountRequestsPerSecond = 1
+	pool.AccountBurst = 5
+
+	alice, err := pool.Client("user-1")
+	if err != nil {
+		t.Fatal(err.Error())
+	}
+	bob, err := pool.Client("user-2")
+	if err != nil {
+		t.Fatal(err.Error())
+	}
+
+	assert.NotSame(t, alice.RateLimiter, bob.RateLimiter)
+	assert.Same(t, pool.template.RateLimiter, alice.RateLimiter.parent)
+	assert.Same(t, pool.template.RateLimiter, bob.RateLimiter.parent)
+}
+
+// TestSessionPoolSharedCache ensures accounts sharing the template's cache
+// each get their own version of a page
+func TestSessionPoolSharedCache(t *testing.T) {
+	pool, poolServer, closeServer := newTestPool(t)
+	defer closeServer()
+
+	pool.template.Cache = NewMemoryCache(10)
+
+	for _, userID := range []string{"user-1", "user-2", "user-1", "user-2"} {
+		client, err := pool.Client(userID)
+		if err != nil {
+			t.Fatal(err.Error())
+		}
+
+		categories, err := client.GetFandomCategories()
+		if err != nil {
+			t.Fatal(err.Error())
+		}
+		if assert.Len(t, categories, 1) {
+			assert.Equal(t, client.Username(), categories[0].Name)
+		}
+	}
+
+	poolServer.mu.Lock()
+	defer poolServer.mu.Unlock()
+	assert.Equal(t, 2, poolServer.media)
+}
@@ -0,0 +1,378 @@
+package ao3
+
+import (
+	"context"
+	"net/http"
+	"net/url"
+	"regexp"
+	"strings"
+	"github.com/PuerkitoBio/goquery"
+)
+
+var postedWorkLinkRegex = regexp.MustCompile(`^/works/(\d+)`)
+var postedChapterLinkRegex = regexp.MustCompile(`^/works/\d+/chapters/(\d+)`)
+
+// workFieldRules attributes AO3's validation messages about works to the
+// fields of WorkOptions. The first matching rule wins, so messages about
+// several fields come first.
+var workFieldRules = []fieldRule{
+	{regexp.MustCompile(`(?i)^fandom, relationship, character, and additional tags`), []string{"Fandoms", "Relationships", "Characters", "AdditionalTags"}},
+	{regexp.MustCompile(`(?i)^chapter title`), []string{"ChapterTitle"}},
+	{regexp.MustCompile(`(?i)^title\b|enter a title`), []string{"Title"}},
+	{regexp.MustCompile(`(?i)^fandom|fandom is missing`), []string{"Fandoms"}},
+	{regexp.MustCompile(`(?i)^rating|rating is missing`), []string{"Rating"}},
+	{regexp.MustCompile(`(?i)^(?:archive )?warning|warning is missing`), []string{"Warnings"}},
+	{regexp.MustCompile(`(?i)^categor`), []string{"Categories"}},
+	{regexp.MustCompile(`(?i)^relationship`), []string{"Relationships"}},
+	{regexp.MustCompile(`(?i)^character`), []string{"Characters"}},
+	{regexp.MustCompile(`(?i)^(?:additional|freeform)`), []string{"AdditionalTags"}},
+	{regexp.MustCompile(`(?i)^summary`), []string{"Summary"}},
+	{regexp.MustCompile(`(?i)^end ?notes`), []string{"EndNotes"}},
+	{regexp.MustCompile(`(?i)^notes`), []string{"Notes"}},
+	{regexp.MustCompile(`(?i)^language`), []string{"Language"}},
+	{regexp.MustCompile(`(?i)^series`), []string{"SeriesID", "NewSeries"}},
+	{regexp.MustCompile(`(?i)^content|enter your story`), []string{"Content"}},
+}
+
+// chapterFieldRules attributes AO3's validation messages about chapters to the
+// fields of ChapterOptions
+var chapterFieldRules = []fieldRule{
+	{regexp.MustCompile(`(?i)^(?:chapter )?title`), []string{"Title"}},
+	{regexp.MustCompile(`(?i)^summary`), []string{"Summary"}},
+	{regexp.MustCompile(`(?i)^end ?notes`), []string{"EndNotes"}},
+	{regexp.MustCompile(`(?i)^notes`), []string{"Notes"}},
+	{regexp.MustCompile(`(?i)^content|enter your story`), []string{"Content"}},
+}
+
+// WorkOptions describes a work to post, or the new state of one to edit. Tags
+// are given by name, e.g. Rating "General Audiences", Warnings "No Archive
+// Warnings Apply" and Categories "Gen".
+type WorkOptions struct {
+	Title string
+
+	Fandoms        []string
+	Rating         string
+	Warnings       []string
+	Categories     []string
+	Relationships  []string
+	Characters     []string
+	AdditionalTags []string
+
+	// Summary, Notes and EndNotes are in HTML, sanitized with the client's
+	// HtmlSanitizer before they are submitted
+	Summary  string
+	Notes    string
+	EndNotes string
+
+	// Language is the name of the work's language as listed by AO3, e.g.
+	// "English"
+	Language string
+
+	// SeriesID adds the work to one of the user's series, or NewSeries to a new
+	// series with that title
+	SeriesID  string
+	NewSeries string
+
+	// ChapterTitle and Content are the title and HTML text of the work's first
+	// chapter. Content is sanitized with the client's HtmlSanitizer. Editing a
+	// work leaves its first chapter unchanged if they are empty.
+	ChapterTitle string
+	Content      string
+
+	// IsRestricted only shows the work to logged-in users
+	IsRestricted bool
+}
+
+// ChapterOptions describes a chapter to post. The summary, notes and content
+// are in HTML, sanitized with the client's HtmlSanitizer before they are
+// submitted.
+type ChapterOptions struct {
+	Title    string
+	Summary  string
+	Notes    string
+	EndNotes string
+	Content  string
+}
+
+// PostWork posts a new work as the logged-in user's default pseud and returns
+// its ID. Works AO3 rejects return an error matching ErrValidation which wraps
+// a ValidationError whose Fields are keyed by the names of WorkOptions' fields.
+//
+// Endpoint: https://archiveofourown.org/works
+func (client *AO3Client) PostWork(options WorkOptions) (string, *AO3Error) {
+	return client.PostWorkWithContext(context.Background(), options)
+}
+
+// PostWorkWithContext is PostWork with a context which cancels the requests
+// when it is done
+func (client *AO3Client) PostWorkWithContext(ctx context.Context, options WorkOptions) (string, *AO3Error) {
+	return client.createWork(ctx, options, "post_button", "Post", "posting work")
+}
+
+// SaveWorkDraft saves a new work as a draft, which is only visible to the
+// logged-in user until it is posted, and returns its ID, like PostWork
+//
+// Endpoint: https://archiveofourown.org/works
+func (client *AO3Client) SaveWorkDraft(options WorkOptions) (string, *AO3Error) {
+	return client.SaveWorkDraftWithContext(context.Background(), options)
+}
+
+// SaveWorkDraftWithContext is SaveWorkDraft with a context which cancels the
+// requests when it is done
+func (client *AO3Client) SaveWorkDraftWithContext(ctx context.Context, options WorkOptions) (string, *AO3Error) {
+	return client.createWork(ctx, options, "preview_button", "Preview", "saving work draft")
+}
+
+// EditWork replaces the metadata of one of the logged-in user's works with
+// options, like PostWork
+//
+// Endpoint: https://archiveofourown.org/works/[work]
+func (client *AO3Client) EditWork(id string, options WorkOptions) *AO3Error {
+	return client.EditWorkWithContext(context.Background(), id, options)
+}
+
+// EditWorkWithContext is EditWork with a context which cancels the requests
+// when it is done
+func (client *AO3Client) EditWorkWithContext(ctx context.Context, id string, options WorkOptions) *AO3Error {
+	if ao3Err := client.requireLogin("editing work"); ao3Err != nil {
+		return ao3Err
+	}
+
+	doc, ao3Err := client.fetchWorkForm(ctx, workEditEndpoint(id), "work[title]", "fetching work form")
+	if ao3Err != nil {
+		return ao3Err
+	}
+
+	form, ao3Err := options.form(doc, client.HtmlSanitizer)
+	if ao3Err != nil {
+		return ao3Err
+	}
+	if options.ChapterTitle == "" {
+		form.Del("work[chapter_attributes][title]")
+	}
+	if options.Content == "" {
+		form.Del("work[chapter_attributes][content]")
+	}
+	form.Set("_method", "patch")
+	form.Set("update_button", "Update")
+
+	res, ao3Err := client.submit(ctx, "/works/"+id, form, "editing work")
+	if ao3Err != nil {
+		return ao3Err
+	}
+	client.invalidate(workEndpoint(id))
+
+	_, ao3Err = checkSubmission(res.body, "editing work")
+	return attributeFields(ao3Err, workFieldRules)
+}
+
+// PostChapter posts a new chapter of one of the logged-in user's works and
+// returns its ID. Chapters AO3 rejects return an error matching ErrValidation
+// which wraps a ValidationError whose Fields are keyed by the names of
+// ChapterOptions' fields.
+//
+// Endpoint: https://archiveofourown.org/works/[work]/chapters
+func (client *AO3Client) PostChapter(workID string, options ChapterOptions) (string, *AO3Error) {
+	return client.PostChapterWithContext(context.Background(), workID, options)
+}
+
+// PostChapterWithContext is PostChapter with a context which cancels the
+// requests when it is done
+func (client *AO3Client) PostChapterWithContext(ctx context.Context, workID string, options ChapterOptions) (string, *AO3Error) {
+	return client.createChapter(ctx, workID, options, "post_without_preview_button", "Post", "posting chapter")
+}
+
+// SaveChapterDraft saves a new chapter of one of the logged-in user's works as
+// a draft and returns its ID, like PostChapter
+//
+// Endpoint: https://archiveofourown.org/works/[work]/chapters
+func (client *AO3Client) SaveChapterDraft(workID string, options ChapterOptions) (string, *AO3Error) {
+	return client.SaveChapterDraftWithContext(context.Background(), workID, options)
+}
+
+// SaveChapterDraftWithContext is SaveChapterDraft with a context which cancels
+// the requests when it is done
+func (client *AO3Client) SaveChapterDraftWithContext(ctx context.Context, workID string, options ChapterOptions) (string, *AO3Error) {
+	return client.createChapter(ctx, workID, options, "preview_button", "Preview", "saving chapter draft")
+}
+
+// createWork fetches the new work form and submits it with the given button
+func (client *AO3Client) createWork(ctx context.Context, options WorkOptions, button string, label string, action string) (string, *AO3Error) {
+	if ao3Err := client.requireLogin(action); ao3Err != nil {
+		return "", ao3Err
+	}
+
+	doc, ao3Err := client.fetchWorkForm(ctx, "/works/new", "work[title]", "fetching work form")
+	if ao3Err != nil {
+		return "", ao3Err
+	}
+
+	form, ao3Err := options.form(doc, client.HtmlSanitizer)
+	if ao3Err != nil {
+		return "", ao3Err
+	}
+	form.Set(button, label)
+
+	res, ao3Err := client.submit(ctx, "/works", form, action)
+	if ao3Err != nil {
+		return "", ao3Err
+	}
+
+	if _, ao3Err := checkSubmission(res.body, action); ao3Err != nil {
+		return "", attributeFields(ao3Err, workFieldRules)
+	}
+
+	return client.postedID(res, postedWorkLinkRegex, "work", action)
+}
+
+// createChapter fetches the new chapter form of a work and submits it with
+// the given button
+func (client *AO3Client) createChapter(ctx context.Context, workID string, options ChapterOptions, button string, label string, action string) (string, *AO3Error) {
+	if ao3Err := client.requireLogin(action); ao3Err != nil {
+		return "", ao3Err
+	}
+
+	endpoint := "/works/" + workID + "/chapters"
+	doc, ao3Err := client.fetchWorkForm(ctx, endpoint+"/new", "chapter[content]", "fetching chapter form")
+	if ao3Err != nil {
+		return "", ao3Err
+	}
+
+	pseudID := formPseudID(doc, "chapter[author_attributes][ids][]")
+	if pseudID == "" {
+		return "", NewError(http.StatusUnprocessableEntity, "unable to find pseud in chapter form")
+	}
+
+	form := url.Values{}
+	form.Set("chapter[title]", options.Title)
+	form.Set("chapter[summary]", client.HtmlSanitizer.Sanitize(options.Summary))
+	form.Set("chapter[notes]", client.HtmlSanitizer.Sanitize(options.Notes))
+	form.Set("chapter[endnotes]", client.HtmlSanitizer.Sanitize(options.EndNotes))
+	form.Set("chapter[content]", client.HtmlSanitizer.Sanitize(options.Content))
+	form.Set("chapter[author_attributes][ids][]", pseudID)
+	setNotesOptions(form, options.Notes, options.EndNotes)
+	form.Set(button, label)
+
+	res, ao3Err := client.submit(ctx, endpoint, form, action)
+	if ao3Err != nil {
+		return "", ao3Err
+	}
+	client.invalidate(workEndpoint(workID))
+
+	if _, ao3Err := checkSubmission(res.body, action); ao3Err != nil {
+		return "", attributeFields(ao3Err, chapterFieldRules)
+	}
+
+	return client.postedID(res, postedChapterLinkRegex, "chapter", action)
+}
+
+// fetchWorkForm fetches a work or chapter form, returning an error if the page
+// has no field named field, e.g. because the work belongs to another user
+func (client *AO3Client) fetchWorkForm(ctx context.Context, endpoint string, field string, action string) (*goquery.Document, *AO3Error) {
+	doc, ao3Err := client.fetchForm(ctx, endpoint, action)
+	if ao3Err != nil {
+		return nil, ao3Err
+	}
+
+	if len(doc.Find(`[name="`+field+`"]`).Nodes) == 0 {
+		if ao3Err := validationError(doc, action); ao3Err != nil {
+			return nil, ao3Err
+		}
+		return nil, NewError(http.StatusUnprocessableEntity, "unable to find "+field+" in form")
+	}
+
+	return doc, nil
+}
+
+// postedID extracts the ID of the work or chapter AO3 redirected to after a
+// form was submitted
+func (client *AO3Client) postedID(res *response, linkRegex *regexp.Regexp, name string, action string) (string, *AO3Error) {
+	if res.url != nil {
+		if matches := linkRegex.FindStringSubmatch(client.relativeLink(res.url.String())); len(matches) == 2 {
+			return matches[1], nil
+		}
+	}
+
+	return "", NewError(http.StatusUnprocessableEntity, "unable to find "+name+" after "+action)
+}
+
+// form encodes the options as the fields of AO3's work form, using the pseud
+// and languages listed by the form in doc
+func (options WorkOptions) form(doc *goquery.Document, sanitizer *Sanitizer) (url.Values, *AO3Error) {
+	form := url.Values{}
+
+	pseudID := formPseudID(doc, "work[author_attributes][ids][]")
+	if pseudID == "" {
+		return nil, NewError(http.StatusUnprocessableEntity, "unable to find pseud in work form")
+	}
+	form.Set("work[author_attributes][ids][]", pseudID)
+
+	languageID, ok := formOptionValue(doc, "work[language_id]", options.Language)
+	if !ok {
+		validation := &ValidationError{
+			Messages: []string{"Language " + options.Language + " is not listed by the archive"},
+			Fields:   map[string][]string{},
+		}
+		validation.Fields["Language"] = validation.Messages
+		return nil, WrapError(http.StatusBadRequest, validation, "work form was rejected").withKind(KindValidation)
+	}
+	form.Set("work[language_id]", languageID)
+
+	form.Set("work[title]", options.Title)
+	form.Set("work[fandom_string]", strings.Join(options.Fandoms, ","))
+	form.Set("work[rating_string]", options.Rating)
+	// Rails submits an empty value alongside checked boxes so that unchecking
+	// all of them clears the field
+	form["work[archive_warning_strings][]"] = append([]string{""}, options.Warnings...)
+	form["work[category_strings][]"] = append([]string{""}, options.Categories...)
+	form.Set("work[relationship_string]", strings.Join(options.Relationships, ","))
+	form.Set("work[character_string]", strings.Join(options.Characters, ","))
+	form.Set("work[freeform_string]", strings.Join(options.AdditionalTags, ","))
+
+	form.Set("work[summary]", sanitizer.Sanitize(options.Summary))
+	form.Set("work[notes]", sanitizer.Sanitize(options.Notes))
+	form.Set("work[endnotes]", sanitizer.Sanitize(options.EndNotes))
+	setNotesOptions(form, options.Notes, options.EndNotes)
+
+	if options.SeriesID != "" {
+		form.Set("work[series_attributes][id]", options.SeriesID)
+	}
+	if options.NewSeries != "" {
+		form.Set("work[series_attributes][title]", options.NewSeries)
+	}
+
+	form.Set("work[chapter_attributes][title]", options.ChapterTitle)
+	form.Set("work[chapter_attributes][content]", sanitizer.Sanitize(options.Content))
+	form.Set("work[restricted]", formBool(options.IsRestricted))
+
+	return form, nil
+}
+
+// setNotesOptions ticks the boxes AO3 shows notes with when they are given
+func setNotesOptions(form url.Values, notes string, endNotes string) {
+	form.Set("front-notes-options-show", formBool(notes != ""))
+	form.Set("end-notes-options-show", formBool(endNotes != ""))
+}
+
+// formOptionValue returns the value of the option of a select field whose text
+// is label, ignoring case. An empty label keeps the field's selected option, if
+// any.
+func formOptionValue(doc *goquery.Document, name string, label string) (string, bool) {
+	field := doc.Find(`select[name="` + name + `"]`).First()
+
+	if label == "" {
+		value, _ := field.Find("option[selected]").First().Attr("value")
+		return value, true
+	}
+
+	value, ok := "", false
+	field.Find("option").EachWithBreak(func(_ int, node *goquery.Selection) bool {
+		if strings.EqualFold(strings.TrimSpace(node.Text()), label) {
+			value, ok = node.Attr("value")
+			return false
+		}
+		return true
+	})
+
+	return value, ok
+}
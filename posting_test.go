@@ -0,0 +1,277 @@
+package ao3
+
+import (
+	"errors"
+	"net/http"
+	"net/url"
+	"strconv"
+	"testing"
+	"github.com/stretchr/testify/assert"
+)
+
+const testWorkFormFields = `<select name="work[author_attributes][ids][]" multiple="multiple"><option value="9" selected="selected">reader</option><option value="10">alt</option></select>
+<select name="work[language_id]"><option value=""></option><option value="1">English</option><option value="2">Français</option></select>
+<input type="text" name="work[title]" />`
+
+const testEditWorkFormFields = `<select name="work[author_attributes][ids][]" multiple="multiple"><option value="9" selected="selected">reader</option></select>
+<select name="work[language_id]"><option value="1">English</option><option value="2" selected="selected">Français</option></select>
+<input type="text" name="work[title]" value="Old Title" />`
+
+const testChapterFormFields = `<select name="chapter[author_attributes][ids][]" multiple="multiple"><option value="9" selected="selected">reader</option></select>
+<textarea name="chapter[content]"></textarea>`
+
+// newPostingServer returns a client pointed at a fake AO3 on which "reader"
+// owns work 10, titled "Old Title" until edited, but not work 12. The forms it
+// receives are recorded.
+func newPostingServer(t *testing.T) (*AO3Client, map[string]url.Values, func()) {
+	title := "Old Title"
+	chapters := 1
+
+	client, forms, closeServer := newTestArchive(t, false, func(w http.ResponseWriter, r *http.Request) {
+		switch {
+		case r.URL.Path == "/works/new":
+			writeTestPage(w, `<form action="/works" method="post">`+testWorkFormFields+`</form>`)
+
+		case r.URL.Path == "/works/10/edit":
+			writeTestPage(w, `<form action="/works/10" method="post">`+testEditWorkFormFields+`</form>`)
+
+		case r.URL.Path == "/works/12/edit":
+			writeTestPage(w, `<div class="flash error">Sorry, you don't have permission to access the page you were trying to reach.</div>`)
+
+		case r.URL.Path == "/works/10/chapters/new":
+			writeTestPage(w, `<form action="/works/10/chapters" method="post">`+testChapterFormFields+`</form>`)
+
+		case r.URL.Path == "/works" && r.Method == http.MethodPost:
+			if r.PostFormValue("work[title]") == "" {
+				writeTestPage(w, `<div id="error" class="error"><h4>Sorry! We couldn't save this work because:</h4><ul>
+<li>Title can't be blank</li>
+<li>Please add all required tags. Fandom is missing.</li>
+<li>Fandom, relationship, character, and additional tags must not add up to more than 75. Your work has 80 of these tags, so you must remove 5 of them.</li>
+<li>Something unexpected went wrong.</li>
+</ul></div>`+`<form action="/works" method="post">`+testWorkFormFields+`</form>`)
+				return
+			}
+			if r.PostFormValue("preview_button") != "" {
+				http.Redirect(w, r, "/works/11/preview", http.StatusFound)
+				return
+			}
+			http.Redirect(w, r, "/works/10", http.StatusFound)
+
+		case r.URL.Path == "/works/10" && r.Method == http.MethodPost:
+			title = r.PostFormValue("work[title]")
+			http.Redirect(w, r, "/works/10", http.StatusFound)
+
+		case r.URL.Path == "/works/10" && r.URL.Query().Get("view_adult") == "true":
+			writeTestPage(w, `<dl class="work meta group"><dd class="language">English</dd><dd class="chapters">`+strconv.Itoa(chapters)+`/?</dd></dl>
+<ul class="work navigation actions"><li class="download"><ul class="expandable secondary"><li><a href="/downloads/10/Work.html?updated_at=1">HTML</a></li></ul></li></ul>
+<div id="workskin"><div class="preface group"><h2 class="title heading">`+title+`</h2><h3 class="byline heading"><a rel="author" href="/users/reader/pseuds/reader">reader</a></h3></div></div>`)
+
+		case r.URL.Path == "/works/10/chapters" && r.Method == http.MethodPost:
+			if r.PostFormValue("chapter[content]") == "" {
+				writeTestPage(w, `<div id="error" class="error"><ul><li>Content can't be blank</li></ul></div>`)
+				return
+			}
+			if r.PostFormValue("preview_button") != "" {
+				http.Redirect(w, r, "/works/10/chapters/21/preview", http.StatusFound)
+				return
+			}
+			chapters++
+			http.Redirect(w, r, "/works/10/chapters/20", http.StatusFound)
+
+		case r.URL.Path == "/works/10" || r.URL.Path == "/works/11/preview" || r.URL.Path == "/works/10/chapters/20" || r.URL.Path == "/works/10/chapters/21/preview":
+			writeTestPage(w, `<div class="flash notice">Work was successfully posted.</div>`)
+
+		default:
+			http.NotFound(w, r)
+		}
+	})
+
+	return client, forms, closeServer
+}
+
+// TestPostWork ensures works are posted and saved as drafts with the fields of
+// AO3's work form
+func TestPostWork(t *testing.T) {
+	client, forms, closeServer := newPostingServer(t)
+	defer closeServer()
+
+	options := WorkOptions{
+		Title:          "A Work",
+		Fandoms:        []string{"Original Work", "No Fandom"},
+		Rating:         "General Audiences",
+		Warnings:       []string{"No Archive Warnings Apply"},
+		Categories:     []string{"Gen", "Other"},
+		Relationships:  []string{"A/B"},
+		Characters:     []string{"A", "B"},
+		AdditionalTags: []string{"Fluff"},
+		Summary:        `<p onclick="x()">A summary</p>`,
+		Notes:          "Some notes",
+		Language:       "français",
+		NewSeries:      "A Series",
+		ChapterTitle:   "Beginnings",
+		Content:        "<p>Once upon a time</p><script>x()</script>",
+		IsRestricted:   true,
+	}
+
+	_, err := client.PostWork(options)
+	if assert.NotNil(t, err) {
+		assert.True(t, errors.Is(err, ErrRestricted))
+	}
+
+	importTestSession(t, client, "reader")
+
+	id, err := client.PostWork(options)
+	if err != nil {
+		t.Fatal(err.Error())
+	}
+	assert.Equal(t, "10", id)
+
+	form := forms[" /works"]
+	assert.Equal(t, "9", form.Get("work[author_attributes][ids][]"))
+	assert.Equal(t, "2", form.Get("work[language_id]"))
+	assert.Equal(t, "A Work", form.Get("work[title]"))
+	assert.Equal(t, "Original Work,No Fandom", form.Get("work[fandom_string]"))
+	assert.Equal(t, "General Audiences", form.Get("work[rating_string]"))
+	assert.Equal(t, []string{"", "No Archive Warnings Apply"}, form["work[archive_warning_strings][]"])
+	assert.Equal(t, []string{"", "Gen", "Other"}, form["work[category_strings][]"])
+	assert.Equal(t, "A/B", form.Get("work[relationship_string]"))
+	assert.Equal(t, "A,B", form.Get("work[character_string]"))
+	assert.Equal(t, "Fluff", form.Get("work[freeform_string]"))
+	assert.Equal(t, "<p>A summary</p>", form.Get("work[summary]"))
+	assert.Equal(t, "1", form.Get("front-notes-options-show"))
+	assert.Equal(t, "0", form.Get("end-notes-options-show"))
+	assert.Equal(t, "A Series", form.Get("work[series_attributes][title]"))
+	assert.Equal(t, "Beginnings", form.Get("work[chapter_attributes][title]"))
+	assert.Equal(t, "<p>Once upon a time</p>", form.Get("work[chapter_attributes][content]"))
+	assert.Equal(t, "1", form.Get("work[restricted]"))
+	assert.Equal(t, "Post", form.Get("post_button"))
+
+	id, err = client.SaveWorkDraft(options)
+	if err != nil {
+		t.Fatal(err.Error())
+	}
+	assert.Equal(t, "11", id)
+	assert.Equal(t, "Preview", forms[" /works"].Get("preview_button"))
+
+	options.Language = "Klingon"
+	_, err = client.PostWork(options)
+	if assert.NotNil(t, err) {
+		var validation *ValidationError
+		if assert.True(t, errors.As(err, &validation)) {
+			assert.Contains(t, validation.Fields, "Language")
+		}
+	}
+}
+
+// TestPostWorkValidation ensures AO3's validation messages are attributed to
+// the fields of WorkOptions
+func TestPostWorkValidation(t *testing.T) {
+	client, _, closeServer := newPostingServer(t)
+	defer closeServer()
+
+	importTestSession(t, client, "reader")
+
+	_, err := client.PostWork(WorkOptions{Content: "Once upon a time"})
+	if assert.NotNil(t, err) {
+		assert.True(t, errors.Is(err, ErrValidation))
+
+		var validation *ValidationError
+		if assert.True(t, errors.As(err, &validation)) {
+			assert.Len(t, validation.Messages, 4)
+			assert.Equal(t, []string{"Title can't be blank"}, validation.Fields["Title"])
+			assert.Len(t, validation.Fields["Fandoms"], 2)
+			assert.Len(t, validation.Fields["AdditionalTags"], 1)
+			assert.NotContains(t, validation.Fields, "Content")
+		}
+	}
+}
+
+// TestEditWork ensures works are edited with Rails' method override, keeping
+// the selected language and the first chapter unless they are given, and edits
+// are seen by GetWork despite the cache
+func TestEditWork(t *testing.T) {
+	client, forms, closeServer := newPostingServer(t)
+	defer closeServer()
+
+	client.Cache = NewMemoryCache(10)
+	importTestSession(t, client, "reader")
+
+	work, err := client.GetWork("10")
+	if err != nil {
+		t.Fatal(err.Error())
+	}
+	assert.Equal(t, "Old Title", work.Title)
+
+	if err := client.EditWork("10", WorkOptions{Title: "New Title", Fandoms: []string{"Original Work"}}); err != nil {
+		t.Fatal(err.Error())
+	}
+
+	form := forms["patch /works/10"]
+	assert.Equal(t, "New Title", form.Get("work[title]"))
+	assert.Equal(t, "2", form.Get("work[language_id]"))
+	assert.Equal(t, "Update", form.Get("update_button"))
+	assert.NotContains(t, form, "work[chapter_attributes][content]")
+
+	work, err = client.GetWork("10")
+	if err != nil {
+		t.Fatal(err.Error())
+	}
+	assert.Equal(t, "New Title", work.Title)
+
+	err = client.EditWork("12", WorkOptions{Title: "Not Mine"})
+	if assert.NotNil(t, err) {
+		assert.True(t, errors.Is(err, ErrValidation))
+	}
+}
+
+// TestPostChapter ensures chapters are posted and saved as drafts, that
+// validation messages are attributed to the fields of ChapterOptions and that
+// new chapters are seen by GetWork despite the cache
+func TestPostChapter(t *testing.T) {
+	client, forms, closeServer := newPostingServer(t)
+	defer closeServer()
+
+	client.Cache = NewMemoryCache(10)
+	importTestSession(t, client, "reader")
+
+	work, err := client.GetWork("10")
+	if err != nil {
+		t.Fatal(err.Error())
+	}
+	assert.Equal(t, "1/?", work.Chapters)
+
+	options := ChapterOptions{Title: "Middles", EndNotes: "Thanks!", Content: "<p>And then</p>"}
+
+	id, err := client.PostChapter("10", options)
+	if err != nil {
+		t.Fatal(err.Error())
+	}
+	assert.Equal(t, "20", id)
+
+	work, err = client.GetWork("10")
+	if err != nil {
+		t.Fatal(err.Error())
+	}
+	assert.Equal(t, "2/?", work.Chapters)
+
+	form := forms[" /works/10/chapters"]
+	assert.Equal(t, "9", form.Get("chapter[author_attributes][ids][]"))
+	assert.Equal(t, "Middles", form.Get("chapter[title]"))
+	assert.Equal(t, "<p>And then</p>", form.Get("chapter[content]"))
+	assert.Equal(t, "1", form.Get("end-notes-options-show"))
+	assert.Equal(t, "Post", form.Get("post_without_preview_button"))
+
+	id, err = client.SaveChapterDraft("10", options)
+	if err != nil {
+		t.Fatal(err.Error())
+	}
+	assert.Equal(t, "21", id)
+
+	_, err = client.PostChapter("10", ChapterOptions{Title: "Empty"})
+	if assert.NotNil(t, err) {
+		var validation *ValidationError
+		if assert.True(t, errors.As(err, &validation)) {
+			assert.Equal(t, []string{"Content can't be blank"}, validation.Fields["Content"])
+		}
+	}
+}
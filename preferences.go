@@ -0,0 +1,160 @@
+package ao3
+
+import (
+	"bytes"
+	"context"
+	"net/http"
+	"net/url"
+	"github.com/PuerkitoBio/goquery"
+)
+
+// hiddenTagsSelector matches the links AO3 shows in place of the warnings or
+// additional tags hidden by the logged-in user's preferences
+const hiddenTagsSelector = `a[href*="/tags/show_hidden"]`
+
+// Preferences are the logged-in user's account preferences which change the
+// pages AO3 serves them. Hidden warnings and additional tags are reported by
+// HasHiddenWarnings and HasHiddenFreeformTags on Work and IndexedWork.
+type Preferences struct {
+	// ShowAdultContent skips the adult content warning in front of works
+	ShowAdultContent bool
+	// ViewFullWorks shows every chapter of a work on its page by default
+	ViewFullWorks bool
+	// HideWarnings and HideAdditionalTags hide the warnings and additional
+	// tags of works until they are asked for
+	HideWarnings       bool
+	HideAdditionalTags bool
+	// DisableWorkSkins hides the custom styles of works
+	DisableWorkSkins bool
+	// HistoryEnabled records the works the user reads in their history
+	HistoryEnabled bool
+	// TimeZone is the name of the time zone dates are shown in, e.g. "UTC"
+	TimeZone string
+}
+
+// preferenceFields maps the boolean fields of Preferences to the fields of
+// AO3's preferences form
+func (preferences *Preferences) preferenceFields() map[string]*bool {
+	return map[string]*bool{
+		"preference[adult]":              &preferences.ShowAdultContent,
+		"preference[view_full_works]":    &preferences.ViewFullWorks,
+		"preference[hide_warnings]":      &preferences.HideWarnings,
+		"preference[hide_freeform]":      &preferences.HideAdditionalTags,
+		"preference[disable_work_skins]": &preferences.DisableWorkSkins,
+		"preference[history_enabled]":    &preferences.HistoryEnabled,
+	}
+}
+
+// GetPreferences returns the logged-in user's account preferences
+//
+// Endpoint: https://archiveofourown.org/users/[user]/preferences
+func (client *AO3Client) GetPreferences() (*Preferences, *AO3Error) {
+	return client.GetPreferencesWithContext(context.Background())
+}
+
+// GetPreferencesWithContext is GetPreferences with a context which cancels the
+// request when it is done
+func (client *AO3Client) GetPreferencesWithContext(ctx context.Context) (*Preferences, *AO3Error) {
+	if ao3Err := client.requireLogin("fetching preferences"); ao3Err != nil {
+		return nil, ao3Err
+	}
+
+	body, ao3Err := client.get(ctx, client.preferencesEndpoint(), 0, "fetching preferences")
+	if ao3Err != nil {
+		return nil, ao3Err
+	}
+
+	doc, err := goquery.NewDocumentFromReader(bytes.NewReader(body))
+	if err != nil {
+		return nil, WrapError(http.StatusUnprocessableEntity, err, "parsing preferences page with goquery failed")
+	}
+
+	formNode, ao3Err := preferencesForm(doc, "fetching preferences")
+	if ao3Err != nil {
+		return nil, ao3Err
+	}
+	form := formValues(formNode)
+
+	preferences := Preferences{TimeZone: form.Get("preference[time_zone]")}
+	for name, value := range preferences.preferenceFields() {
+		// Rails precedes each checkbox with a hidden "0", so the last value wins
+		if values := form[name]; len(values) > 0 {
+			*value = values[len(values)-1] == "1"
+		}
+	}
+
+	return &preferences, nil
+}
+
+// UpdatePreferences replaces the logged-in user's account preferences with
+// preferences, leaving the preferences it does not cover unchanged. An empty
+// TimeZone keeps the current time zone. As preferences change the markup of
+// AO3's pages, the user's cached pages are dropped after the update if the
+// client's Cache is a CachePurger; other caches keep serving the previous
+// markup until their entries expire.
+//
+// Endpoint: https://archiveofourown.org/users/[user]/preferences/[preference]
+func (client *AO3Client) UpdatePreferences(preferences Preferences) *AO3Error {
+	return client.UpdatePreferencesWithContext(context.Background(), preferences)
+}
+
+// UpdatePreferencesWithContext is UpdatePreferences with a context which
+// cancels the requests when it is done
+func (client *AO3Client) UpdatePreferencesWithContext(ctx context.Context, preferences Preferences) *AO3Error {
+	if ao3Err := client.requireLogin("updating preferences"); ao3Err != nil {
+		return ao3Err
+	}
+
+	doc, ao3Err := client.fetchForm(ctx, client.preferencesEndpoint(), "fetching preferences")
+	if ao3Err != nil {
+		return ao3Err
+	}
+
+	formNode, ao3Err := preferencesForm(doc, "fetching preferences")
+	if ao3Err != nil {
+		return ao3Err
+	}
+	formAction, ok := formNode.Attr("action")
+	if !ok {
+		return NewError(http.StatusUnprocessableEntity, "unable to find preferences form action")
+	}
+
+	form := formValues(formNode)
+	for name, value := range preferences.preferenceFields() {
+		form.Set(name, formBool(*value))
+	}
+	if preferences.TimeZone != "" {
+		form.Set("preference[time_zone]", preferences.TimeZone)
+	}
+	form.Set("commit", "Update")
+
+	res, ao3Err := client.submit(ctx, client.relativeLink(formAction), form, "updating preferences")
+	if ao3Err != nil {
+		return ao3Err
+	}
+
+	if _, ao3Err := checkSubmission(res.body, "updating preferences"); ao3Err != nil {
+		return ao3Err
+	}
+	client.invalidateUser()
+
+	return nil
+}
+
+// preferencesEndpoint returns the endpoint of the logged-in user's preferences
+func (client *AO3Client) preferencesEndpoint() string {
+	return "/users/" + url.PathEscape(client.Username()) + "/preferences"
+}
+
+// preferencesForm finds the preferences form on a preferences page
+func preferencesForm(doc *goquery.Document, action string) (*goquery.Selection, *AO3Error) {
+	formNode := doc.Find(`[name="preference[hide_warnings]"]`).Closest("form")
+	if len(formNode.Nodes) == 0 {
+		if ao3Err := validationError(doc, action); ao3Err != nil {
+			return nil, ao3Err
+		}
+		return nil, NewError(http.StatusUnprocessableEntity, "unable to find preferences form")
+	}
+
+	return formNode, nil
+}
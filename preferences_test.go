@@ -0,0 +1,129 @@
+package ao3
+
+import (
+	"errors"
+	"net/http"
+	"testing"
+	"github.com/stretchr/testify/assert"
+)
+
+const testPreferencesForm = `<form class="edit_preference" id="edit_preference_7" action="/users/reader/preferences/7" method="post">
+<input type="hidden" name="_method" value="patch" />
+<input type="hidden" name="preference[minimize_search_engines]" value="0" /><input type="checkbox" value="1" checked="checked" name="preference[minimize_search_engines]" />
+<input type="hidden" name="preference[adult]" value="0" /><input type="checkbox" value="1" checked="checked" name="preference[adult]" />
+<input type="hidden" name="preference[view_full_works]" value="0" /><input type="checkbox" value="1" name="preference[view_full_works]" />
+<input type="hidden" name="preference[hide_warnings]" value="0" /><input type="checkbox" value="1" name="preference[hide_warnings]" />
+<input type="hidden" name="preference[hide_freeform]" value="0" /><input type="checkbox" value="1" checked="checked" name="preference[hide_freeform]" />
+<input type="hidden" name="preference[disable_work_skins]" value="0" /><input type="checkbox" value="1" name="preference[disable_work_skins]" />
+<input type="hidden" name="preference[history_enabled]" value="0" /><input type="checkbox" value="1" checked="checked" name="preference[history_enabled]" />
+<select name="preference[time_zone]"><option value="UTC">(GMT+00:00) UTC</option><option selected="selected" value="London">(GMT+00:00) London</option></select>
+<input type="submit" name="commit" value="Update" />
+</form>`
+
+// TestPreferences ensures preferences are read from and written to AO3's
+// preferences form, keeping the preferences they do not cover
+func TestPreferences(t *testing.T) {
+	client, forms, closeServer := newTestArchive(t, false, func(w http.ResponseWriter, r *http.Request) {
+		switch {
+		case r.URL.Path == "/users/reader/preferences":
+			writeTestPage(w, testPreferencesForm)
+
+		case r.URL.Path == "/users/reader/preferences/7" && r.Method == http.MethodPost:
+			if r.PostFormValue("preference[time_zone]") == "Nowhere" {
+				writeTestPage(w, `<div id="error" class="error"><ul><li>Time zone is not included in the list</li></ul></div>`)
+				return
+			}
+			http.Redirect(w, r, "/users/reader/preferences", http.StatusFound)
+
+		default:
+			http.NotFound(w, r)
+		}
+	})
+	defer closeServer()
+
+	_, err := client.GetPreferences()
+	if assert.NotNil(t, err) {
+		assert.True(t, errors.Is(err, ErrRestricted))
+	}
+
+	importTestSession(t, client, "reader")
+
+	preferences, err := client.GetPreferences()
+	if err != nil {
+		t.Fatal(err.Error())
+	}
+	assert.Equal(t, Preferences{
+		ShowAdultContent:   true,
+		HideAdditionalTags: true,
+		HistoryEnabled:     true,
+		TimeZone:           "London",
+	}, *preferences)
+
+	preferences.HideWarnings = true
+	preferences.HideAdditionalTags = false
+	if err := client.UpdatePreferences(*preferences); err != nil {
+		t.Fatal(err.Error())
+	}
+	updated := forms["patch /users/reader/preferences/7"]
+	assert.Equal(t, "patch", updated.Get("_method"))
+	assert.Equal(t, "1", updated.Get("preference[hide_warnings]"))
+	assert.Equal(t, "0", updated.Get("preference[hide_freeform]"))
+	assert.Equal(t, "1", updated.Get("preference[adult]"))
+	assert.Equal(t, "London", updated.Get("preference[time_zone]"))
+	assert.Equal(t, []string{"0", "1"}, updated["preference[minimize_search_engines]"])
+
+	preferences.TimeZone = "Nowhere"
+	err = client.UpdatePreferences(*preferences)
+	if assert.NotNil(t, err) {
+		assert.True(t, errors.Is(err, ErrValidation))
+	}
+}
+
+// TestUpdatePreferencesDropsCachedPages ensures pages cached with the previous
+// preferences are fetched again once they change
+func TestUpdatePreferencesDropsCachedPages(t *testing.T) {
+	hideWarnings := false
+
+	client, _, closeServer := newTestArchive(t, false, func(w http.ResponseWriter, r *http.Request) {
+		switch {
+		case r.URL.Path == "/users/reader/preferences":
+			writeTestPage(w, testPreferencesForm)
+
+		case r.URL.Path == "/users/reader/preferences/7" && r.Method == http.MethodPost:
+			hideWarnings = r.PostFormValue("preference[hide_warnings]") == "1"
+			http.Redirect(w, r, "/users/reader/preferences", http.StatusFound)
+
+		case r.URL.Path == "/works/5":
+			warnings := `<li><a class="tag" href="/tags/No%20Archive%20Warnings%20Apply/works">No Archive Warnings Apply</a></li>`
+			if hideWarnings {
+				warnings = `<li><a class="warnings" data-remote="true" href="/tags/show_hidden?creation_id=5&amp;creation_type=Work&amp;tag_type=warnings">Show warnings</a></li>`
+			}
+			writeTestPage(w, `<dl class="work meta group"><dd class="warning tags"><ul class="commas">`+warnings+`</ul></dd><dd class="language">English</dd></dl>
+<ul class="work navigation actions"><li class="download"><ul class="expandable secondary"><li><a href="/downloads/5/Hidden.html?updated_at=1">HTML</a></li></ul></li></ul>
+<div id="workskin"><div class="preface group"><h2 class="title heading">Hidden</h2><h3 class="byline heading"><a rel="author" href="/users/author/pseuds/author">author</a></h3></div></div>`)
+
+		default:
+			http.NotFound(w, r)
+		}
+	})
+	defer closeServer()
+
+	client.Cache = NewMemoryCache(10)
+	importTestSession(t, client, "reader")
+
+	work, err := client.GetWork("5")
+	if err != nil {
+		t.Fatal(err.Error())
+	}
+	assert.False(t, work.HasHiddenWarnings)
+
+	if err := client.UpdatePreferences(Preferences{HideWarnings: true}); err != nil {
+		t.Fatal(err.Error())
+	}
+
+	work, err = client.GetWork("5")
+	if err != nil {
+		t.Fatal(err.Error())
+	}
+	assert.True(t, work.HasHiddenWarnings)
+}
@@ -0,0 +1,192 @@
+package ao3
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"strconv"
+	"sync"
+	"time"
+)
+
+// errRateLimitExceedsDeadline is returned by RateLimiter.Wait when the caller's
+// deadline would pass before a request is allowed
+var errRateLimitExceedsDeadline = errors.New("rate limit wait exceeds context deadline")
+
+// RateLimiter is a token bucket limiting the rate of requests made to AO3. A
+// single RateLimiter may be shared by several clients so that every endpoint
+// of every client draws from the same bucket.
+//
+// When AO3 responds with 429 Too Many Requests, the limiter is paused for the
+// duration given by the Retry-After header so that concurrent requests wait
+// too instead of being throttled in turn.
+//
+// A limiter created with Child also waits for its parent, so that e.g. each
+// account may be limited on its own while all accounts share a global limit.
+type RateLimiter struct {
+	mu sync.Mutex
+
+	parent *RateLimiter
+
+	rate   float64
+	burst  float64
+	tokens float64
+	last   time.Time
+
+	pausedUntil time.Time
+}
+
+// NewRateLimiter creates a limiter allowing requestsPerSecond requests on
+// average with bursts of up to burst requests. For example, NewRateLimiter(0.5,
+// 3) allows three requests at once, then one request every two seconds. A
+// non-positive rate disables limiting while still honouring Retry-After pauses.
+func NewRateLimiter(requestsPerSecond float64, burst int) *RateLimiter {
+	if burst < 1 {
+		burst = 1
+	}
+
+	return &RateLimiter{
+		rate:   requestsPerSecond,
+		burst:  float64(burst),
+		tokens: float64(burst),
+		last:   time.Now(),
+	}
+}
+
+// Child creates a limiter allowing requestsPerSecond requests on average with
+// bursts of up to burst requests, like NewRateLimiter, whose requests are also
+// limited by this limiter. Pauses caused by 429 responses apply to the parent
+// too, as AO3 throttles by IP address rather than by account.
+func (limiter *RateLimiter) Child(requestsPerSecond float64, burst int) *RateLimiter {
+	child := NewRateLimiter(requestsPerSecond, burst)
+	child.parent = limiter
+	return child
+}
+
+// Wait blocks until a request may be made. It returns ctx.Err() if the context
+// is done while waiting, or an error without waiting if the request would not
+// be allowed before the context's deadline.
+func (limiter *RateLimiter) Wait(ctx context.Context) error {
+	delay := limiter.reserve(time.Now())
+
+	if deadline, ok := ctx.Deadline(); ok && time.Now().Add(delay).After(deadline) {
+		limiter.cancelReservation()
+		return errRateLimitExceedsDeadline
+	}
+
+	if err := sleepContext(ctx, delay); err != nil {
+		limiter.cancelReservation()
+		return err
+	}
+
+	if limiter.parent != nil {
+		if err := limiter.parent.Wait(ctx); err != nil {
+			limiter.cancelReservation()
+			return err
+		}
+	}
+
+	return nil
+}
+
+// reserve takes a token from the bucket, returning how long the caller must
+// wait before using it. The bucket may go into debt so that concurrent callers
+// are queued rather than all woken at once.
+func (limiter *RateLimiter) reserve(now time.Time) time.Duration {
+	limiter.mu.Lock()
+	defer limiter.mu.Unlock()
+
+	if now.After(limiter.last) {
+		limiter.tokens += now.Sub(limiter.last).Seconds() * limiter.rate
+		if limiter.tokens > limiter.burst {
+			limiter.tokens = limiter.burst
+		}
+		limiter.last = now
+	}
+
+	var delay time.Duration
+	if limiter.rate > 0 {
+		limiter.tokens--
+		if limiter.tokens < 0 {
+			delay = time.Duration(-limiter.tokens / limiter.rate * float64(time.Second))
+		}
+	}
+
+	if pause := limiter.pausedUntil.Sub(now); pause > delay {
+		delay = pause
+	}
+
+	return delay
+}
+
+// cancelReservation returns a token taken by reserve which was never used
+func (limiter *RateLimiter) cancelReservation() {
+	limiter.mu.Lock()
+	defer limiter.mu.Unlock()
+
+	if limiter.rate <= 0 {
+		return
+	}
+
+	limiter.tokens++
+	if limiter.tokens > limiter.burst {
+		limiter.tokens = limiter.burst
+	}
+}
+
+// pause stops the limiter and its parents from allowing any requests until the
+// given time
+func (limiter *RateLimiter) pause(until time.Time) {
+	limiter.mu.Lock()
+	if until.After(limiter.pausedUntil) {
+		limiter.pausedUntil = until
+	}
+	limiter.mu.Unlock()
+
+	if limiter.parent != nil {
+		limiter.parent.pause(until)
+	}
+}
+
+// parseRetryAfter parses a Retry-After header, which is either a number of
+// seconds or an HTTP date. ok is false if the header is missing or malformed.
+func parseRetryAfter(header string, now time.Time) (wait time.Duration, ok bool) {
+	if header == "" {
+		return 0, false
+	}
+
+	if seconds, err := strconv.Atoi(header); err == nil {
+		if seconds < 0 {
+			seconds = 0
+		}
+		return time.Duration(seconds) * time.Second, true
+	}
+
+	if date, err := http.ParseTime(header); err == nil {
+		wait = date.Sub(now)
+		if wait < 0 {
+			wait = 0
+		}
+		return wait, true
+	}
+
+	return 0, false
+}
+
+// sleepContext sleeps for the given duration, returning early with ctx.Err()
+// if the context is done first
+func sleepContext(ctx context.Context, duration time.Duration) error {
+	if duration <= 0 {
+		return ctx.Err()
+	}
+
+	timer := time.NewTimer(duration)
+	defer timer.Stop()
+
+	select {
+	case <-ctx.Done():
+		return ctx.Err()
+	case <-timer.C:
+		return nil
+	}
+}
@@ -0,0 +1,141 @@
+package ao3
+
+import (
+	"context"
+	"testing"
+	"time"
+	"net/http"
+	"sync/atomic"
+	"github.com/stretchr/testify/assert"
+)
+
+// TestRateLimiterAllowsBurstThenLimits ensures requests beyond the burst are
+// spaced according to the rate
+func TestRateLimiterAllowsBurstThenLimits(t *testing.T) {
+	limiter := NewRateLimiter(20, 2)
+	ctx := context.Background()
+
+	start := time.Now()
+	for i := 0; i < 4; i++ {
+		if err := limiter.Wait(ctx); err != nil {
+			t.Fatal(err.Error())
+		}
+	}
+
+	// Two requests are immediate, the other two each wait 50ms
+	elapsed := time.Since(start)
+	assert.True(t, elapsed >= 90*time.Millisecond, elapsed.String())
+}
+
+// TestRateLimiterRejectsWaitBeyondDeadline ensures a wait which cannot finish
+// before the deadline fails immediately
+func TestRateLimiterRejectsWaitBeyondDeadline(t *testing.T) {
+	limiter := NewRateLimiter(0.1, 1)
+
+	if err := limiter.Wait(context.Background()); err != nil {
+		t.Fatal(err.Error())
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+	defer cancel()
+
+	start := time.Now()
+	assert.Equal(t, errRateLimitExceedsDeadline, limiter.Wait(ctx))
+	assert.True(t, time.Since(start) < 500*time.Millisecond)
+}
+
+// TestChildRateLimiterWaitsForParent ensures children are limited by both
+// their own rate and the rate shared through their parent
+func TestChildRateLimiterWaitsForParent(t *testing.T) {
+	parent := NewRateLimiter(20, 1)
+	first := parent.Child(0, 1)
+	second := parent.Child(0, 1)
+	ctx := context.Background()
+
+	start := time.Now()
+	for i := 0; i < 4; i++ {
+		limiter := first
+		if i%2 == 1 {
+			limiter = second
+		}
+		if err := limiter.Wait(ctx); err != nil {
+			t.Fatal(err.Error())
+		}
+	}
+
+	// One request is immediate, the other three each wait 50ms for the parent
+	elapsed := time.Since(start)
+	assert.True(t, elapsed >= 140*time.Millisecond, elapsed.String())
+
+	// A child's pause stops its siblings too
+	first.pause(time.Now().Add(time.Hour))
+	timeoutCtx, cancel := context.WithTimeout(ctx, time.Second)
+	defer cancel()
+	assert.Equal(t, errRateLimitExceedsDeadline, second.Wait(timeoutCtx))
+}
+
+func TestParseRetryAfter(t *testing.T) {
+	now := time.Date(2018, 1, 1, 0, 0, 0, 0, time.UTC)
+
+	wait, ok := parseRetryAfter("120", now)
+	assert.True(t, ok)
+	assert.Equal(t, 2*time.Minute, wait)
+
+	wait, ok = parseRetryAfter("Mon, 01 Jan 2018 00:00:30 GMT", now)
+	assert.True(t, ok)
+	assert.Equal(t, 30*time.Second, wait)
+
+	_, ok = parseRetryAfter("", now)
+	assert.False(t, ok)
+
+	_, ok = parseRetryAfter("soon", now)
+	assert.False(t, ok)
+}
+
+// TestGetWaitsForRetryAfter ensures a 429 response is retried once the
+// Retry-After delay has passed
+func TestGetWaitsForRetryAfter(t *testing.T) {
+	var requests int32
+	client, server := newTestClient(t, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if atomic.AddInt32(&requests, 1) == 1 {
+			w.Header().Set("Retry-After", "1")
+			w.WriteHeader(http.StatusTooManyRequests)
+			w.Write([]byte("Retry later"))
+			return
+		}
+		w.Write([]byte("<ol></ol>"))
+	}))
+	defer server.Close()
+
+	client.RateLimiter = NewRateLimiter(100, 1)
+
+	start := time.Now()
+	_, err := client.GetFandomCategory("Books%20*a*%20Literature")
+	if err != nil {
+		t.Fatal(err.Error())
+	}
+
+	assert.Equal(t, int32(2), atomic.LoadInt32(&requests))
+	assert.True(t, time.Since(start) >= time.Second)
+}
+
+// TestGetReturnsThrottledBeyondDeadline ensures a Retry-After delay which
+// exceeds the caller's deadline is reported as a throttled error
+func TestGetReturnsThrottledBeyondDeadline(t *testing.T) {
+	client, server := newTestClient(t, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Retry-After", "300")
+		w.WriteHeader(http.StatusTooManyRequests)
+	}))
+	defer server.Close()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	_, err := client.GetWorkWithContext(ctx, "1")
+	if err == nil {
+		t.Fatal("expected a throttled error")
+	}
+
+	assert.True(t, err.IsThrottled())
+	assert.False(t, err.IsCanceled())
+}
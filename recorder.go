@@ -0,0 +1,141 @@
+package ao3
+
+import (
+	"bufio"
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"io/ioutil"
+	"net/http"
+	"net/http/httputil"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+)
+
+// RecorderMode determines whether a Recorder captures or replays responses
+type RecorderMode int
+
+const (
+	// ReplayMode serves responses from fixture files and fails requests which
+	// have no fixture, so that no request reaches the network
+	ReplayMode RecorderMode = 0
+	// RecordMode forwards requests to the network and saves each response as a
+	// fixture file without its cookies, overwriting existing fixtures
+	RecordMode RecorderMode = 1
+)
+
+// maxFixtureNameLength is the length after which fixture names are shortened
+// and suffixed with a hash of the request
+const maxFixtureNameLength = 100
+
+var fixtureNameRegex = regexp.MustCompile("[^A-Za-z0-9.-]+")
+
+// Recorder is an http.RoundTripper which records real AO3 responses to fixture
+// files and replays them, allowing the scrapers to be tested offline against
+// pinned pages.
+//
+// Fixtures are raw HTTP responses named after the request's method, path and
+// query. The host is ignored, so fixtures recorded from one mirror can be
+// replayed against another.
+type Recorder struct {
+	// Dir is the directory fixture files are read from and written to
+	Dir  string
+	Mode RecorderMode
+
+	// Transport performs requests in RecordMode. Defaults to
+	// http.DefaultTransport.
+	Transport http.RoundTripper
+}
+
+// NewRecorder creates a Recorder using the fixtures in dir
+func NewRecorder(dir string, mode RecorderMode) *Recorder {
+	return &Recorder{
+		Dir:  dir,
+		Mode: mode,
+	}
+}
+
+func (recorder *Recorder) RoundTrip(req *http.Request) (*http.Response, error) {
+	if recorder.Mode == RecordMode {
+		return recorder.record(req)
+	}
+
+	return recorder.replay(req)
+}
+
+// FixturePath returns the file the response to req is stored in
+func (recorder *Recorder) FixturePath(req *http.Request) string {
+	return filepath.Join(recorder.Dir, fixtureName(req.Method, req.URL.RequestURI())+".http")
+}
+
+func (recorder *Recorder) replay(req *http.Request) (*http.Response, error) {
+	if req.Body != nil {
+		req.Body.Close()
+	}
+
+	path := recorder.FixturePath(req)
+	fixture, err := ioutil.ReadFile(path)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil, errors.New("no fixture recorded for " + req.Method + " " + req.URL.RequestURI() + " (expected " + path + ")")
+		}
+		return nil, err
+	}
+
+	return http.ReadResponse(bufio.NewReader(bytes.NewReader(fixture)), req)
+}
+
+func (recorder *Recorder) record(req *http.Request) (*http.Response, error) {
+	transport := recorder.Transport
+	if transport == nil {
+		transport = http.DefaultTransport
+	}
+
+	res, err := transport.RoundTrip(req)
+	if err != nil {
+		return nil, err
+	}
+
+	body, err := ioutil.ReadAll(res.Body)
+	res.Body.Close()
+	if err != nil {
+		return nil, err
+	}
+
+	// Cookies carry the session of whoever recorded the fixture, so they are
+	// left out of the file but still handed to the caller's jar
+	scrubbed := *res
+	scrubbed.Header = res.Header.Clone()
+	scrubbed.Header.Del("Set-Cookie")
+	scrubbed.Body = ioutil.NopCloser(bytes.NewReader(body))
+
+	fixture, err := httputil.DumpResponse(&scrubbed, true)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := os.MkdirAll(recorder.Dir, 0755); err != nil {
+		return nil, err
+	}
+	if err := ioutil.WriteFile(recorder.FixturePath(req), fixture, 0644); err != nil {
+		return nil, err
+	}
+
+	res.Body = ioutil.NopCloser(bytes.NewReader(body))
+	return res, nil
+}
+
+// fixtureName converts a request into a file name which is readable for short
+// URLs and unique for long ones, e.g. "get_works_5191202_view_adult_true"
+func fixtureName(method string, requestURI string) string {
+	name := strings.ToLower(method) + "_" + strings.Trim(fixtureNameRegex.ReplaceAllString(requestURI, "_"), "_")
+	if len(name) <= maxFixtureNameLength {
+		return name
+	}
+
+	hash := sha256.Sum256([]byte(method + " " + requestURI))
+	return name[:maxFixtureNameLength-13] + "_" + hex.EncodeToString(hash[:])[:12]
+}
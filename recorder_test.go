@@ -0,0 +1,94 @@
+package ao3
+
+import (
+	"testing"
+	"io/ioutil"
+	"net/http"
+	"os"
+	"github.com/stretchr/testify/assert"
+)
+
+// TestRecorderRecordsAndReplays ensures responses recorded from a server are
+// replayed once the server is gone
+func TestRecorderRecordsAndReplays(t *testing.T) {
+	dir, err := ioutil.TempDir("", "ao3-fixtures")
+	if err != nil {
+		t.Fatal(err.Error())
+	}
+	defer os.RemoveAll(dir)
+
+	recorder := NewRecorder(dir, RecordMode)
+	client, server := newTestClient(t, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("ETag", `"fixture"`)
+		w.Write([]byte(testFandomCategoriesPage))
+	}))
+	client.HttpClient.Transport = recorder
+
+	recorded, ao3Err := client.GetFandomCategories()
+	if ao3Err != nil {
+		t.Fatal(ao3Err.Error())
+	}
+	server.Close()
+
+	recorder.Mode = ReplayMode
+	replayed, ao3Err := client.GetFandomCategories()
+	if ao3Err != nil {
+		t.Fatal(ao3Err.Error())
+	}
+
+	assert.Equal(t, recorded, replayed)
+}
+
+// TestRecorderScrubsCookies ensures recorded fixtures do not contain the
+// recording session's cookies, which still reach the client
+func TestRecorderScrubsCookies(t *testing.T) {
+	dir, err := ioutil.TempDir("", "ao3-fixtures")
+	if err != nil {
+		t.Fatal(err.Error())
+	}
+	defer os.RemoveAll(dir)
+
+	recorder := NewRecorder(dir, RecordMode)
+	client, server := newTestClient(t, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		http.SetCookie(w, &http.Cookie{Name: "_otwarchive_session", Value: "secret", Path: "/"})
+		w.Write([]byte(testFandomCategoriesPage))
+	}))
+	defer server.Close()
+	client.HttpClient.Transport = recorder
+
+	if _, ao3Err := client.GetFandomCategories(); ao3Err != nil {
+		t.Fatal(ao3Err.Error())
+	}
+
+	req, _ := http.NewRequest(http.MethodGet, server.URL+"/media", nil)
+	fixture, err := ioutil.ReadFile(recorder.FixturePath(req))
+	if err != nil {
+		t.Fatal(err.Error())
+	}
+	assert.NotContains(t, string(fixture), "secret")
+	assert.Contains(t, string(fixture), "listbox")
+
+	if cookies := client.HttpClient.Jar.Cookies(client.baseURL); assert.Len(t, cookies, 1) {
+		assert.Equal(t, "secret", cookies[0].Value)
+	}
+}
+
+// TestRecorderFailsWithoutFixture ensures replaying never reaches the network
+func TestRecorderFailsWithoutFixture(t *testing.T) {
+	client := newFixtureClient(t)
+	if *record {
+		t.Skip("fixtures are being recorded")
+	}
+
+	_, err := client.GetWork("0")
+	assert.NotNil(t, err)
+}
+
+func TestFixtureName(t *testing.T) {
+	assert.Equal(t, "get_works_5191202_view_adult_true", fixtureName("GET", "/works/5191202?view_adult=true"))
+	assert.Equal(t, "get_media_Books_20_a_20Literature_fandoms", fixtureName("GET", "/media/Books%20*a*%20Literature/fandoms"))
+
+	long := fixtureName("GET", "/works/search?utf8=%E2%9C%93&work_search%5Btitle%5D=Across+the+Stars&work_search%5Bcreators%5D=+jacksqueen16")
+	assert.Equal(t, maxFixtureNameLength, len(long))
+	assert.NotEqual(t, long, fixtureName("GET", "/works/search?utf8=%E2%9C%93&work_search%5Btitle%5D=Across+the+Stars&work_search%5Bcreators%5D=+jacksqueen17"))
+}
@@ -0,0 +1,270 @@
+package ao3
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+)
+
+// maxThrottleRetries is the number of times a request throttled with 429 Too
+// Many Requests is retried before a throttled error is returned
+const maxThrottleRetries = 3
+
+// defaultRetryAfter is waited when AO3 throttles a request without sending a
+// usable Retry-After header
+const defaultRetryAfter = 30 * time.Second
+
+// response is a fully read HTTP response
+type response struct {
+	statusCode int
+	header     http.Header
+	body       []byte
+
+	// url is the URL of the response after following redirects
+	url *url.URL
+}
+
+// get fetches an endpoint relative to the base URL and returns the body of the
+// response. The action describes the request in error messages, e.g.
+// "fetching work" results in "fetching work returned a non-200 status code".
+//
+// If the client has a Cache and ttl is positive, fresh cached responses are
+// returned without a request and stale responses are revalidated using their
+// ETag or Last-Modified headers.
+func (client *AO3Client) get(ctx context.Context, endpoint string, ttl time.Duration, action string) ([]byte, *AO3Error) {
+	rawURL := client.endpointURL(endpoint)
+
+	if client.Cache == nil || ttl <= 0 {
+		res, ao3Err := client.fetch(ctx, http.MethodGet, rawURL, nil, nil, action)
+		if ao3Err != nil {
+			return nil, ao3Err
+		}
+		return res.body, nil
+	}
+
+	key := client.cacheKey(rawURL)
+	header := http.Header{}
+
+	entry, cached := client.Cache.Get(key)
+	if cached {
+		if time.Now().Before(entry.Expires) {
+			return entry.Body, nil
+		}
+
+		if entry.ETag != "" {
+			header.Set("If-None-Match", entry.ETag)
+		}
+		if entry.LastModified != "" {
+			header.Set("If-Modified-Since", entry.LastModified)
+		}
+	}
+
+	res, ao3Err := client.fetch(ctx, http.MethodGet, rawURL, header, nil, action)
+	if ao3Err != nil {
+		return nil, ao3Err
+	}
+
+	if res.statusCode == http.StatusNotModified && cached {
+		entry.Expires = time.Now().Add(ttl)
+		client.Cache.Set(key, entry)
+		return entry.Body, nil
+	}
+
+	client.Cache.Set(key, &CacheEntry{
+		Body:         res.body,
+		ETag:         res.header.Get("ETag"),
+		LastModified: res.header.Get("Last-Modified"),
+		Expires:      time.Now().Add(ttl),
+	})
+
+	return res.body, nil
+}
+
+// post submits a form to an endpoint relative to the base URL and returns the
+// response, after following any redirects. Forms are never cached.
+func (client *AO3Client) post(ctx context.Context, endpoint string, form url.Values, action string) (*response, *AO3Error) {
+	return client.fetch(ctx, http.MethodPost, client.endpointURL(endpoint), nil, form, action)
+}
+
+// fetch performs a request with the given extra headers and, for POST
+// requests, URL-encoded form, accepting 200 OK and, for conditional requests,
+// 304 Not Modified.
+//
+// Requests wait for the client's RateLimiter, if any. Throttled requests are
+// retried after the delay given by AO3's Retry-After header unless it exceeds
+// MaxThrottleWait or the context's deadline, in which case the returned error
+// has the code http.StatusTooManyRequests and IsThrottled reports true. Other
+// failures of GET requests are retried according to the client's RetryPolicy.
+// Other requests are not, as AO3 may have acted on them before failing.
+//
+// If ctx is canceled or its deadline passes, the returned error has the code
+// StatusRequestCanceled and IsCanceled reports true.
+//
+// AO3's error pages and interstitials are returned as errors of the matching
+// kind even when they are served with 200 OK, e.g. ErrAdultGate for the adult
+// content warning and ErrRestricted for the login page shown in place of
+// restricted works.
+func (client *AO3Client) fetch(ctx context.Context, method string, rawURL string, header http.Header, form url.Values, action string) (*response, *AO3Error) {
+	retryable := method == http.MethodGet
+
+	throttles := 0
+	for attempt := 1; ; attempt++ {
+		if client.RateLimiter != nil {
+			if err := client.RateLimiter.Wait(ctx); err != nil {
+				if err == errRateLimitExceedsDeadline {
+					return nil, WrapError(http.StatusTooManyRequests, err, action+" was throttled by the rate limiter")
+				}
+				return nil, WrapError(StatusRequestCanceled, err, action+" was canceled")
+			}
+		}
+
+		var reqBody io.Reader
+		if form != nil {
+			reqBody = strings.NewReader(form.Encode())
+		}
+
+		req, err := http.NewRequest(method, rawURL, reqBody)
+		if err != nil {
+			return nil, WrapError(http.StatusBadRequest, err, action+" failed to create a request")
+		}
+		req = req.WithContext(ctx)
+		for name, values := range header {
+			req.Header[name] = values
+		}
+		if form != nil {
+			req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+		}
+
+		if err := client.beforeRequest(req); err != nil {
+			return nil, WrapError(http.StatusBadRequest, err, action+" was rejected by middleware")
+		}
+
+		start := time.Now()
+		res, err := client.HttpClient.Do(req)
+		client.afterResponse(req, res, err, time.Since(start))
+		if err != nil {
+			if retryable && client.retry(ctx, attempt, nil, err) {
+				continue
+			}
+			if ctx.Err() != nil {
+				return nil, WrapError(StatusRequestCanceled, ctx.Err(), action+" was canceled")
+			}
+			return nil, WrapError(http.StatusServiceUnavailable, err, action+" returned an err").withKind(KindNetwork)
+		}
+
+		if res.StatusCode == http.StatusTooManyRequests {
+			res.Body.Close()
+
+			wait, ok := parseRetryAfter(res.Header.Get("Retry-After"), time.Now())
+			if !ok {
+				wait = defaultRetryAfter
+			}
+
+			if ao3Err := client.waitForThrottle(ctx, wait, throttles, action); ao3Err != nil {
+				return nil, ao3Err
+			}
+
+			// Being throttled does not count as a failed attempt
+			throttles++
+			attempt--
+			continue
+		}
+
+		notModified := res.StatusCode == http.StatusNotModified && len(header) > 0
+		if res.StatusCode != http.StatusOK && !notModified {
+			res.Body.Close()
+
+			ao3Err := NewError(res.StatusCode, action+" returned a non-200 status code")
+			if isMaintenanceStatusCode(res.StatusCode) {
+				ao3Err = NewError(res.StatusCode, action+" failed as AO3 is unavailable or down for maintenance")
+			}
+			if res.StatusCode == http.StatusUnprocessableEntity {
+				// AO3 rejects forms with expired authenticity tokens with 422,
+				// which is not a failure to parse
+				ao3Err.withKind(KindUnknown)
+			}
+
+			if retryable && client.retry(ctx, attempt, res, nil) {
+				continue
+			}
+			if ctx.Err() != nil {
+				return nil, WrapError(StatusRequestCanceled, ctx.Err(), action+" was canceled")
+			}
+			return nil, ao3Err
+		}
+
+		body, err := ioutil.ReadAll(res.Body)
+		res.Body.Close()
+		if err != nil {
+			if ctx.Err() != nil {
+				return nil, WrapError(StatusRequestCanceled, ctx.Err(), action+" was canceled")
+			}
+			return nil, WrapError(http.StatusUnprocessableEntity, err, "unable to read bytes from response").withKind(KindNetwork)
+		}
+
+		finalURL := req.URL
+		if res.Request != nil {
+			finalURL = res.Request.URL
+		}
+		if page := classifyPage(finalURL, body); page != nil {
+			return nil, NewError(page.code, action+" failed as "+page.description).withKind(page.kind)
+		}
+
+		return &response{statusCode: res.StatusCode, header: res.Header, body: body, url: finalURL}, nil
+	}
+}
+
+// retry consults the client's RetryPolicy after a failed attempt and waits for
+// the returned delay. It returns false if the request should not be retried,
+// including when the delay would exceed the context's deadline.
+func (client *AO3Client) retry(ctx context.Context, attempt int, res *http.Response, err error) bool {
+	if client.RetryPolicy == nil {
+		return false
+	}
+
+	delay, ok := client.RetryPolicy.Retry(attempt, res, err)
+	if !ok {
+		return false
+	}
+
+	if deadline, ok := ctx.Deadline(); ok && time.Now().Add(delay).After(deadline) {
+		return false
+	}
+
+	return sleepContext(ctx, delay) == nil
+}
+
+// waitForThrottle waits out a 429 response, pausing the rate limiter so that
+// concurrent requests back off too. A throttled error is returned instead if
+// the request should be given up on.
+func (client *AO3Client) waitForThrottle(ctx context.Context, wait time.Duration, attempt int, action string) *AO3Error {
+	throttled := fmt.Sprintf("%s was throttled by AO3 (retry after %s)", action, wait)
+
+	if attempt >= maxThrottleRetries {
+		return NewError(http.StatusTooManyRequests, throttled)
+	}
+	if client.MaxThrottleWait > 0 && wait > client.MaxThrottleWait {
+		return NewError(http.StatusTooManyRequests, throttled)
+	}
+	if deadline, ok := ctx.Deadline(); ok && time.Now().Add(wait).After(deadline) {
+		return NewError(http.StatusTooManyRequests, throttled)
+	}
+
+	if client.RateLimiter != nil {
+		client.RateLimiter.pause(time.Now().Add(wait))
+
+		// The limiter will wait out the pause before the next attempt
+		return nil
+	}
+
+	if err := sleepContext(ctx, wait); err != nil {
+		return WrapError(StatusRequestCanceled, err, action+" was canceled")
+	}
+
+	return nil
+}
@@ -0,0 +1,186 @@
+package ao3
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"testing"
+	"time"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"github.com/stretchr/testify/assert"
+)
+
+// newTestClient returns a client pointed at a local server running handler
+func newTestClient(t *testing.T, handler http.Handler) (*AO3Client, *httptest.Server) {
+	server := httptest.NewServer(handler)
+
+	client, err := InitAO3Client(nil, AO3Policy)
+	if err != nil {
+		server.Close()
+		t.Fatal(err.Error())
+	}
+
+	if err := client.SetBaseURL(server.URL); err != nil {
+		server.Close()
+		t.Fatal(err.Error())
+	}
+
+	return client, server
+}
+
+// testAuthenticityToken is the authenticity token of the archives served by
+// newTestArchive
+const testAuthenticityToken = "test-token"
+
+// newTestArchive returns a client pointed at a fake AO3 on which the session
+// "reader" is logged in, and the forms posted to it keyed on their _method and
+// path. Requests from other sessions are redirected to the login page unless
+// public is set. Posts must carry testAuthenticityToken, which the home page
+// serves; every other request is served by routes.
+func newTestArchive(t *testing.T, public bool, routes http.HandlerFunc) (*AO3Client, map[string]url.Values, func()) {
+	var mu sync.Mutex
+	forms := map[string]url.Values{}
+
+	client, server := newTestClient(t, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		mu.Lock()
+		defer mu.Unlock()
+
+		if !public && !isTestReader(r) {
+			http.Redirect(w, r, "/users/login", http.StatusFound)
+			return
+		}
+
+		if r.Method == http.MethodPost {
+			if r.PostFormValue("authenticity_token") != testAuthenticityToken {
+				w.WriteHeader(http.StatusUnprocessableEntity)
+				return
+			}
+			forms[r.PostFormValue("_method")+" "+r.URL.Path] = r.PostForm
+		}
+
+		if r.URL.Path == "/" {
+			writeTestPage(w, "")
+			return
+		}
+
+		routes(w, r)
+	}))
+
+	return client, forms, server.Close
+}
+
+// isTestReader reports whether a request to a fake archive comes from the
+// session of "reader"
+func isTestReader(r *http.Request) bool {
+	session, _ := r.Cookie("_otwarchive_session")
+	return session != nil && session.Value == "reader"
+}
+
+// writeTestPage serves content as the main content of a page of a fake
+// archive, with testAuthenticityToken for its forms
+func writeTestPage(w http.ResponseWriter, content string) {
+	w.Write([]byte(`<html><head><meta name="csrf-token" content="` + testAuthenticityToken + `" /></head><body class="logged-in"><div id="main">` + content + `</div></body></html>`))
+}
+
+// TestGetWorkWithContextCanceled ensures a canceled context aborts an in-flight
+// request and is reported as a cancellation rather than a network error
+func TestGetWorkWithContextCanceled(t *testing.T) {
+	release := make(chan struct{})
+	client, server := newTestClient(t, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		<-release
+	}))
+	defer server.Close()
+	defer close(release)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	go func() {
+		time.Sleep(50 * time.Millisecond)
+		cancel()
+	}()
+
+	_, err := client.GetWorkWithContext(ctx, "1")
+	if err == nil {
+		t.Fatal("expected an error from a canceled request")
+	}
+
+	assert.True(t, err.IsCanceled())
+	assert.Equal(t, StatusRequestCanceled, err.Code())
+}
+
+// TestDownloadWorkWithContextDeadline ensures an exceeded deadline is reported
+// as a cancellation
+func TestDownloadWorkWithContextDeadline(t *testing.T) {
+	release := make(chan struct{})
+	client, server := newTestClient(t, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		<-release
+	}))
+	defer server.Close()
+	defer close(release)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+
+	_, err := client.DownloadWorkWithContext(ctx, "Co/CodenameCarrot/5191202/A.html")
+	if err == nil {
+		t.Fatal("expected an error from a request past its deadline")
+	}
+
+	assert.True(t, err.IsCanceled())
+}
+
+// TestGetReturnsStatusCode ensures non-200 responses are not reported as
+// cancellations
+func TestGetReturnsStatusCode(t *testing.T) {
+	client, server := newTestClient(t, http.NotFoundHandler())
+	defer server.Close()
+
+	_, err := client.GetSeriesWithContext(context.Background(), "1")
+	if err == nil {
+		t.Fatal("expected an error from a missing series")
+	}
+
+	assert.False(t, err.IsCanceled())
+	assert.Equal(t, http.StatusNotFound, err.Code())
+}
+
+// TestGetReturnsNetworkError ensures failed connections are classified as
+// network errors rather than AO3 being down for maintenance
+func TestGetReturnsNetworkError(t *testing.T) {
+	client, server := newTestClient(t, http.NotFoundHandler())
+	server.Close()
+	client.RetryPolicy = nil
+
+	_, err := client.GetSeries("1")
+	if err == nil {
+		t.Fatal("expected an error from a closed server")
+	}
+
+	assert.Equal(t, KindNetwork, err.Kind())
+	assert.True(t, errors.Is(err, ErrNetwork))
+	assert.False(t, errors.Is(err, ErrMaintenance))
+}
+
+// TestPostIsNotRetried ensures forms are submitted once, as AO3 may have acted
+// on them before failing
+func TestPostIsNotRetried(t *testing.T) {
+	requests := 0
+	client, server := newTestClient(t, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests++
+		assert.Equal(t, "application/x-www-form-urlencoded", r.Header.Get("Content-Type"))
+		assert.Equal(t, "1", r.PostFormValue("kudo[commentable_id]"))
+		w.WriteHeader(http.StatusServiceUnavailable)
+	}))
+	defer server.Close()
+
+	client.RetryPolicy = retryPolicyFunc(func(attempt int, res *http.Response, err error) (time.Duration, bool) {
+		return 0, true
+	})
+
+	_, err := client.post(context.Background(), "/kudos", url.Values{"kudo[commentable_id]": {"1"}}, "leaving kudos")
+	if assert.NotNil(t, err) {
+		assert.Equal(t, KindMaintenance, err.Kind())
+	}
+	assert.Equal(t, 1, requests)
+}
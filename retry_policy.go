@@ -0,0 +1,136 @@
+package ao3
+
+import (
+	"errors"
+	"math/rand"
+	"net"
+	"net/http"
+	"net/url"
+	"time"
+)
+
+// StatusCloudflareSSLHandshakeFailed is returned by Cloudflare in front of AO3
+// while the archive is down for maintenance or restarting
+const StatusCloudflareSSLHandshakeFailed = 525
+
+// RetryPolicy decides whether a failed request should be retried.
+type RetryPolicy interface {
+	// Retry is called after the given attempt (starting at 1) failed, either
+	// with a non-200 response or, if res is nil, with a network error. It
+	// returns whether the request should be retried and how long to wait
+	// before doing so.
+	Retry(attempt int, res *http.Response, err error) (time.Duration, bool)
+}
+
+// BackoffPolicy is a RetryPolicy which retries with exponential backoff and
+// jitter
+type BackoffPolicy struct {
+	// MaxAttempts is the total number of attempts, including the first
+	MaxAttempts int
+
+	// BaseDelay is the delay before the first retry, doubling on each retry up
+	// to MaxDelay. A zero MaxDelay leaves the delay uncapped.
+	BaseDelay time.Duration
+	MaxDelay  time.Duration
+
+	// Jitter is the fraction of each delay which is randomised, between 0 and 1
+	Jitter float64
+
+	// RetryableStatusCodes are the status codes which are retried
+	RetryableStatusCodes []int
+
+	// RetryNetworkErrors determines whether network errors, such as timeouts
+	// and refused connections, are retried
+	RetryNetworkErrors bool
+}
+
+// NewBackoffPolicy returns a BackoffPolicy which makes up to three attempts and
+// retries network errors and AO3's maintenance responses (502, 503, 504 and
+// 525)
+func NewBackoffPolicy() *BackoffPolicy {
+	return &BackoffPolicy{
+		MaxAttempts: 3,
+		BaseDelay:   time.Second,
+		MaxDelay:    30 * time.Second,
+		Jitter:      0.5,
+		RetryableStatusCodes: []int{
+			http.StatusBadGateway,
+			http.StatusServiceUnavailable,
+			http.StatusGatewayTimeout,
+			StatusCloudflareSSLHandshakeFailed,
+		},
+		RetryNetworkErrors: true,
+	}
+}
+
+func (policy *BackoffPolicy) Retry(attempt int, res *http.Response, err error) (time.Duration, bool) {
+	if attempt >= policy.MaxAttempts {
+		return 0, false
+	}
+
+	if res != nil {
+		if !policy.isRetryableStatusCode(res.StatusCode) {
+			return 0, false
+		}
+	} else if !policy.RetryNetworkErrors || !isNetworkError(err) {
+		return 0, false
+	}
+
+	return policy.delay(attempt), true
+}
+
+func (policy *BackoffPolicy) isRetryableStatusCode(code int) bool {
+	for _, retryable := range policy.RetryableStatusCodes {
+		if code == retryable {
+			return true
+		}
+	}
+
+	return false
+}
+
+// delay returns the jittered delay before the retry following attempt
+func (policy *BackoffPolicy) delay(attempt int) time.Duration {
+	delay := policy.BaseDelay
+	for i := 1; i < attempt; i++ {
+		delay *= 2
+		if policy.MaxDelay > 0 && delay >= policy.MaxDelay {
+			break
+		}
+	}
+	if policy.MaxDelay > 0 && delay > policy.MaxDelay {
+		delay = policy.MaxDelay
+	}
+
+	if policy.Jitter > 0 {
+		jitter := float64(delay) * policy.Jitter
+		delay = time.Duration(float64(delay) - jitter + rand.Float64()*jitter)
+	}
+
+	return delay
+}
+
+// isNetworkError reports whether err was caused by the network rather than,
+// for example, a malformed request
+func isNetworkError(err error) bool {
+	if err == nil {
+		return false
+	}
+
+	// http.Client wraps every failure in *url.Error, which implements
+	// net.Error itself, including unsupported schemes and redirect errors.
+	// Only the wrapped error tells whether the network failed.
+	var urlErr *url.Error
+	if errors.As(err, &urlErr) {
+		err = urlErr.Err
+	}
+
+	var netErr net.Error
+	return errors.As(err, &netErr)
+}
+
+// isMaintenanceStatusCode reports whether the status code is one AO3 responds
+// with while it is down for maintenance
+func isMaintenanceStatusCode(code int) bool {
+	return code == http.StatusBadGateway || code == http.StatusServiceUnavailable || code == http.StatusGatewayTimeout || code == StatusCloudflareSSLHandshakeFailed
+}
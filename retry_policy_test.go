@@ -0,0 +1,165 @@
+package ao3
+
+import (
+	"errors"
+	"testing"
+	"time"
+	"net"
+	"net/http"
+	"net/url"
+	"sync/atomic"
+	"github.com/stretchr/testify/assert"
+)
+
+func newTestBackoffPolicy() *BackoffPolicy {
+	policy := NewBackoffPolicy()
+	policy.BaseDelay = 10 * time.Millisecond
+	policy.MaxDelay = 40 * time.Millisecond
+	policy.Jitter = 0
+
+	return policy
+}
+
+func TestBackoffPolicyDelays(t *testing.T) {
+	policy := newTestBackoffPolicy()
+	policy.MaxAttempts = 5
+	res := &http.Response{StatusCode: http.StatusServiceUnavailable}
+
+	expectedDelays := []time.Duration{10 * time.Millisecond, 20 * time.Millisecond, 40 * time.Millisecond, 40 * time.Millisecond}
+	for i, expected := range expectedDelays {
+		delay, ok := policy.Retry(i+1, res, nil)
+		assert.True(t, ok)
+		assert.Equal(t, expected, delay)
+	}
+
+	_, ok := policy.Retry(5, res, nil)
+	assert.False(t, ok)
+}
+
+// TestBackoffPolicyDelaysWithoutMaxDelay ensures the delay keeps doubling when
+// it is not capped
+func TestBackoffPolicyDelaysWithoutMaxDelay(t *testing.T) {
+	policy := newTestBackoffPolicy()
+	policy.MaxAttempts = 5
+	policy.MaxDelay = 0
+	res := &http.Response{StatusCode: http.StatusServiceUnavailable}
+
+	expectedDelays := []time.Duration{10 * time.Millisecond, 20 * time.Millisecond, 40 * time.Millisecond, 80 * time.Millisecond}
+	for i, expected := range expectedDelays {
+		delay, ok := policy.Retry(i+1, res, nil)
+		assert.True(t, ok)
+		assert.Equal(t, expected, delay)
+	}
+}
+
+func TestBackoffPolicyRetryableFailures(t *testing.T) {
+	policy := newTestBackoffPolicy()
+
+	for _, code := range []int{502, 503, 504, 525} {
+		_, ok := policy.Retry(1, &http.Response{StatusCode: code}, nil)
+		assert.True(t, ok, "status code %d", code)
+	}
+
+	for _, code := range []int{400, 403, 404, 422} {
+		_, ok := policy.Retry(1, &http.Response{StatusCode: code}, nil)
+		assert.False(t, ok, "status code %d", code)
+	}
+
+	_, ok := policy.Retry(1, nil, errors.New("not a network error"))
+	assert.False(t, ok)
+}
+
+// TestIsNetworkError ensures only failures of the network are classified as
+// network errors, not every error wrapped by http.Client
+func TestIsNetworkError(t *testing.T) {
+	refused := &net.OpError{Op: "dial", Net: "tcp", Err: errors.New("connection refused")}
+	assert.True(t, isNetworkError(&url.Error{Op: "Get", URL: "https://archiveofourown.org/", Err: refused}))
+	assert.True(t, isNetworkError(&url.Error{Op: "Get", URL: "https://archiveofourown.org/", Err: &net.DNSError{Err: "no such host", Name: "archiveofourown.org"}}))
+
+	assert.False(t, isNetworkError(&url.Error{Op: "Get", URL: "ftp://archiveofourown.org/", Err: errors.New("unsupported protocol scheme \"ftp\"")}))
+	assert.False(t, isNetworkError(&url.Error{Op: "Get", URL: "/users/login", Err: errors.New("stopped after 10 redirects")}))
+	assert.False(t, isNetworkError(nil))
+}
+
+// TestGetRetriesMaintenance ensures maintenance responses are retried until
+// the archive is back
+func TestGetRetriesMaintenance(t *testing.T) {
+	var requests int32
+	client, server := newTestClient(t, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if atomic.AddInt32(&requests, 1) < 3 {
+			w.WriteHeader(http.StatusServiceUnavailable)
+			w.Write([]byte("The Archive is down for maintenance."))
+			return
+		}
+		w.Write([]byte("<ol></ol>"))
+	}))
+	defer server.Close()
+
+	client.RetryPolicy = newTestBackoffPolicy()
+
+	_, err := client.GetFandomCategory("Books%20*a*%20Literature")
+	if err != nil {
+		t.Fatal(err.Error())
+	}
+
+	assert.Equal(t, int32(3), atomic.LoadInt32(&requests))
+}
+
+// TestGetGivesUpAfterMaxAttempts ensures the last failure is returned once the
+// policy stops retrying
+func TestGetGivesUpAfterMaxAttempts(t *testing.T) {
+	var requests int32
+	client, server := newTestClient(t, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		atomic.AddInt32(&requests, 1)
+		w.WriteHeader(StatusCloudflareSSLHandshakeFailed)
+	}))
+	defer server.Close()
+
+	client.RetryPolicy = newTestBackoffPolicy()
+
+	_, err := client.DownloadWork("Co/CodenameCarrot/5191202/A.html")
+	if err == nil {
+		t.Fatal("expected an error once retries are exhausted")
+	}
+
+	assert.Equal(t, StatusCloudflareSSLHandshakeFailed, err.Code())
+	assert.Equal(t, int32(3), atomic.LoadInt32(&requests))
+}
+
+// TestGetRetriesNetworkErrors ensures connection failures are retried
+func TestGetRetriesNetworkErrors(t *testing.T) {
+	client, server := newTestClient(t, http.NotFoundHandler())
+	server.Close()
+
+	var attempts int32
+	policy := newTestBackoffPolicy()
+	client.RetryPolicy = retryPolicyFunc(func(attempt int, res *http.Response, err error) (time.Duration, bool) {
+		atomic.AddInt32(&attempts, 1)
+		return policy.Retry(attempt, res, err)
+	})
+
+	_, err := client.GetWork("1")
+	if err == nil {
+		t.Fatal("expected an error from a closed server")
+	}
+
+	assert.Equal(t, http.StatusServiceUnavailable, err.Code())
+	assert.Equal(t, int32(3), atomic.LoadInt32(&attempts))
+}
+
+type retryPolicyFunc func(attempt int, res *http.Response, err error) (time.Duration, bool)
+
+func (f retryPolicyFunc) Retry(attempt int, res *http.Response, err error) (time.Duration, bool) {
+	return f(attempt, res, err)
+}
+
+// TestMaintenanceStatusCodes ensures every status code retried by default is
+// reported as maintenance
+func TestMaintenanceStatusCodes(t *testing.T) {
+	for _, code := range NewBackoffPolicy().RetryableStatusCodes {
+		assert.True(t, isMaintenanceStatusCode(code), code)
+		assert.True(t, errors.Is(NewError(code, "fetching work failed"), ErrMaintenance), code)
+	}
+
+	assert.False(t, isMaintenanceStatusCode(http.StatusInternalServerError))
+}
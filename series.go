@@ -1,6 +1,8 @@
 package ao3
 
 import (
+	"bytes"
+	"context"
 	"net/http"
 	"github.com/PuerkitoBio/goquery"
 	"strings"
@@ -23,56 +25,79 @@ type Series struct {
 	Bookmarks   int
 
 	Works []IndexedWork
+
+	// Diagnostics describes the fields of the page which could not be parsed
+	// in lenient mode. Diagnostics of individual works are kept in the works.
+	Diagnostics []ParseDiagnostic
 }
 
 // GetSeries returns the metadata and works for a series.
 //
 // Endpoint: https://archiveofourown.org/series/[series]
 func (client *AO3Client) GetSeries(id string) (*Series, *AO3Error) {
+	return client.GetSeriesWithContext(context.Background(), id)
+}
+
+// GetSeriesWithContext is GetSeries with a context which cancels the request
+// when it is done
+func (client *AO3Client) GetSeriesWithContext(ctx context.Context, id string) (*Series, *AO3Error) {
 	endpoint := "/series/" + id
 
-	res, err := client.HttpClient.Get(baseURL + endpoint)
-	if err != nil {
-		return nil, WrapError(http.StatusServiceUnavailable, err, "unable to fetch series")
-	}
-	defer res.Body.Close()
-	if res.StatusCode != http.StatusOK {
-		return nil, NewError(res.StatusCode, "fetching series returned a non-200 status code")
+	body, ao3Err := client.get(ctx, endpoint, client.CacheTTLs.Series, "fetching series")
+	if ao3Err != nil {
+		return nil, ao3Err
 	}
 
-	doc, err := goquery.NewDocumentFromReader(res.Body)
+	doc, err := goquery.NewDocumentFromReader(bytes.NewReader(body))
 	if err != nil {
 		return nil, WrapError(http.StatusUnprocessableEntity, err, "unable to parse series page")
 	}
 
 	var series Series
+	state := client.newParseState(client.endpointURL(endpoint), client.Lenient)
 
 	// Extract title
 	titleMatches := doc.Find("div#main > h2.heading")
 	if len(titleMatches.Nodes) != 1 {
-		return nil, NewError(http.StatusUnprocessableEntity, "unable to match title node")
+		ao3Err := NewError(http.StatusUnprocessableEntity, "unable to match title node")
+		if state.fail("Title", "div#main > h2.heading", doc.Find("div#main"), ao3Err) {
+			return nil, ao3Err
+		}
+	} else {
+		series.Title = strings.TrimSpace(titleMatches.First().Text())
 	}
-	series.Title = strings.TrimSpace(titleMatches.First().Text())
 
 	// Extract metadata nodes, ensuring that the number of nodes are even and
 	// composed of <dt> tags each directly followed by a <dd> tag.
 	metadataMatches := doc.Find("dl.series.meta.group")
-	if len(metadataMatches.Nodes) != 1 {
-		return nil, NewError(http.StatusUnprocessableEntity, "unable to match metadata node")
-	}
-
 	metadataNodes := metadataMatches.Children()
-	if len(metadataNodes.Nodes)%2 == 1 {
-		return nil, NewError(http.StatusUnprocessableEntity, "unable to match metadata nodes")
+	if len(metadataMatches.Nodes) != 1 {
+		ao3Err := NewError(http.StatusUnprocessableEntity, "unable to match metadata node")
+		if state.fail("Metadata", "dl.series.meta.group", doc.Find("div#main"), ao3Err) {
+			return nil, ao3Err
+		}
+		metadataNodes = metadataNodes.Slice(0, 0)
+	} else if len(metadataNodes.Nodes)%2 == 1 {
+		ao3Err := NewError(http.StatusUnprocessableEntity, "unable to match metadata nodes")
+		if state.fail("Metadata", "dl.series.meta.group", metadataMatches, ao3Err) {
+			return nil, ao3Err
+		}
+		metadataNodes = metadataNodes.Slice(0, 0)
 	}
 
+	// In lenient mode, the label of a pair which could not be parsed, e.g.
+	// "Creator:", is used as the field of its diagnostic
 	for i := 0; i < len(metadataNodes.Nodes); i += 2 {
 		dtNode := metadataNodes.Eq(i)
 		ddNode := metadataNodes.Eq(i + 1)
 
 		err := client.parseDescriptionList(dtNode, ddNode, &series)
 		if err != nil {
-			return nil, WrapError(http.StatusUnprocessableEntity, err, "unable to parse metadata node")
+			ao3Err := WrapError(http.StatusUnprocessableEntity, err, "unable to parse metadata node")
+			field := strings.TrimSuffix(strings.TrimSpace(dtNode.Text()), ":")
+			if state.fail(field, "dl.series.meta.group > dd", ddNode, ao3Err) {
+				return nil, ao3Err
+			}
 		}
 	}
 
@@ -84,7 +109,7 @@ func (client *AO3Client) GetSeries(id string) (*Series, *AO3Error) {
 	for i := range workMatches.Nodes {
 		node := workMatches.Eq(i)
 
-		work, err := client.parseIndexedWorkNode(node)
+		work, err := client.parseIndexedWorkNode(state.rawURL, node)
 		if err != nil {
 			return nil, WrapError(http.StatusUnprocessableEntity, err, "parsing series work failed")
 		}
@@ -92,6 +117,12 @@ func (client *AO3Client) GetSeries(id string) (*Series, *AO3Error) {
 		series.Works = append(series.Works, *work)
 	}
 
+	if series.Works, ao3Err = client.hideMutedWorks(ctx, state, series.Works); ao3Err != nil {
+		return nil, ao3Err
+	}
+
+	series.Diagnostics = state.diagnostics
+
 	return &series, nil
 }
 
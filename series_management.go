@@ -0,0 +1,315 @@
+package ao3
+
+import (
+	"context"
+	"net/http"
+	"net/url"
+	"regexp"
+	"strconv"
+	"github.com/PuerkitoBio/goquery"
+)
+
+var seriesLinkRegex = regexp.MustCompile(`^/series/(\d+)`)
+var serialWorkIDRegex = regexp.MustCompile(`^serial_work_(\d+)$`)
+
+// seriesFieldRules attributes AO3's validation messages about series to the
+// fields of SeriesOptions
+var seriesFieldRules = []fieldRule{
+	{regexp.MustCompile(`(?i)^title`), []string{"Title"}},
+	{regexp.MustCompile(`(?i)^(?:summary|description)`), []string{"Description"}},
+	{regexp.MustCompile(`(?i)^(?:series )?notes`), []string{"Notes"}},
+}
+
+// SeriesOptions describes a series to create, or the new state of one to
+// edit. The description and notes are in HTML, sanitized with the client's
+// HtmlSanitizer before they are submitted.
+type SeriesOptions struct {
+	Title       string
+	Description string
+	Notes       string
+	IsComplete  bool
+}
+
+// CreateSeries creates an empty series as the logged-in user's default pseud
+// and returns its ID. Series AO3 rejects return an error matching
+// ErrValidation which wraps a ValidationError whose Fields are keyed by the
+// names of SeriesOptions' fields.
+//
+// Endpoint: https://archiveofourown.org/series
+func (client *AO3Client) CreateSeries(options SeriesOptions) (string, *AO3Error) {
+	return client.CreateSeriesWithContext(context.Background(), options)
+}
+
+// CreateSeriesWithContext is CreateSeries with a context which cancels the
+// requests when it is done
+func (client *AO3Client) CreateSeriesWithContext(ctx context.Context, options SeriesOptions) (string, *AO3Error) {
+	if ao3Err := client.requireLogin("creating series"); ao3Err != nil {
+		return "", ao3Err
+	}
+
+	doc, ao3Err := client.fetchWorkForm(ctx, "/series/new", "series[title]", "fetching series form")
+	if ao3Err != nil {
+		return "", ao3Err
+	}
+
+	pseudID := formPseudID(doc, "series[author_attributes][ids][]")
+	if pseudID == "" {
+		return "", NewError(http.StatusUnprocessableEntity, "unable to find pseud in series form")
+	}
+
+	form := options.form(client.HtmlSanitizer)
+	form.Set("series[author_attributes][ids][]", pseudID)
+	form.Set("commit", "Submit")
+
+	res, ao3Err := client.submit(ctx, "/series", form, "creating series")
+	if ao3Err != nil {
+		return "", ao3Err
+	}
+
+	if _, ao3Err := checkSubmission(res.body, "creating series"); ao3Err != nil {
+		return "", attributeFields(ao3Err, seriesFieldRules)
+	}
+
+	return client.postedID(res, seriesLinkRegex, "series", "creating series")
+}
+
+// EditSeries replaces the title, description, notes and completion of one of
+// the logged-in user's series with options, like CreateSeries. The series'
+// other settings, such as its creators, are submitted unchanged.
+//
+// Endpoint: https://archiveofourown.org/series/[series]
+func (client *AO3Client) EditSeries(id string, options SeriesOptions) *AO3Error {
+	return client.EditSeriesWithContext(context.Background(), id, options)
+}
+
+// EditSeriesWithContext is EditSeries with a context which cancels the
+// requests when it is done
+func (client *AO3Client) EditSeriesWithContext(ctx context.Context, id string, options SeriesOptions) *AO3Error {
+	if ao3Err := client.requireLogin("editing series"); ao3Err != nil {
+		return ao3Err
+	}
+
+	doc, ao3Err := client.fetchWorkForm(ctx, "/series/"+id+"/edit", "series[title]", "fetching series form")
+	if ao3Err != nil {
+		return ao3Err
+	}
+
+	// The rest of the form, such as the series' creators, is sent back as is
+	form := formValues(doc.Find(`[name="series[title]"]`).Closest("form"))
+	for name, values := range options.form(client.HtmlSanitizer) {
+		form[name] = values
+	}
+	form.Set("_method", "patch")
+	form.Set("commit", "Update")
+
+	res, ao3Err := client.submit(ctx, "/series/"+id, form, "editing series")
+	if ao3Err != nil {
+		return ao3Err
+	}
+	client.invalidate("/series/" + id)
+
+	_, ao3Err = checkSubmission(res.body, "editing series")
+	return attributeFields(ao3Err, seriesFieldRules)
+}
+
+// AddWorkToSeries adds one of the logged-in user's works to the end of one of
+// their series. The work's other metadata is submitted unchanged.
+//
+// Endpoint: https://archiveofourown.org/works/[work]
+func (client *AO3Client) AddWorkToSeries(workID string, seriesID string) *AO3Error {
+	return client.AddWorkToSeriesWithContext(context.Background(), workID, seriesID)
+}
+
+// AddWorkToSeriesWithContext is AddWorkToSeries with a context which cancels
+// the requests when it is done
+func (client *AO3Client) AddWorkToSeriesWithContext(ctx context.Context, workID string, seriesID string) *AO3Error {
+	if ao3Err := client.requireLogin("adding work to series"); ao3Err != nil {
+		return ao3Err
+	}
+
+	doc, ao3Err := client.fetchWorkForm(ctx, workEditEndpoint(workID), "work[title]", "fetching work form")
+	if ao3Err != nil {
+		return ao3Err
+	}
+
+	form := formValues(doc.Find(`[name="work[title]"]`).Closest("form"))
+	form.Del("work[series_attributes][title]")
+	form.Set("work[series_attributes][id]", seriesID)
+	form.Set("_method", "patch")
+	form.Set("update_button", "Update")
+
+	res, ao3Err := client.submit(ctx, "/works/"+workID, form, "adding work to series")
+	if ao3Err != nil {
+		return ao3Err
+	}
+	client.invalidate("/series/" + seriesID)
+	client.invalidate(workEndpoint(workID))
+
+	_, ao3Err = checkSubmission(res.body, "adding work to series")
+	return ao3Err
+}
+
+// RemoveWorkFromSeries removes one of the logged-in user's works from one of
+// their series. The work itself is not deleted.
+//
+// Endpoint: https://archiveofourown.org/serial_works/[serial work]
+func (client *AO3Client) RemoveWorkFromSeries(workID string, seriesID string) *AO3Error {
+	return client.RemoveWorkFromSeriesWithContext(context.Background(), workID, seriesID)
+}
+
+// RemoveWorkFromSeriesWithContext is RemoveWorkFromSeries with a context which
+// cancels the requests when it is done
+func (client *AO3Client) RemoveWorkFromSeriesWithContext(ctx context.Context, workID string, seriesID string) *AO3Error {
+	if ao3Err := client.requireLogin("removing work from series"); ao3Err != nil {
+		return ao3Err
+	}
+
+	serialWorks, ao3Err := client.getSerialWorks(ctx, seriesID)
+	if ao3Err != nil {
+		return ao3Err
+	}
+
+	serialWorkID := ""
+	for _, serialWork := range serialWorks {
+		if serialWork.workID == workID {
+			serialWorkID = serialWork.id
+			break
+		}
+	}
+	if serialWorkID == "" {
+		return NewError(http.StatusNotFound, "work "+workID+" is not part of series "+seriesID)
+	}
+
+	form := url.Values{}
+	form.Set("_method", "delete")
+
+	res, ao3Err := client.submit(ctx, "/serial_works/"+serialWorkID, form, "removing work from series")
+	if ao3Err != nil {
+		return ao3Err
+	}
+	client.invalidate("/series/" + seriesID)
+	client.invalidate(workEndpoint(workID))
+
+	_, ao3Err = checkSubmission(res.body, "removing work from series")
+	return ao3Err
+}
+
+// ReorderSeries reorders the works of one of the logged-in user's series.
+// workIDs are the IDs of the works in their new order; works of the series
+// which are not listed keep their relative order after them.
+//
+// Endpoint: https://archiveofourown.org/serial_works/update_positions
+func (client *AO3Client) ReorderSeries(seriesID string, workIDs []string) *AO3Error {
+	return client.ReorderSeriesWithContext(context.Background(), seriesID, workIDs)
+}
+
+// ReorderSeriesWithContext is ReorderSeries with a context which cancels the
+// requests when it is done
+func (client *AO3Client) ReorderSeriesWithContext(ctx context.Context, seriesID string, workIDs []string) *AO3Error {
+	if ao3Err := client.requireLogin("reordering series"); ao3Err != nil {
+		return ao3Err
+	}
+
+	serialWorks, ao3Err := client.getSerialWorks(ctx, seriesID)
+	if ao3Err != nil {
+		return ao3Err
+	}
+
+	serialWorkIDs := map[string]string{}
+	for _, serialWork := range serialWorks {
+		serialWorkIDs[serialWork.workID] = serialWork.id
+	}
+
+	form := url.Values{}
+	positioned := map[string]bool{}
+	position := 1
+	for _, workID := range workIDs {
+		serialWorkID, ok := serialWorkIDs[workID]
+		if !ok {
+			return NewError(http.StatusNotFound, "work "+workID+" is not part of series "+seriesID)
+		}
+		if positioned[workID] {
+			continue
+		}
+		form.Set("serial_works["+serialWorkID+"]", strconv.Itoa(position))
+		positioned[workID] = true
+		position++
+	}
+	for _, serialWork := range serialWorks {
+		if !positioned[serialWork.workID] {
+			form.Set("serial_works["+serialWork.id+"]", strconv.Itoa(position))
+			position++
+		}
+	}
+
+	res, ao3Err := client.submit(ctx, "/serial_works/update_positions", form, "reordering series")
+	if ao3Err != nil {
+		return ao3Err
+	}
+	client.invalidate("/series/" + seriesID)
+
+	_, ao3Err = checkSubmission(res.body, "reordering series")
+	return ao3Err
+}
+
+// serialWork is the membership of a work in a series
+type serialWork struct {
+	id     string
+	workID string
+}
+
+// getSerialWorks returns the works of a series in order from the page its
+// creators manage it on
+func (client *AO3Client) getSerialWorks(ctx context.Context, seriesID string) ([]serialWork, *AO3Error) {
+	doc, ao3Err := client.fetchForm(ctx, "/series/"+seriesID+"/manage", "fetching series works")
+	if ao3Err != nil {
+		return nil, ao3Err
+	}
+
+	serialWorks := []serialWork{}
+	var parseErr *AO3Error
+	doc.Find(`li[id^="serial_work_"]`).EachWithBreak(func(_ int, node *goquery.Selection) bool {
+		nodeID, _ := node.Attr("id")
+		idMatches := serialWorkIDRegex.FindStringSubmatch(nodeID)
+
+		workID := ""
+		node.Find("a[href]").EachWithBreak(func(_ int, linkNode *goquery.Selection) bool {
+			link, _ := linkNode.Attr("href")
+			if matches := postedWorkLinkRegex.FindStringSubmatch(client.relativeLink(link)); len(matches) == 2 {
+				workID = matches[1]
+				return false
+			}
+			return true
+		})
+
+		if len(idMatches) != 2 || workID == "" {
+			parseErr = NewError(http.StatusUnprocessableEntity, "unable to parse work of series "+seriesID)
+			return false
+		}
+
+		serialWorks = append(serialWorks, serialWork{id: idMatches[1], workID: workID})
+		return true
+	})
+	if parseErr != nil {
+		return nil, parseErr
+	}
+
+	if len(serialWorks) == 0 {
+		if ao3Err := validationError(doc, "fetching series works"); ao3Err != nil {
+			return nil, ao3Err
+		}
+	}
+
+	return serialWorks, nil
+}
+
+// form encodes the options as the fields of AO3's series form
+func (options SeriesOptions) form(sanitizer *Sanitizer) url.Values {
+	form := url.Values{}
+	form.Set("series[title]", options.Title)
+	form.Set("series[summary]", sanitizer.Sanitize(options.Description))
+	form.Set("series[series_notes]", sanitizer.Sanitize(options.Notes))
+	form.Set("series[complete]", formBool(options.IsComplete))
+
+	return form
+}
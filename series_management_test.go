@@ -0,0 +1,205 @@
+package ao3
+
+import (
+	"errors"
+	"net/http"
+	"net/url"
+	"testing"
+	"github.com/stretchr/testify/assert"
+)
+
+const testWorkEditForm = `<form id="work-form" action="/works/10" method="post">
+<input type="hidden" name="_method" value="patch" />
+<input type="hidden" name="authenticity_token" value="test-token" />
+<select name="work[author_attributes][ids][]" multiple="multiple"><option value="9" selected="selected">reader</option><option value="10">alt</option></select>
+<input type="text" name="work[title]" value="Part One" />
+<input type="text" name="work[fandom_string]" value="Original Work" />
+<select name="work[rating_string]"><option value="Not Rated">Not Rated</option><option value="General Audiences" selected="selected">General Audiences</option></select>
+<input type="hidden" name="work[archive_warning_strings][]" value="" />
+<input type="checkbox" name="work[archive_warning_strings][]" value="No Archive Warnings Apply" checked="checked" />
+<input type="checkbox" name="work[archive_warning_strings][]" value="Graphic Depictions Of Violence" />
+<select name="work[language_id]"><option value="1" selected="selected">English</option></select>
+<select name="work[series_attributes][id]"><option value=""></option><option value="5">A Series</option></select>
+<input type="text" name="work[series_attributes][title]" />
+<textarea name="work[summary]">A summary</textarea>
+<input type="submit" name="preview_button" value="Preview" />
+<input type="submit" name="update_button" value="Update" />
+</form>`
+
+// newSeriesServer returns a client pointed at a fake AO3 on which "reader"
+// owns series 5, made up of works 10, 11 and 12, and work 13. The forms it
+// receives are recorded.
+func newSeriesServer(t *testing.T) (*AO3Client, map[string]url.Values, func()) {
+	title := "A Series"
+	seriesOfWork13 := ""
+
+	client, forms, closeServer := newTestArchive(t, false, func(w http.ResponseWriter, r *http.Request) {
+		switch {
+		case r.URL.Path == "/series/new":
+			writeTestPage(w, `<form action="/series" method="post"><input type="text" name="series[title]" /><select name="series[author_attributes][ids][]" multiple="multiple"><option value="9" selected="selected">reader</option></select></form>`)
+
+		case r.URL.Path == "/series" && r.Method == http.MethodPost:
+			if r.PostFormValue("series[title]") == "" {
+				writeTestPage(w, `<div id="error" class="error"><ul><li>Title can't be blank</li></ul></div>`)
+				return
+			}
+			http.Redirect(w, r, "/series/5", http.StatusFound)
+
+		case r.URL.Path == "/series/5/edit":
+			writeTestPage(w, `<form action="/series/5" method="post"><input type="hidden" name="_method" value="patch" /><input type="text" name="series[title]" value="`+title+`" />
+<select name="series[author_attributes][ids][]" multiple="multiple"><option value="9" selected="selected">reader</option><option value="12" selected="selected">cocreator</option></select>
+<input type="hidden" name="series[complete]" value="0" /><input type="checkbox" name="series[complete]" value="1" />
+<input type="submit" name="commit" value="Update" /></form>`)
+
+		case r.URL.Path == "/series/5" && r.Method == http.MethodPost:
+			title = r.PostFormValue("series[title]")
+			http.Redirect(w, r, "/series/5", http.StatusFound)
+
+		case r.URL.Path == "/series/5":
+			writeTestPage(w, `<h2 class="heading">`+title+`</h2>`)
+
+		case r.URL.Path == "/series/5/manage":
+			writeTestPage(w, `<ul id="sortable_series_list">
+<li id="serial_work_71"><a href="/works/10">Part One</a></li>
+<li id="serial_work_72"><a href="/works/11">Part Two</a></li>
+<li id="serial_work_73"><a href="/works/12">Part Three</a></li>
+</ul>`)
+
+		case r.URL.Path == "/works/13/edit":
+			writeTestPage(w, testWorkEditForm)
+
+		case r.URL.Path == "/works/13" && r.Method == http.MethodPost:
+			seriesOfWork13 = r.PostFormValue("work[series_attributes][id]")
+			http.Redirect(w, r, "/works/13", http.StatusFound)
+
+		case r.URL.Path == "/works/13" && r.URL.Query().Get("view_adult") == "true":
+			series := ""
+			if seriesOfWork13 != "" {
+				series = `<dd class="series"><span class="series"><span class="position">Part 4 of the <a href="/series/` + seriesOfWork13 + `">` + title + `</a> series</span></span></dd>`
+			}
+			writeTestPage(w, `<dl class="work meta group"><dd class="language">English</dd>`+series+`</dl>
+<ul class="work navigation actions"><li class="download"><ul class="expandable secondary"><li><a href="/downloads/13/Work.html?updated_at=1">HTML</a></li></ul></li></ul>
+<div id="workskin"><div class="preface group"><h2 class="title heading">Part Four</h2><h3 class="byline heading"><a rel="author" href="/users/reader/pseuds/reader">reader</a></h3></div></div>`)
+
+		case r.URL.Path == "/serial_works/update_positions" || r.URL.Path == "/serial_works/72":
+			http.Redirect(w, r, "/series/5", http.StatusFound)
+
+		case r.URL.Path == "/works/13":
+			writeTestPage(w, `<div class="flash notice">Work was successfully updated.</div>`)
+
+		default:
+			http.NotFound(w, r)
+		}
+	})
+
+	return client, forms, closeServer
+}
+
+// TestCreateAndEditSeries ensures series are created and edited, and edits are
+// seen by GetSeries despite the cache
+func TestCreateAndEditSeries(t *testing.T) {
+	client, forms, closeServer := newSeriesServer(t)
+	defer closeServer()
+
+	client.Cache = NewMemoryCache(10)
+	client.Lenient = true
+	importTestSession(t, client, "reader")
+
+	id, err := client.CreateSeries(SeriesOptions{Title: "A Series", Description: `<p onclick="x()">About</p>`})
+	if err != nil {
+		t.Fatal(err.Error())
+	}
+	assert.Equal(t, "5", id)
+	assert.Equal(t, "9", forms[" /series"].Get("series[author_attributes][ids][]"))
+	assert.Equal(t, "<p>About</p>", forms[" /series"].Get("series[summary]"))
+	assert.Equal(t, "0", forms[" /series"].Get("series[complete]"))
+
+	_, err = client.CreateSeries(SeriesOptions{})
+	if assert.NotNil(t, err) {
+		var validation *ValidationError
+		if assert.True(t, errors.As(err, &validation)) {
+			assert.Equal(t, []string{"Title can't be blank"}, validation.Fields["Title"])
+		}
+	}
+
+	series, err := client.GetSeries("5")
+	if err != nil {
+		t.Fatal(err.Error())
+	}
+	assert.Equal(t, "A Series", series.Title)
+
+	if err := client.EditSeries("5", SeriesOptions{Title: "A Finished Series", IsComplete: true}); err != nil {
+		t.Fatal(err.Error())
+	}
+	assert.Equal(t, "1", forms["patch /series/5"].Get("series[complete]"))
+	assert.Equal(t, []string{"1"}, forms["patch /series/5"]["series[complete]"])
+	assert.Equal(t, []string{"9", "12"}, forms["patch /series/5"]["series[author_attributes][ids][]"])
+	assert.Equal(t, "Update", forms["patch /series/5"].Get("commit"))
+
+	series, err = client.GetSeries("5")
+	if err != nil {
+		t.Fatal(err.Error())
+	}
+	assert.Equal(t, "A Finished Series", series.Title)
+}
+
+// TestSeriesWorks ensures works are added, removed and reordered, keeping the
+// rest of the work form unchanged, and that GetWork sees the work's new series
+// despite the cache
+func TestSeriesWorks(t *testing.T) {
+	client, forms, closeServer := newSeriesServer(t)
+	defer closeServer()
+
+	client.Cache = NewMemoryCache(10)
+	importTestSession(t, client, "reader")
+
+	work, err := client.GetWork("13")
+	if err != nil {
+		t.Fatal(err.Error())
+	}
+	assert.False(t, work.IsSeries)
+
+	if err := client.AddWorkToSeries("13", "5"); err != nil {
+		t.Fatal(err.Error())
+	}
+
+	work, err = client.GetWork("13")
+	if err != nil {
+		t.Fatal(err.Error())
+	}
+	assert.True(t, work.IsSeries)
+	assert.Equal(t, Link{Text: "A Series", Slug: "5"}, work.Series)
+	form := forms["patch /works/13"]
+	assert.Equal(t, "5", form.Get("work[series_attributes][id]"))
+	assert.Equal(t, "Part One", form.Get("work[title]"))
+	assert.Equal(t, "General Audiences", form.Get("work[rating_string]"))
+	assert.Equal(t, []string{"", "No Archive Warnings Apply"}, form["work[archive_warning_strings][]"])
+	assert.Equal(t, []string{"9"}, form["work[author_attributes][ids][]"])
+	assert.Equal(t, "A summary", form.Get("work[summary]"))
+	assert.Equal(t, "Update", form.Get("update_button"))
+	assert.NotContains(t, form, "preview_button")
+	assert.NotContains(t, form, "work[series_attributes][title]")
+
+	if err := client.ReorderSeries("5", []string{"12", "10"}); err != nil {
+		t.Fatal(err.Error())
+	}
+	form = forms[" /serial_works/update_positions"]
+	assert.Equal(t, "1", form.Get("serial_works[73]"))
+	assert.Equal(t, "2", form.Get("serial_works[71]"))
+	assert.Equal(t, "3", form.Get("serial_works[72]"))
+
+	if err := client.RemoveWorkFromSeries("11", "5"); err != nil {
+		t.Fatal(err.Error())
+	}
+	assert.Contains(t, forms, "delete /serial_works/72")
+
+	err = client.RemoveWorkFromSeries("13", "5")
+	if assert.NotNil(t, err) {
+		assert.True(t, errors.Is(err, ErrNotFound))
+	}
+
+	err = client.ReorderSeries("5", []string{"13"})
+	if assert.NotNil(t, err) {
+		assert.True(t, errors.Is(err, ErrNotFound))
+	}
+}
@@ -34,10 +34,7 @@ func TestGetSeries(t *testing.T) {
 	const expectedMinBookmarks = 4
 
 	// Fetch the work
-	client, err := InitAO3Client(nil, AO3Policy)
-	if err != nil {
-		t.Fatal(err.Error())
-	}
+	client := newFixtureClient(t)
 
 	series, err := client.GetSeries(seriesId)
 	if err != nil {
@@ -93,10 +90,7 @@ func TestGetSeriesWithMultipleCreators(t *testing.T) {
 	}
 
 	// Fetch the work
-	client, err := InitAO3Client(nil, AO3Policy)
-	if err != nil {
-		t.Fatal(err.Error())
-	}
+	client := newFixtureClient(t)
 
 	series, err := client.GetSeries(seriesId)
 	if err != nil {
@@ -111,10 +105,7 @@ func TestGetSeriesWithAnonymousCreator(t *testing.T) {
 	const seriesId = "258439"
 
 	// Fetch the work
-	client, err := InitAO3Client(nil, AO3Policy)
-	if err != nil {
-		t.Fatal(err.Error())
-	}
+	client := newFixtureClient(t)
 
 	series, err := client.GetSeries(seriesId)
 	if err != nil {
@@ -0,0 +1,336 @@
+package ao3
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"io/ioutil"
+	"net/http"
+	"net/url"
+	"strings"
+	"github.com/PuerkitoBio/goquery"
+)
+
+// sessionVersion is the version of the format written by ExportSession
+const sessionVersion = 1
+
+// logoutEndpoint receives the logout form
+const logoutEndpoint = "/users/logout"
+
+// exportedSession is the format written by ExportSession
+type exportedSession struct {
+	Version   int              `json:"version"`
+	BaseURL   string           `json:"base_url"`
+	Username  string           `json:"username"`
+	CSRFToken string           `json:"csrf_token,omitempty"`
+	Cookies   []exportedCookie `json:"cookies"`
+}
+
+type exportedCookie struct {
+	Name  string `json:"name"`
+	Value string `json:"value"`
+}
+
+// Username returns the name of the user the client is logged in as, or an empty
+// string if it has not been authenticated
+func (client *AO3Client) Username() string {
+	client.sessionMu.RLock()
+	defer client.sessionMu.RUnlock()
+
+	return client.username
+}
+
+// ExportSession serialises the client's AO3 session, i.e. its cookies, user
+// name and authenticity token, so that it can be restored into another client
+// with ImportSession. The blob is opaque and contains credentials, so it must
+// be stored securely.
+func (client *AO3Client) ExportSession() ([]byte, *AO3Error) {
+	if ao3Err := client.requireCookieJar("exporting session"); ao3Err != nil {
+		return nil, ao3Err
+	}
+
+	client.sessionMu.RLock()
+	session := exportedSession{
+		Version:   sessionVersion,
+		BaseURL:   client.BaseURL(),
+		Username:  client.username,
+		CSRFToken: client.csrfToken,
+		Cookies:   []exportedCookie{},
+	}
+	client.sessionMu.RUnlock()
+
+	for _, cookie := range client.HttpClient.Jar.Cookies(client.baseURL) {
+		session.Cookies = append(session.Cookies, exportedCookie{Name: cookie.Name, Value: cookie.Value})
+	}
+
+	data, err := json.Marshal(session)
+	if err != nil {
+		return nil, WrapError(http.StatusInternalServerError, err, "unable to encode session")
+	}
+
+	return data, nil
+}
+
+// ImportSession restores a session exported by ExportSession into the client's
+// cookie jar. The session must have been exported from a client with the same
+// base URL. Use IsLoggedIn to check that it has not
+// expired.
+func (client *AO3Client) ImportSession(data []byte) *AO3Error {
+	var session exportedSession
+	if err := json.Unmarshal(data, &session); err != nil {
+		return WrapError(http.StatusBadRequest, err, "unable to decode session")
+	}
+
+	if session.Version != sessionVersion {
+		return NewError(http.StatusBadRequest, "unsupported session version")
+	}
+	if session.BaseURL != client.BaseURL() {
+		return NewError(http.StatusBadRequest, "session was exported from "+session.BaseURL+" rather than "+client.BaseURL())
+	}
+
+	if ao3Err := client.requireCookieJar("importing session"); ao3Err != nil {
+		return ao3Err
+	}
+
+	cookies := make([]*http.Cookie, 0, len(session.Cookies))
+	for _, cookie := range session.Cookies {
+		cookies = append(cookies, &http.Cookie{Name: cookie.Name, Value: cookie.Value, Path: "/"})
+	}
+	client.HttpClient.Jar.SetCookies(client.baseURL, cookies)
+
+	client.setSession(session.Username, session.CSRFToken)
+
+	return nil
+}
+
+// SaveSession writes the exported session to a file readable only by its owner
+func (client *AO3Client) SaveSession(path string) *AO3Error {
+	data, ao3Err := client.ExportSession()
+	if ao3Err != nil {
+		return ao3Err
+	}
+
+	if err := ioutil.WriteFile(path, data, 0600); err != nil {
+		return WrapError(http.StatusInternalServerError, err, "unable to write session file")
+	}
+
+	return nil
+}
+
+// LoadSession imports a session written by SaveSession
+func (client *AO3Client) LoadSession(path string) *AO3Error {
+	data, err := ioutil.ReadFile(path)
+	if err != nil {
+		return WrapError(http.StatusBadRequest, err, "unable to read session file")
+	}
+
+	return client.ImportSession(data)
+}
+
+// IsLoggedIn checks whether the client's session is still valid by inspecting
+// the header of the home page. The user name and authenticity token are
+// refreshed from the page, or cleared if the session has expired.
+//
+// Endpoint: https://archiveofourown.org/
+func (client *AO3Client) IsLoggedIn() (bool, *AO3Error) {
+	return client.IsLoggedInWithContext(context.Background())
+}
+
+// IsLoggedInWithContext is IsLoggedIn with a context which cancels the request
+// when it is done
+func (client *AO3Client) IsLoggedInWithContext(ctx context.Context) (bool, *AO3Error) {
+	body, ao3Err := client.get(ctx, "/", 0, "checking session")
+	if ao3Err != nil {
+		return false, ao3Err
+	}
+
+	doc, err := goquery.NewDocumentFromReader(bytes.NewReader(body))
+	if err != nil {
+		return false, WrapError(http.StatusUnprocessableEntity, err, "parsing home page with goquery failed")
+	}
+
+	if !isLoggedIn(doc) {
+		client.setSession("", "")
+		return false, nil
+	}
+
+	username := client.loggedInUsername(doc)
+	if username == "" {
+		username = client.Username()
+	}
+	client.setSession(username, csrfToken(doc))
+
+	return true, nil
+}
+
+// Logout ends the client's session on AO3 and removes its cookies
+//
+// Endpoint: https://archiveofourown.org/users/logout
+func (client *AO3Client) Logout() *AO3Error {
+	return client.LogoutWithContext(context.Background())
+}
+
+// LogoutWithContext is Logout with a context which cancels the request when it
+// is done
+func (client *AO3Client) LogoutWithContext(ctx context.Context) *AO3Error {
+	if client.HttpClient.Jar == nil {
+		client.setSession("", "")
+		return nil
+	}
+
+	form := url.Values{}
+	form.Set("_method", "delete")
+
+	if _, ao3Err := client.submit(ctx, logoutEndpoint, form, "logging out"); ao3Err != nil {
+		return ao3Err
+	}
+
+	// AO3 replaces the session cookie with an anonymous one, but any other
+	// cookies, such as "remember me", are expired too
+	expired := []*http.Cookie{}
+	for _, cookie := range client.HttpClient.Jar.Cookies(client.baseURL) {
+		expired = append(expired, &http.Cookie{Name: cookie.Name, Path: "/", MaxAge: -1})
+	}
+	client.HttpClient.Jar.SetCookies(client.baseURL, expired)
+
+	client.setSession("", "")
+
+	return nil
+}
+
+// submit posts a form with the session's authenticity token, fetching a token
+// if the client has none. If AO3 rejects the token as expired, a fresh token
+// is fetched and the form is submitted once more.
+func (client *AO3Client) submit(ctx context.Context, endpoint string, form url.Values, action string) (*response, *AO3Error) {
+	token, ao3Err := client.authenticityToken(ctx)
+	if ao3Err != nil {
+		return nil, ao3Err
+	}
+
+	form.Set("authenticity_token", token)
+	res, ao3Err := client.post(ctx, endpoint, form, action)
+	if ao3Err != nil && ao3Err.Code() == http.StatusUnprocessableEntity && ao3Err.Kind() == KindUnknown {
+		client.setCSRFToken("")
+
+		token, ao3Err = client.authenticityToken(ctx)
+		if ao3Err != nil {
+			return nil, ao3Err
+		}
+
+		form.Set("authenticity_token", token)
+		res, ao3Err = client.post(ctx, endpoint, form, action)
+	}
+
+	return res, ao3Err
+}
+
+// fetchForm fetches a page containing a form bypassing the cache, keeping its
+// authenticity token as the session's so that the form can be submitted
+func (client *AO3Client) fetchForm(ctx context.Context, endpoint string, action string) (*goquery.Document, *AO3Error) {
+	body, ao3Err := client.get(ctx, endpoint, 0, action)
+	if ao3Err != nil {
+		return nil, ao3Err
+	}
+
+	doc, err := goquery.NewDocumentFromReader(bytes.NewReader(body))
+	if err != nil {
+		return nil, WrapError(http.StatusUnprocessableEntity, err, "parsing form with goquery failed")
+	}
+
+	if token := csrfToken(doc); token != "" {
+		client.setCSRFToken(token)
+	}
+
+	return doc, nil
+}
+
+// formValues returns the values a browser would submit with a form as it was
+// served: its checked boxes, selected options, text fields and hidden fields.
+// Buttons are left out so that the caller can choose which one to submit.
+func formValues(form *goquery.Selection) url.Values {
+	values := url.Values{}
+
+	form.Find("input[name]").Each(func(_ int, node *goquery.Selection) {
+		name, _ := node.Attr("name")
+		value, _ := node.Attr("value")
+
+		switch inputType, _ := node.Attr("type"); strings.ToLower(inputType) {
+		case "submit", "button", "image", "reset", "file":
+			return
+		case "checkbox", "radio":
+			if _, checked := node.Attr("checked"); !checked {
+				return
+			}
+			if _, ok := node.Attr("value"); !ok {
+				value = "on"
+			}
+		}
+
+		values.Add(name, value)
+	})
+
+	form.Find("select[name]").Each(func(_ int, node *goquery.Selection) {
+		name, _ := node.Attr("name")
+
+		selected := node.Find("option[selected]")
+		if len(selected.Nodes) == 0 {
+			if _, multiple := node.Attr("multiple"); multiple {
+				return
+			}
+			selected = node.Find("option").First()
+		}
+
+		selected.Each(func(_ int, option *goquery.Selection) {
+			value, ok := option.Attr("value")
+			if !ok {
+				value = strings.TrimSpace(option.Text())
+			}
+			values.Add(name, value)
+		})
+	})
+
+	form.Find("textarea[name]").Each(func(_ int, node *goquery.Selection) {
+		name, _ := node.Attr("name")
+		values.Add(name, node.Text())
+	})
+
+	return values
+}
+
+// authenticityToken returns the session's authenticity token, fetching one
+// from the home page if the client has none
+func (client *AO3Client) authenticityToken(ctx context.Context) (string, *AO3Error) {
+	client.sessionMu.RLock()
+	token := client.csrfToken
+	client.sessionMu.RUnlock()
+
+	if token != "" {
+		return token, nil
+	}
+
+	token, ao3Err := client.fetchCSRFToken(ctx, "/", "fetching authenticity token")
+	if ao3Err != nil {
+		return "", ao3Err
+	}
+	client.setCSRFToken(token)
+
+	return token, nil
+}
+
+func (client *AO3Client) setSession(username string, csrfToken string) {
+	client.sessionMu.Lock()
+	defer client.sessionMu.Unlock()
+
+	if username != client.username {
+		client.mutedUsers = nil
+	}
+	client.username = username
+	client.csrfToken = csrfToken
+}
+
+func (client *AO3Client) setCSRFToken(csrfToken string) {
+	client.sessionMu.Lock()
+	defer client.sessionMu.Unlock()
+
+	client.csrfToken = csrfToken
+}
@@ -0,0 +1,174 @@
+package ao3
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+	"github.com/stretchr/testify/assert"
+)
+
+// newSessionClient returns a fresh client pointed at the same server as client
+func newSessionClient(t *testing.T, client *AO3Client) *AO3Client {
+	restored, err := InitAO3Client(nil, AO3Policy)
+	if err != nil {
+		t.Fatal(err.Error())
+	}
+
+	if err := restored.SetBaseURL(client.BaseURL()); err != nil {
+		t.Fatal(err.Error())
+	}
+
+	return restored
+}
+
+// importTestSession logs client in by importing a session whose cookie is the
+// user name, as the fake servers of these tests expect
+func importTestSession(t *testing.T, client *AO3Client, username string) {
+	if err := client.ImportSession([]byte(`{"version":1,"base_url":"` + client.BaseURL() + `","username":"` + username + `","cookies":[{"name":"_otwarchive_session","value":"` + username + `"}]}`)); err != nil {
+		t.Fatal(err.Error())
+	}
+}
+
+// TestSessionExportImport ensures an exported session logs a fresh client in
+func TestSessionExportImport(t *testing.T) {
+	client, closeServer := newLoginServer(t)
+	defer closeServer()
+
+	if err := client.Authenticate("reader", "hunter2"); err != nil {
+		t.Fatal(err.Error())
+	}
+
+	data, err := client.ExportSession()
+	if err != nil {
+		t.Fatal(err.Error())
+	}
+
+	restored := newSessionClient(t, client)
+	if err := restored.ImportSession(data); err != nil {
+		t.Fatal(err.Error())
+	}
+	assert.Equal(t, "reader", restored.Username())
+
+	loggedIn, err := restored.IsLoggedIn()
+	if err != nil {
+		t.Fatal(err.Error())
+	}
+	assert.True(t, loggedIn)
+
+	categories, err := restored.GetFandomCategories()
+	if err != nil {
+		t.Fatal(err.Error())
+	}
+	assert.Equal(t, "Restricted", categories[0].Name)
+}
+
+// TestSessionFile ensures sessions saved to a file are private to their owner
+func TestSessionFile(t *testing.T) {
+	client, closeServer := newLoginServer(t)
+	defer closeServer()
+
+	if err := client.Authenticate("reader", "hunter2"); err != nil {
+		t.Fatal(err.Error())
+	}
+
+	dir, tempErr := ioutil.TempDir("", "ao3-session")
+	if tempErr != nil {
+		t.Fatal(tempErr.Error())
+	}
+	defer os.RemoveAll(dir)
+
+	path := filepath.Join(dir, "session.json")
+	if err := client.SaveSession(path); err != nil {
+		t.Fatal(err.Error())
+	}
+
+	info, statErr := os.Stat(path)
+	if statErr != nil {
+		t.Fatal(statErr.Error())
+	}
+	assert.Equal(t, os.FileMode(0600), info.Mode().Perm())
+
+	restored := newSessionClient(t, client)
+	if err := restored.LoadSession(path); err != nil {
+		t.Fatal(err.Error())
+	}
+
+	loggedIn, err := restored.IsLoggedIn()
+	if err != nil {
+		t.Fatal(err.Error())
+	}
+	assert.True(t, loggedIn)
+}
+
+// TestImportSessionErrors ensures malformed sessions and sessions of other
+// archives are rejected
+func TestImportSessionErrors(t *testing.T) {
+	client, err := InitAO3Client(nil, AO3Policy)
+	if err != nil {
+		t.Fatal(err.Error())
+	}
+
+	assert.NotNil(t, client.ImportSession([]byte("not a session")))
+	assert.NotNil(t, client.ImportSession([]byte(`{"version":2,"base_url":"https://archiveofourown.org/"}`)))
+	assert.NotNil(t, client.ImportSession([]byte(`{"version":1,"base_url":"https://archiveofourown.gay/"}`)))
+
+	const session = `{"version":1,"base_url":"https://archiveofourown.org/","username":"reader","cookies":[{"name":"_otwarchive_session","value":"reader"}]}`
+
+	// A HttpClient replaced by one without a cookie jar cannot hold sessions
+	jar := client.HttpClient.Jar
+	client.HttpClient.Jar = nil
+	_, err = client.ExportSession()
+	assert.NotNil(t, err)
+	assert.NotNil(t, client.ImportSession([]byte(session)))
+	client.HttpClient.Jar = jar
+
+	assert.Nil(t, client.ImportSession([]byte(session)))
+	assert.Equal(t, "reader", client.Username())
+}
+
+// TestIsLoggedInExpired ensures an expired session is detected and cleared
+func TestIsLoggedInExpired(t *testing.T) {
+	client, closeServer := newLoginServer(t)
+	defer closeServer()
+
+	if err := client.ImportSession([]byte(`{"version":1,"base_url":"` + client.BaseURL() + `","username":"reader","cookies":[{"name":"_otwarchive_session","value":"expired"}]}`)); err != nil {
+		t.Fatal(err.Error())
+	}
+
+	loggedIn, err := client.IsLoggedIn()
+	if err != nil {
+		t.Fatal(err.Error())
+	}
+	assert.False(t, loggedIn)
+	assert.Equal(t, "", client.Username())
+}
+
+// TestLogout ensures logging out ends the session, refreshing a stale
+// authenticity token on the way
+func TestLogout(t *testing.T) {
+	client, closeServer := newLoginServer(t)
+	defer closeServer()
+
+	if err := client.Authenticate("reader", "hunter2"); err != nil {
+		t.Fatal(err.Error())
+	}
+	client.setCSRFToken("stale-token")
+
+	if err := client.Logout(); err != nil {
+		t.Fatal(err.Error())
+	}
+	assert.Equal(t, "", client.Username())
+
+	loggedIn, err := client.IsLoggedIn()
+	if err != nil {
+		t.Fatal(err.Error())
+	}
+	assert.False(t, loggedIn)
+
+	categories, err := client.GetFandomCategories()
+	if err != nil {
+		t.Fatal(err.Error())
+	}
+	assert.Equal(t, "Books & Literature", categories[0].Name)
+}
@@ -0,0 +1,253 @@
+package ao3
+
+import (
+	"bytes"
+	"context"
+	"net/http"
+	"net/url"
+	"regexp"
+	"strconv"
+	"strings"
+	"github.com/PuerkitoBio/goquery"
+)
+
+var statsWordsRegex = regexp.MustCompile(`([\d,]+)\s+words?`)
+
+// StatsSort selects the column the works of GetUserStats are sorted by
+type StatsSort int
+
+const (
+	// StatsSortHits sorts works by hits, as AO3 does by default
+	StatsSortHits StatsSort = iota
+	// StatsSortTitle sorts works by title
+	StatsSortTitle
+	// StatsSortDate sorts works by the date they were posted
+	StatsSortDate
+	// StatsSortWords sorts works by word count
+	StatsSortWords
+	// StatsSortKudos sorts works by kudos
+	StatsSortKudos
+	// StatsSortCommentThreads sorts works by comment threads
+	StatsSortCommentThreads
+	// StatsSortBookmarks sorts works by bookmarks
+	StatsSortBookmarks
+	// StatsSortSubscriptions sorts works by subscriptions
+	StatsSortSubscriptions
+)
+
+// statsSortColumns are AO3's names of the StatsSort columns
+var statsSortColumns = map[StatsSort]string{
+	StatsSortHits:           "hits",
+	StatsSortTitle:          "title",
+	StatsSortDate:           "date",
+	StatsSortWords:          "word_count",
+	StatsSortKudos:          "kudos.count",
+	StatsSortCommentThreads: "comment_thread_count",
+	StatsSortBookmarks:      "bookmarks.count",
+	StatsSortSubscriptions:  "subscriptions.count",
+}
+
+// StatsOptions filter and sort the statistics returned by GetUserStats
+type StatsOptions struct {
+	// Year limits the statistics to the works posted in a year. Zero covers
+	// all years.
+	Year int
+
+	// SortBy and SortAscending order the works, by descending hits by
+	// default
+	SortBy        StatsSort
+	SortAscending bool
+}
+
+// WorkStats are the statistics of one of the logged-in user's works
+type WorkStats struct {
+	// Work is the work, whose slug is its ID
+	Work Link
+
+	Words          int
+	Hits           int
+	Kudos          int
+	CommentThreads int
+	Bookmarks      int
+	Subscriptions  int
+}
+
+// UserStats are the statistics of the logged-in user and their works
+type UserStats struct {
+	// UserSubscriptions counts the subscriptions to the user, Subscriptions
+	// those to their works
+	UserSubscriptions int
+
+	Kudos          int
+	CommentThreads int
+	Bookmarks      int
+	Subscriptions  int
+	Words          int
+	Hits           int
+
+	Works []WorkStats
+
+	// Diagnostics describes the totals and works which could not be parsed in
+	// lenient mode
+	Diagnostics []ParseDiagnostic
+}
+
+// GetUserStats returns the statistics of the logged-in user and their works.
+// AO3 only shows users their own statistics.
+//
+// Endpoint: https://archiveofourown.org/users/[user]/stats?flat_view=true&year=[year]&sort_column=[column]&sort_direction=[direction]
+func (client *AO3Client) GetUserStats(options StatsOptions) (*UserStats, *AO3Error) {
+	return client.GetUserStatsWithContext(context.Background(), options)
+}
+
+// GetUserStatsWithContext is GetUserStats with a context which cancels the
+// request when it is done
+func (client *AO3Client) GetUserStatsWithContext(ctx context.Context, options StatsOptions) (*UserStats, *AO3Error) {
+	if ao3Err := client.requireLogin("fetching user stats"); ao3Err != nil {
+		return nil, ao3Err
+	}
+
+	column, ok := statsSortColumns[options.SortBy]
+	if !ok {
+		return nil, NewError(http.StatusBadRequest, "unknown stats sort column "+strconv.Itoa(int(options.SortBy)))
+	}
+
+	query := url.Values{}
+	query.Set("flat_view", "true")
+	query.Set("sort_column", column)
+	if options.SortAscending {
+		query.Set("sort_direction", "ASC")
+	} else {
+		query.Set("sort_direction", "DESC")
+	}
+	if options.Year != 0 {
+		query.Set("year", strconv.Itoa(options.Year))
+	} else {
+		query.Set("year", "All Years")
+	}
+
+	endpoint := "/users/" + url.PathEscape(client.Username()) + "/stats?" + query.Encode()
+
+	body, ao3Err := client.get(ctx, endpoint, 0, "fetching user stats")
+	if ao3Err != nil {
+		return nil, ao3Err
+	}
+
+	doc, err := goquery.NewDocumentFromReader(bytes.NewReader(body))
+	if err != nil {
+		return nil, WrapError(http.StatusUnprocessableEntity, err, "parsing stats page with goquery failed")
+	}
+
+	stats := UserStats{Works: []WorkStats{}}
+	state := client.newParseState(client.endpointURL(endpoint), client.Lenient)
+
+	// Extract the totals. The user's subscriptions share the subscriptions
+	// class with those of their works.
+	totalsNode := doc.Find("dl.statistics").First()
+	totals := []struct {
+		field    string
+		selector string
+		value    *int
+	}{
+		{"UserSubscriptions", "dd.user.subscriptions", &stats.UserSubscriptions},
+		{"Kudos", "dd.kudos", &stats.Kudos},
+		{"CommentThreads", "dd.comment.thread", &stats.CommentThreads},
+		{"Bookmarks", "dd.bookmarks", &stats.Bookmarks},
+		{"Subscriptions", "dd.subscriptions:not(.user)", &stats.Subscriptions},
+		{"Words", "dd.words", &stats.Words},
+		{"Hits", "dd.hits", &stats.Hits},
+	}
+	for _, total := range totals {
+		totalMatches := totalsNode.Find(total.selector)
+		if len(totalMatches.Nodes) != 1 {
+			ao3Err := NewError(http.StatusUnprocessableEntity, "unable to find "+total.field+" total on stats page")
+			if state.fail(total.field, "dl.statistics "+total.selector, doc.Find("#main"), ao3Err) {
+				return nil, ao3Err
+			}
+			continue
+		}
+
+		*total.value, err = AtoiWithComma(strings.TrimSpace(totalMatches.Text()))
+		if err != nil {
+			ao3Err := WrapError(http.StatusUnprocessableEntity, err, "parsing "+total.field+" total failed")
+			if state.fail(total.field, "dl.statistics "+total.selector, totalMatches, ao3Err) {
+				return nil, ao3Err
+			}
+		}
+	}
+
+	// Extract the works, each of which lists its statistics in a dl.stats
+	workMatches := doc.Find("dl.stats").Closest("li")
+	for i := range workMatches.Nodes {
+		node := workMatches.Eq(i)
+
+		work, ao3Err := client.parseWorkStats(node)
+		if ao3Err != nil {
+			if state.fail("Works", "li dl.stats", node, ao3Err) {
+				return nil, ao3Err
+			}
+			continue
+		}
+
+		stats.Works = append(stats.Works, *work)
+	}
+
+	stats.Diagnostics = state.diagnostics
+
+	return &stats, nil
+}
+
+// parseWorkStats extracts the statistics of a work from its entry on the
+// stats page
+func (client *AO3Client) parseWorkStats(node *goquery.Selection) (*WorkStats, *AO3Error) {
+	work := WorkStats{}
+
+	node.Find("a[href]").EachWithBreak(func(_ int, linkNode *goquery.Selection) bool {
+		link, _ := linkNode.Attr("href")
+		if matches := postedWorkLinkRegex.FindStringSubmatch(client.relativeLink(link)); len(matches) == 2 {
+			work.Work = Link{Text: strings.TrimSpace(linkNode.Text()), Slug: matches[1]}
+			return false
+		}
+		return true
+	})
+	if work.Work.Slug == "" {
+		return nil, NewError(http.StatusUnprocessableEntity, "unable to find work link in stats")
+	}
+
+	wordsMatches := statsWordsRegex.FindStringSubmatch(node.Find(".words").First().Text())
+	if len(wordsMatches) != 2 {
+		return nil, NewError(http.StatusUnprocessableEntity, "unable to find word count of work "+work.Work.Slug)
+	}
+	words, err := AtoiWithComma(wordsMatches[1])
+	if err != nil {
+		return nil, WrapError(http.StatusUnprocessableEntity, err, "parsing word count of work "+work.Work.Slug+" failed")
+	}
+	work.Words = words
+
+	// Works without any of a statistic leave it out
+	statsNode := node.Find("dl.stats").First()
+	counts := []struct {
+		class string
+		value *int
+	}{
+		{"hits", &work.Hits},
+		{"kudos", &work.Kudos},
+		{"comments", &work.CommentThreads},
+		{"bookmarks", &work.Bookmarks},
+		{"subscriptions", &work.Subscriptions},
+	}
+	for _, count := range counts {
+		countMatches := statsNode.Find("dd." + count.class)
+		if len(countMatches.Nodes) == 0 {
+			continue
+		}
+
+		value, err := AtoiWithComma(strings.TrimSpace(countMatches.First().Text()))
+		if err != nil {
+			return nil, WrapError(http.StatusUnprocessableEntity, err, "parsing "+count.class+" of work "+work.Work.Slug+" failed")
+		}
+		*count.value = value
+	}
+
+	return &work, nil
+}
@@ -0,0 +1,101 @@
+package ao3
+
+import (
+	"errors"
+	"net/http"
+	"net/url"
+	"testing"
+	"github.com/stretchr/testify/assert"
+)
+
+const testStatsPage = `<html><body class="logged-in"><div id="main" class="stats-index dashboard region">
+<h2 class="heading">Stats for reader</h2>
+<div class="statistics meta group"><dl class="statistics meta group">
+<dt class="user subscriptions">User Subscriptions:</dt><dd class="user subscriptions">3</dd>
+<dt class="kudos">Kudos:</dt><dd class="kudos">1,204</dd>
+<dt class="comment thread count">Comment Threads:</dt><dd class="comment thread count">57</dd>
+<dt class="bookmarks">Bookmarks:</dt><dd class="bookmarks">88</dd>
+<dt class="subscriptions">Subscriptions:</dt><dd class="subscriptions">21</dd>
+<dt class="words">Word Count:</dt><dd class="words">45,000</dd>
+<dt class="hits">Hits:</dt><dd class="hits">12,345</dd>
+</dl></div>
+<ul class="index group">
+<li><dl><dt><a href="/works/10">First Work</a> <span class="fandom">(No Fandom)</span> <span class="words">(40,000 words)</span></dt>
+<dd><dl class="stats">
+<dt class="subscriptions">Subscriptions:</dt><dd class="subscriptions">20</dd>
+<dt class="hits">Hits:</dt><dd class="hits">12,000</dd>
+<dt class="kudos">Kudos:</dt><dd class="kudos">1,200</dd>
+<dt class="comments">Comment Threads:</dt><dd class="comments">56</dd>
+<dt class="bookmarks">Bookmarks:</dt><dd class="bookmarks">87</dd>
+</dl></dd></dl></li>
+<li><dl><dt><a href="/works/11">Second Work</a> <span class="fandom">(No Fandom)</span> <span class="words">(5,000 words)</span></dt>
+<dd><dl class="stats">
+<dt class="hits">Hits:</dt><dd class="hits">345</dd>
+<dt class="kudos">Kudos:</dt><dd class="kudos">4</dd>
+</dl></dd></dl></li>
+<li><dl><dt><a href="/works/12">Broken Work</a></dt>
+<dd><dl class="stats"><dt class="hits">Hits:</dt><dd class="hits">many</dd></dl></dd></dl></li>
+</ul>
+</div></body></html>`
+
+// TestGetUserStats ensures the totals and works of the stats page are parsed,
+// with the year and sorting options in the query
+func TestGetUserStats(t *testing.T) {
+	var query url.Values
+
+	client, _, closeServer := newTestArchive(t, false, func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/users/reader/stats":
+			query = r.URL.Query()
+			w.Write([]byte(testStatsPage))
+		default:
+			http.NotFound(w, r)
+		}
+	})
+	defer closeServer()
+
+	_, err := client.GetUserStats(StatsOptions{})
+	if assert.NotNil(t, err) {
+		assert.True(t, errors.Is(err, ErrRestricted))
+	}
+
+	importTestSession(t, client, "reader")
+
+	_, err = client.GetUserStats(StatsOptions{})
+	if assert.NotNil(t, err) {
+		assert.True(t, errors.Is(err, ErrParse))
+	}
+	assert.Equal(t, "true", query.Get("flat_view"))
+	assert.Equal(t, "All Years", query.Get("year"))
+	assert.Equal(t, "hits", query.Get("sort_column"))
+	assert.Equal(t, "DESC", query.Get("sort_direction"))
+
+	client.Lenient = true
+
+	stats, err := client.GetUserStats(StatsOptions{Year: 2023, SortBy: StatsSortKudos, SortAscending: true})
+	if err != nil {
+		t.Fatal(err.Error())
+	}
+	assert.Equal(t, "2023", query.Get("year"))
+	assert.Equal(t, "kudos.count", query.Get("sort_column"))
+	assert.Equal(t, "ASC", query.Get("sort_direction"))
+
+	assert.Equal(t, 3, stats.UserSubscriptions)
+	assert.Equal(t, 1204, stats.Kudos)
+	assert.Equal(t, 57, stats.CommentThreads)
+	assert.Equal(t, 88, stats.Bookmarks)
+	assert.Equal(t, 21, stats.Subscriptions)
+	assert.Equal(t, 45000, stats.Words)
+	assert.Equal(t, 12345, stats.Hits)
+
+	assert.Equal(t, []WorkStats{
+		{Work: Link{Text: "First Work", Slug: "10"}, Words: 40000, Hits: 12000, Kudos: 1200, CommentThreads: 56, Bookmarks: 87, Subscriptions: 20},
+		{Work: Link{Text: "Second Work", Slug: "11"}, Words: 5000, Hits: 345, Kudos: 4},
+	}, stats.Works)
+	if assert.Len(t, stats.Diagnostics, 1) {
+		assert.Equal(t, "Works", stats.Diagnostics[0].Field)
+	}
+
+	_, err = client.GetUserStats(StatsOptions{SortBy: StatsSort(99)})
+	assert.NotNil(t, err)
+}
@@ -0,0 +1,239 @@
+package ao3
+
+import (
+	"bytes"
+	"context"
+	"net/http"
+	"net/url"
+	"regexp"
+	"strconv"
+	"strings"
+	"github.com/PuerkitoBio/goquery"
+)
+
+// SubscriptionKind is the kind of thing subscribed to, named as in AO3's forms
+type SubscriptionKind string
+
+const (
+	// WorkSubscription notifies the user of new chapters of a work
+	WorkSubscription SubscriptionKind = "Work"
+	// SeriesSubscription notifies the user of new works in a series
+	SeriesSubscription SubscriptionKind = "Series"
+	// UserSubscription notifies the user of new works by a user
+	UserSubscription SubscriptionKind = "User"
+)
+
+var subscriptionLinkRegex = regexp.MustCompile(`^/users/[^/]+/subscriptions/(\d+)$`)
+var subscribableLinkRegex = regexp.MustCompile(`^/(works|series|users)/([^/?#]+)$`)
+
+// Subscriptions is a page of the logged-in user's subscriptions, grouped by
+// kind. The slugs of works and series are their IDs and the slugs of users
+// their names.
+type Subscriptions struct {
+	Works  []Link
+	Series []Link
+	Users  []Link
+
+	// Pagination-related values
+	IsPaginated bool
+	CurrentPage int
+	LastPage    int
+
+	// Diagnostics describes the subscriptions which could not be parsed in
+	// lenient mode
+	Diagnostics []ParseDiagnostic
+}
+
+// Subscribe subscribes the logged-in user to a work or series by its ID, or to
+// a user by their name. Subscribing to something the user is already
+// subscribed to does nothing.
+//
+// Endpoint: https://archiveofourown.org/users/[user]/subscriptions
+func (client *AO3Client) Subscribe(kind SubscriptionKind, id string) *AO3Error {
+	return client.SubscribeWithContext(context.Background(), kind, id)
+}
+
+// SubscribeWithContext is Subscribe with a context which cancels the requests
+// when it is done
+func (client *AO3Client) SubscribeWithContext(ctx context.Context, kind SubscriptionKind, id string) *AO3Error {
+	doc, ao3Err := client.fetchSubscriptionForm(ctx, kind, id, "subscribing")
+	if ao3Err != nil {
+		return ao3Err
+	}
+
+	formNode := doc.Find(`form:has(input[name="subscription[subscribable_id]"])`).First()
+	if len(formNode.Nodes) == 0 {
+		if _, subscribed := client.unsubscribeEndpoint(doc); subscribed {
+			return nil
+		}
+		return NewError(http.StatusUnprocessableEntity, "unable to find subscribe form")
+	}
+
+	endpoint, _ := formNode.Attr("action")
+	subscribableID, _ := formNode.Find(`input[name="subscription[subscribable_id]"]`).Attr("value")
+	subscribableType, _ := formNode.Find(`input[name="subscription[subscribable_type]"]`).Attr("value")
+	if endpoint == "" || subscribableID == "" || subscribableType == "" {
+		return NewError(http.StatusUnprocessableEntity, "unable to parse subscribe form")
+	}
+
+	form := url.Values{}
+	form.Set("subscription[subscribable_id]", subscribableID)
+	form.Set("subscription[subscribable_type]", subscribableType)
+	form.Set("commit", "Subscribe")
+
+	res, ao3Err := client.submit(ctx, client.relativeLink(endpoint), form, "subscribing")
+	if ao3Err != nil {
+		return ao3Err
+	}
+
+	_, ao3Err = checkSubmission(res.body, "subscribing")
+	return ao3Err
+}
+
+// Unsubscribe unsubscribes the logged-in user from a work or series by its ID,
+// or from a user by their name. Unsubscribing from something the user is not
+// subscribed to does nothing.
+//
+// Endpoint: https://archiveofourown.org/users/[user]/subscriptions/[subscription]
+func (client *AO3Client) Unsubscribe(kind SubscriptionKind, id string) *AO3Error {
+	return client.UnsubscribeWithContext(context.Background(), kind, id)
+}
+
+// UnsubscribeWithContext is Unsubscribe with a context which cancels the
+// requests when it is done
+func (client *AO3Client) UnsubscribeWithContext(ctx context.Context, kind SubscriptionKind, id string) *AO3Error {
+	doc, ao3Err := client.fetchSubscriptionForm(ctx, kind, id, "unsubscribing")
+	if ao3Err != nil {
+		return ao3Err
+	}
+
+	endpoint, subscribed := client.unsubscribeEndpoint(doc)
+	if !subscribed {
+		if len(doc.Find(`input[name="subscription[subscribable_id]"]`).Nodes) > 0 {
+			return nil
+		}
+		return NewError(http.StatusUnprocessableEntity, "unable to find unsubscribe form")
+	}
+
+	form := url.Values{}
+	form.Set("_method", "delete")
+
+	res, ao3Err := client.submit(ctx, endpoint, form, "unsubscribing")
+	if ao3Err != nil {
+		return ao3Err
+	}
+
+	_, ao3Err = checkSubmission(res.body, "unsubscribing")
+	return ao3Err
+}
+
+// ListSubscriptions returns a page of the logged-in user's subscriptions
+//
+// Endpoint: https://archiveofourown.org/users/[user]/subscriptions?page=[page]
+func (client *AO3Client) ListSubscriptions(page int) (*Subscriptions, *AO3Error) {
+	return client.ListSubscriptionsWithContext(context.Background(), page)
+}
+
+// ListSubscriptionsWithContext is ListSubscriptions with a context which
+// cancels the request when it is done
+func (client *AO3Client) ListSubscriptionsWithContext(ctx context.Context, page int) (*Subscriptions, *AO3Error) {
+	if ao3Err := client.requireLogin("listing subscriptions"); ao3Err != nil {
+		return nil, ao3Err
+	}
+
+	endpoint := "/users/" + url.PathEscape(client.Username()) + "/subscriptions"
+	if page != 0 {
+		endpoint += "?page=" + strconv.Itoa(page)
+	}
+
+	body, ao3Err := client.get(ctx, endpoint, 0, "fetching subscriptions")
+	if ao3Err != nil {
+		return nil, ao3Err
+	}
+
+	doc, err := goquery.NewDocumentFromReader(bytes.NewReader(body))
+	if err != nil {
+		return nil, WrapError(http.StatusUnprocessableEntity, err, "parsing subscriptions page with goquery failed")
+	}
+
+	subscriptions := Subscriptions{Works: []Link{}, Series: []Link{}, Users: []Link{}}
+	state := client.newParseState(client.endpointURL(endpoint), client.Lenient)
+
+	pages, ao3Err := parsePagination(state, doc, 1)
+	if ao3Err != nil {
+		return nil, ao3Err
+	}
+	subscriptions.IsPaginated = pages.isPaginated
+	subscriptions.CurrentPage = pages.currentPage
+	subscriptions.LastPage = pages.lastPage
+
+	subscriptionMatches := doc.Find("dl.subscription > dt")
+	for i := range subscriptionMatches.Nodes {
+		node := subscriptionMatches.Eq(i)
+		linkNode := node.Find("a[href]").First()
+
+		link, _ := linkNode.Attr("href")
+		matches := subscribableLinkRegex.FindStringSubmatch(client.relativeLink(link))
+		if len(matches) != 3 {
+			ao3Err := NewError(http.StatusUnprocessableEntity, "unable to parse subscription link")
+			if state.fail("Subscriptions", "dl.subscription > dt a", node, ao3Err) {
+				return nil, ao3Err
+			}
+			continue
+		}
+
+		slug, _ := url.PathUnescape(matches[2])
+		subscription := Link{Text: strings.TrimSpace(linkNode.Text()), Slug: slug}
+
+		switch matches[1] {
+		case "works":
+			subscriptions.Works = append(subscriptions.Works, subscription)
+		case "series":
+			subscriptions.Series = append(subscriptions.Series, subscription)
+		case "users":
+			subscriptions.Users = append(subscriptions.Users, subscription)
+		}
+	}
+
+	subscriptions.Diagnostics = state.diagnostics
+
+	return &subscriptions, nil
+}
+
+// fetchSubscriptionForm fetches the page of a work, series or user, which
+// holds the form to subscribe to or unsubscribe from it
+func (client *AO3Client) fetchSubscriptionForm(ctx context.Context, kind SubscriptionKind, id string, action string) (*goquery.Document, *AO3Error) {
+	if ao3Err := client.requireLogin(action); ao3Err != nil {
+		return nil, ao3Err
+	}
+
+	var endpoint string
+	switch kind {
+	case WorkSubscription:
+		endpoint = workEndpoint(id)
+	case SeriesSubscription:
+		endpoint = "/series/" + id
+	case UserSubscription:
+		endpoint = "/users/" + url.PathEscape(id)
+	default:
+		return nil, NewError(http.StatusBadRequest, action+" failed as \""+string(kind)+"\" cannot be subscribed to")
+	}
+
+	return client.fetchForm(ctx, endpoint, "fetching subscription form")
+}
+
+// unsubscribeEndpoint returns the endpoint of the form which unsubscribes the
+// user from the page's work, series or user, if they are subscribed
+func (client *AO3Client) unsubscribeEndpoint(doc *goquery.Document) (string, bool) {
+	endpoint := ""
+	doc.Find(`form[action*="/subscriptions/"]`).EachWithBreak(func(_ int, node *goquery.Selection) bool {
+		action, _ := node.Attr("action")
+		if subscriptionLinkRegex.MatchString(client.relativeLink(action)) {
+			endpoint = client.relativeLink(action)
+			return false
+		}
+		return true
+	})
+
+	return endpoint, endpoint != ""
+}
@@ -0,0 +1,133 @@
+package ao3
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+	"testing"
+	"github.com/stretchr/testify/assert"
+)
+
+// newSubscriptionServer returns a client pointed at a fake AO3 on which
+// "reader" can subscribe to work 1, series 2 and the user "bob"
+func newSubscriptionServer(t *testing.T) (*AO3Client, func()) {
+	type subscribable struct {
+		id    string
+		kind  string
+		link  string
+		title string
+	}
+	subscribables := map[string]subscribable{
+		"/works/1":   {id: "1", kind: "Work", link: "/works/1", title: "Work One"},
+		"/series/2":  {id: "2", kind: "Series", link: "/series/2", title: "Series Two"},
+		"/users/bob": {id: "300", kind: "User", link: "/users/bob", title: "bob"},
+	}
+
+	// subscriptions maps the subscribable IDs and types to subscription IDs
+	subscriptions := map[string]int{}
+	nextID := 1
+
+	client, _, closeServer := newTestArchive(t, false, func(w http.ResponseWriter, r *http.Request) {
+		if subscribed, ok := subscribables[r.URL.Path]; ok {
+			form := `<form id="new_subscription" action="/users/reader/subscriptions" method="post"><input type="hidden" name="subscription[subscribable_id]" value="` + subscribed.id + `" /><input type="hidden" name="subscription[subscribable_type]" value="` + subscribed.kind + `" /><input type="submit" name="commit" value="Subscribe" /></form>`
+			if id, ok := subscriptions[subscribed.kind+subscribed.id]; ok {
+				form = fmt.Sprintf(`<form id="edit_subscription_%d" action="/users/reader/subscriptions/%d" method="post"><input type="hidden" name="_method" value="delete" /><input type="submit" name="commit" value="Unsubscribe" /></form>`, id, id)
+			}
+			writeTestPage(w, `<ul class="work navigation actions"><li class="subscribe">`+form+`</li></ul>`)
+			return
+		}
+
+		switch {
+		case r.URL.Path == "/users/reader/subscriptions" && r.Method == http.MethodPost:
+			key := r.PostFormValue("subscription[subscribable_type]") + r.PostFormValue("subscription[subscribable_id]")
+			subscriptions[key] = nextID
+			nextID++
+			http.Redirect(w, r, "/users/reader/subscriptions", http.StatusFound)
+
+		case strings.HasPrefix(r.URL.Path, "/users/reader/subscriptions/") && r.Method == http.MethodPost:
+			for key, id := range subscriptions {
+				if r.URL.Path == fmt.Sprintf("/users/reader/subscriptions/%d", id) && r.PostFormValue("_method") == "delete" {
+					delete(subscriptions, key)
+					http.Redirect(w, r, "/users/reader/subscriptions", http.StatusFound)
+					return
+				}
+			}
+			w.Write([]byte(`<body><div class="flash error">Sorry, we couldn't find that subscription.</div></body>`))
+
+		case r.URL.Path == "/users/reader/subscriptions":
+			var list strings.Builder
+			for _, subscribed := range []subscribable{subscribables["/works/1"], subscribables["/series/2"], subscribables["/users/bob"]} {
+				if id, ok := subscriptions[subscribed.kind+subscribed.id]; ok {
+					fmt.Fprintf(&list, `<dt><a href="%s">%s</a></dt><dd><form action="/users/reader/subscriptions/%d" method="post"><input type="hidden" name="_method" value="delete" /></form></dd>`, subscribed.link, subscribed.title, id)
+				}
+			}
+			pagination := `<ol class="pagination actions"><li class="previous"><span class="disabled">&#8592; Previous</span></li><li><span class="current">1</span></li><li><a href="/users/reader/subscriptions?page=2">2</a></li><li class="next"><a href="/users/reader/subscriptions?page=2">Next &#8594;</a></li></ol>`
+			w.Write([]byte(`<body><div id="main"><dl class="subscription index group">` + list.String() + `</dl>` + pagination + `</div></body>`))
+
+		default:
+			http.NotFound(w, r)
+		}
+	})
+
+	return client, closeServer
+}
+
+// TestSubscriptions ensures subscribing and unsubscribing are reflected in the
+// listed subscriptions, and repeating either does nothing
+func TestSubscriptions(t *testing.T) {
+	client, closeServer := newSubscriptionServer(t)
+	defer closeServer()
+
+	err := client.Subscribe(WorkSubscription, "1")
+	if assert.NotNil(t, err) {
+		assert.True(t, errors.Is(err, ErrRestricted))
+	}
+
+	importTestSession(t, client, "reader")
+
+	for _, subscription := range []struct {
+		kind SubscriptionKind
+		id   string
+	}{{WorkSubscription, "1"}, {SeriesSubscription, "2"}, {UserSubscription, "bob"}, {WorkSubscription, "1"}} {
+		if err := client.Subscribe(subscription.kind, subscription.id); err != nil {
+			t.Fatal(err.Error())
+		}
+	}
+
+	subscriptions, err := client.ListSubscriptions(0)
+	if err != nil {
+		t.Fatal(err.Error())
+	}
+	assert.Equal(t, []Link{{Text: "Work One", Slug: "1"}}, subscriptions.Works)
+	assert.Equal(t, []Link{{Text: "Series Two", Slug: "2"}}, subscriptions.Series)
+	assert.Equal(t, []Link{{Text: "bob", Slug: "bob"}}, subscriptions.Users)
+	assert.True(t, subscriptions.IsPaginated)
+	assert.Equal(t, 1, subscriptions.CurrentPage)
+	assert.Equal(t, 2, subscriptions.LastPage)
+
+	if err := client.Unsubscribe(SeriesSubscription, "2"); err != nil {
+		t.Fatal(err.Error())
+	}
+	if err := client.Unsubscribe(SeriesSubscription, "2"); err != nil {
+		t.Fatal(err.Error())
+	}
+
+	subscriptions, err = client.ListSubscriptions(0)
+	if err != nil {
+		t.Fatal(err.Error())
+	}
+	assert.Len(t, subscriptions.Works, 1)
+	assert.Empty(t, subscriptions.Series)
+	assert.Len(t, subscriptions.Users, 1)
+
+	err = client.Subscribe(SubscriptionKind("Tag"), "1")
+	if assert.NotNil(t, err) {
+		assert.Equal(t, http.StatusBadRequest, err.Code())
+	}
+
+	err = client.Subscribe(WorkSubscription, "3")
+	if assert.NotNil(t, err) {
+		assert.True(t, errors.Is(err, ErrNotFound))
+	}
+}
@@ -1,6 +1,8 @@
 package ao3
 
 import (
+	"bytes"
+	"context"
 	"net/http"
 	"github.com/PuerkitoBio/goquery"
 	"regexp"
@@ -16,6 +18,10 @@ type TagWorks struct {
 	IsPaginated bool
 	CurrentPage int
 	LastPage    int
+
+	// Diagnostics describes the fields of the page which could not be parsed
+	// in lenient mode. Diagnostics of individual works are kept in the works.
+	Diagnostics []ParseDiagnostic
 }
 
 // GetTagWorks returns a paginated list of works from a tag. A tag can represent
@@ -24,77 +30,61 @@ type TagWorks struct {
 // Endpoint: https://archiveofourown.org/tags/[tag]/works?page=[page]
 // Example: https://archiveofourown.org/tags/Action*s*Adventure/works
 func (client *AO3Client) GetTagWorks(tag string, page int) (*TagWorks, *AO3Error) {
+	return client.GetTagWorksWithContext(context.Background(), tag, page)
+}
+
+// GetTagWorksWithContext is GetTagWorks with a context which cancels the
+// request when it is done
+func (client *AO3Client) GetTagWorksWithContext(ctx context.Context, tag string, page int) (*TagWorks, *AO3Error) {
 	endpoint := "/tags/" + tag + "/works"
 	if page != 0 {
 		endpoint += "?page=" + strconv.Itoa(page)
 	}
 
-	res, err := client.HttpClient.Get(baseURL + endpoint)
-	if err != nil {
-		return nil, WrapError(http.StatusServiceUnavailable, err, "fetching tagged works returned an err")
-	}
-	defer res.Body.Close()
-	if res.StatusCode != http.StatusOK {
-		return nil, NewError(res.StatusCode, "fetching tagged works returned a non-200 status code")
+	body, ao3Err := client.get(ctx, endpoint, client.CacheTTLs.TagWorks, "fetching tagged works")
+	if ao3Err != nil {
+		return nil, ao3Err
 	}
 
-	doc, err := goquery.NewDocumentFromReader(res.Body)
+	doc, err := goquery.NewDocumentFromReader(bytes.NewReader(body))
 	if err != nil {
 		return nil, WrapError(http.StatusUnprocessableEntity, err, "parsing tagged works page with goquery failed")
 	}
 
 	var tagWorks TagWorks
+	state := client.newParseState(client.endpointURL(endpoint), client.Lenient)
 
 	// Get the number of works returned by the result
 	countMatches := doc.Find("#main > h2.heading")
 	if len(countMatches.Nodes) != 1 {
-		return nil, NewError(http.StatusUnprocessableEntity, "unable to find works count node")
-	}
-
-	countRegex := regexp.MustCompile("(?m)(?:.+of )?(\\d+) Work.+")
-	matchedCount := countRegex.FindStringSubmatch(countMatches.First().Text())
-	if len(matchedCount) != 2 {
-		return nil, NewError(http.StatusUnprocessableEntity, "unable to find works count in node")
-	}
-
-	tagWorks.Count, err = AtoiWithComma(matchedCount[1])
-	if err != nil {
-		return nil, NewError(http.StatusUnprocessableEntity, "unable to regex works count")
-	}
-
-	// Get pagination details. There are two pagination bars on each page.
-	paginationMatches := doc.Find("ol.pagination")
-	tagWorks.IsPaginated = len(paginationMatches.Nodes) == 2
-
-	if tagWorks.IsPaginated {
-		paginationNode := paginationMatches.First()
-
-		// Get the current page number
-		currentMatches := paginationNode.Find("span.current")
-		if len(currentMatches.Nodes) != 1 {
-			return nil, NewError(http.StatusUnprocessableEntity, "unable to match current page")
+		ao3Err := NewError(http.StatusUnprocessableEntity, "unable to find works count node")
+		if state.fail("Count", "#main > h2.heading", doc.Find("#main"), ao3Err) {
+			return nil, ao3Err
 		}
-
-		tagWorks.CurrentPage, err = AtoiWithComma(currentMatches.First().Text())
-		if err != nil {
-			return nil, NewError(http.StatusUnprocessableEntity, "unable to parse current page number")
-		}
-
-		// Get the last page number
-		// The last page is always the penultimate <li> tag in the <ol> list.
-		// Therefore, we assume there must be at least three <li> tags: the
-		// previous page link, first page and next page link.
-		paginationLinkNodes := paginationNode.Find("li")
-		if len(paginationLinkNodes.Nodes) < 3 {
-			return nil, NewError(http.StatusUnprocessableEntity, "unable to parse current page number")
+	} else {
+		countRegex := regexp.MustCompile("(?m)(?:.+of )?(\\d+) Work.+")
+		matchedCount := countRegex.FindStringSubmatch(countMatches.First().Text())
+		if len(matchedCount) != 2 {
+			ao3Err := NewError(http.StatusUnprocessableEntity, "unable to find works count in node")
+			if state.fail("Count", "#main > h2.heading", countMatches, ao3Err) {
+				return nil, ao3Err
+			}
+		} else if tagWorks.Count, err = AtoiWithComma(matchedCount[1]); err != nil {
+			ao3Err := NewError(http.StatusUnprocessableEntity, "unable to regex works count")
+			if state.fail("Count", "#main > h2.heading", countMatches, ao3Err) {
+				return nil, ao3Err
+			}
 		}
+	}
 
-		lastWorkNode := paginationLinkNodes.Eq(len(paginationLinkNodes.Nodes) - 2)
-		tagWorks.LastPage, err = AtoiWithComma(lastWorkNode.Text())
-		if err != nil {
-			return nil, NewError(http.StatusUnprocessableEntity, "unable to parse last page number")
-		}
+	// Get pagination details. There are two pagination bars on each page.
+	pages, ao3Err := parsePagination(state, doc, 2)
+	if ao3Err != nil {
+		return nil, ao3Err
 	}
+	tagWorks.IsPaginated = pages.isPaginated
+	tagWorks.CurrentPage = pages.currentPage
+	tagWorks.LastPage = pages.lastPage
 
 	// Fetch the list of works for the page
 	tagWorks.Works = []IndexedWork{}
@@ -104,7 +94,7 @@ func (client *AO3Client) GetTagWorks(tag string, page int) (*TagWorks, *AO3Error
 	for i := range workMatches.Nodes {
 		node := workMatches.Eq(i)
 
-		work, err := client.parseIndexedWorkNode(node)
+		work, err := client.parseIndexedWorkNode(state.rawURL, node)
 		if err != nil {
 			return nil, WrapError(http.StatusUnprocessableEntity, err, "parsing tag work failed")
 		}
@@ -112,5 +102,11 @@ func (client *AO3Client) GetTagWorks(tag string, page int) (*TagWorks, *AO3Error
 		tagWorks.Works = append(tagWorks.Works, *work)
 	}
 
+	if tagWorks.Works, ao3Err = client.hideMutedWorks(ctx, state, tagWorks.Works); ao3Err != nil {
+		return nil, ao3Err
+	}
+
+	tagWorks.Diagnostics = state.diagnostics
+
 	return &tagWorks, nil
 }
@@ -3,19 +3,18 @@ package ao3
 import (
 	"testing"
 	"sync"
+	"net/http"
+	"github.com/stretchr/testify/assert"
 )
 
 // TestGetTaggedWorks is an integration test to ensure that no errors are raised
-// while traversing a sufficient sample set of live tagged works. No comparison
+// while traversing a sufficient sample set of recorded tagged works. No comparison
 // is made against a hardcoded work in this test.
 func TestGetTaggedWorks(t *testing.T) {
 	const tag = "No%20Archive%20Warnings%20Apply"
 	const testPages = 25
 
-	client, err := InitAO3Client(nil, AO3Policy)
-	if err != nil {
-		t.Error(err.Error())
-	}
+	client := newFixtureClient(t)
 
 	var wg sync.WaitGroup
 
@@ -34,3 +33,30 @@ func TestGetTaggedWorks(t *testing.T) {
 
 	wg.Wait()
 }
+
+// TestGetTagWorksPagination ensures tag listings are only paginated when both
+// of their pagination bars, above and below the works, are found
+func TestGetTagWorksPagination(t *testing.T) {
+	const bar = `<ol class="pagination actions"><li class="previous"><span class="disabled">&#8592; Previous</span></li><li><span class="current">1</span></li><li><a href="/tags/Fluff/works?page=2">2</a></li><li class="next"><a href="/tags/Fluff/works?page=2">Next &#8594;</a></li></ol>`
+
+	top := bar
+	client, server := newTestClient(t, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(`<div id="main"><h2 class="heading">1 - 1 of 2 Works in Fluff</h2>` + top + `<ol class="work index group">` + testReading("1", "0", "") + `</ol>` + bar + `</div>`))
+	}))
+	defer server.Close()
+
+	works, err := client.GetTagWorks("Fluff", 0)
+	if err != nil {
+		t.Fatal(err.Error())
+	}
+	assert.True(t, works.IsPaginated)
+	assert.Equal(t, 1, works.CurrentPage)
+	assert.Equal(t, 2, works.LastPage)
+
+	top = ""
+	works, err = client.GetTagWorks("Fluff", 1)
+	if err != nil {
+		t.Fatal(err.Error())
+	}
+	assert.False(t, works.IsPaginated)
+}
@@ -0,0 +1,107 @@
+package ao3
+
+import (
+	"bytes"
+	"errors"
+	"net/http"
+	"regexp"
+	"strings"
+	"github.com/PuerkitoBio/goquery"
+)
+
+// ValidationError lists the reasons AO3 gave for rejecting a submitted form.
+// It is wrapped by AO3Errors of the kind KindValidation, e.g.
+//
+//     var validation *ao3.ValidationError
+//     if errors.As(err, &validation) { ... }
+type ValidationError struct {
+	Messages []string
+
+	// Fields maps the fields of the submitted options, e.g. "Title" for
+	// WorkOptions.Title, to the messages about them, for forms whose messages
+	// can be attributed. Messages which cannot be attributed are only listed
+	// in Messages.
+	Fields map[string][]string
+}
+
+func (e *ValidationError) Error() string {
+	return strings.Join(e.Messages, "; ")
+}
+
+// fieldRule attributes the validation messages matching pattern to fields
+type fieldRule struct {
+	pattern *regexp.Regexp
+	fields  []string
+}
+
+// attributeFields fills in the Fields of the ValidationError wrapped by err, if
+// any, with the first rule matching each message
+func attributeFields(err *AO3Error, rules []fieldRule) *AO3Error {
+	var validation *ValidationError
+	if err == nil || !errors.As(err, &validation) {
+		return err
+	}
+
+	validation.Fields = map[string][]string{}
+	for _, message := range validation.Messages {
+		for _, rule := range rules {
+			if rule.pattern.MatchString(message) {
+				for _, field := range rule.fields {
+					validation.Fields[field] = append(validation.Fields[field], message)
+				}
+				break
+			}
+		}
+	}
+
+	return err
+}
+
+// validationError returns the errors AO3 shows above a rejected form or in an
+// error flash, or nil if the page has none
+func validationError(doc *goquery.Document, action string) *AO3Error {
+	messages := []string{}
+	doc.Find("#error li, div.error li").Each(func(_ int, node *goquery.Selection) {
+		if message := strings.TrimSpace(node.Text()); message != "" {
+			messages = append(messages, message)
+		}
+	})
+
+	if len(messages) == 0 {
+		doc.Find(".flash.error").Each(func(_ int, node *goquery.Selection) {
+			if message := strings.TrimSpace(node.Text()); message != "" {
+				messages = append(messages, message)
+			}
+		})
+	}
+
+	if len(messages) == 0 {
+		return nil
+	}
+
+	return WrapError(http.StatusUnprocessableEntity, &ValidationError{Messages: messages}, action+" was rejected").withKind(KindValidation)
+}
+
+// checkSubmission parses the page AO3 responded to a submitted form with,
+// returning the reasons the form was rejected if it was
+func checkSubmission(body []byte, action string) (*goquery.Document, *AO3Error) {
+	doc, err := goquery.NewDocumentFromReader(bytes.NewReader(body))
+	if err != nil {
+		return nil, WrapError(http.StatusUnprocessableEntity, err, "parsing response to "+action+" with goquery failed")
+	}
+
+	if ao3Err := validationError(doc, action); ao3Err != nil {
+		return nil, ao3Err
+	}
+
+	return doc, nil
+}
+
+// requireLogin returns an error if the client has not been authenticated, for
+// actions which AO3 only allows logged-in users to take
+func (client *AO3Client) requireLogin(action string) *AO3Error {
+	if client.Username() == "" {
+		return NewError(http.StatusUnauthorized, action+" requires the client to be authenticated").withKind(KindRestricted)
+	}
+	return nil
+}
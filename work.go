@@ -1,12 +1,13 @@
 package ao3
 
 import (
+	"bytes"
+	"context"
 	"github.com/PuerkitoBio/goquery"
 	"net/http"
 	"regexp"
 	"errors"
 	"strings"
-	"io/ioutil"
 )
 
 type Work struct {
@@ -14,6 +15,9 @@ type Work struct {
 	IsAnonymous bool
 	Authors     []Link
 
+	// IsRestricted reports whether the work is only visible to logged-in users
+	IsRestricted bool
+
 	RatingTags    []Link
 	FandomTags    []Link
 	WarningTags   []Link
@@ -21,6 +25,12 @@ type Work struct {
 	CharacterTags []Link
 	FreeformTags  []Link
 
+	// HasHiddenWarnings and HasHiddenFreeformTags report whether the
+	// logged-in user's preferences hide the work's warnings or additional
+	// tags behind a "Show" link, leaving WarningTags or FreeformTags empty
+	HasHiddenWarnings     bool
+	HasHiddenFreeformTags bool
+
 	IsSeries   bool
 	Series     Link
 	SeriesPart int
@@ -38,57 +48,64 @@ type Work struct {
 	Summary string
 
 	HTMLDownloadSlug string
+
+	// Diagnostics describes the fields which could not be parsed in lenient
+	// mode
+	Diagnostics []ParseDiagnostic
 }
 
-// DownloadWork downloads the work and returns a byte array
+// DownloadWork downloads the work and returns a byte array. The path is
+// relative to /downloads/ on the client's base URL, e.g. a Work's
+// HTMLDownloadSlug. Like GetWork, downloading a work only visible to logged-in
+// users requires the client to be authenticated.
 func (client *AO3Client) DownloadWork(path string) ([]byte, *AO3Error) {
-	endpoint := "/downloads/" + path
-
-	res, err := client.HttpClient.Get(baseURL + endpoint)
-	if err != nil {
-		return nil, WrapError(http.StatusServiceUnavailable, err, "downloading work returned an err")
-	}
-	defer res.Body.Close()
-	if res.StatusCode != http.StatusOK {
-		return nil, NewError(res.StatusCode, "downloading work returned a non-200 status code")
-	}
+	return client.DownloadWorkWithContext(context.Background(), path)
+}
 
-	bytes, err := ioutil.ReadAll(res.Body)
-	if err != nil {
-		return nil ,WrapError(http.StatusUnprocessableEntity, err, "unable to read bytes from response")
-	}
+// DownloadWorkWithContext is DownloadWork with a context which cancels the
+// download when it is done
+func (client *AO3Client) DownloadWorkWithContext(ctx context.Context, path string) ([]byte, *AO3Error) {
+	endpoint := "/downloads/" + path
 
-	return bytes, nil
+	return client.get(ctx, endpoint, client.CacheTTLs.Download, "downloading work")
 }
 
-// GetWork retrieves a work from its page
+// GetWork retrieves a work from its page. Works only visible to logged-in users
+// return an error matching ErrRestricted unless the client is authenticated.
 //
 // Endpoint: https://archiveofourown.org/works/[work]?view_adult=true
 func (client *AO3Client) GetWork(id string) (*Work, *AO3Error) {
+	return client.GetWorkWithContext(context.Background(), id)
+}
+
+// GetWorkWithContext is GetWork with a context which cancels the request when
+// it is done
+func (client *AO3Client) GetWorkWithContext(ctx context.Context, id string) (*Work, *AO3Error) {
 	authorSlugRegex := regexp.MustCompile("/users/(.+)/pseuds/.+")
 	seriesRegex := regexp.MustCompile("(?m)Part (.+) of the <a href=\".*/series/(.+)\">(.+)</a> series")
-	endpoint := "/works/" + id + "?view_adult=true"
+	endpoint := workEndpoint(id)
 
-	res, err := client.HttpClient.Get(baseURL + endpoint)
-	if err != nil {
-		return nil, WrapError(http.StatusServiceUnavailable, err, "fetching work returned an err")
-	}
-	defer res.Body.Close()
-	if res.StatusCode != http.StatusOK {
-		return nil, NewError(res.StatusCode, "fetching work returned a non-200 status code")
+	body, ao3Err := client.get(ctx, endpoint, client.CacheTTLs.Work, "fetching work")
+	if ao3Err != nil {
+		return nil, ao3Err
 	}
 
-	doc, err := goquery.NewDocumentFromReader(res.Body)
+	doc, err := goquery.NewDocumentFromReader(bytes.NewReader(body))
 	if err != nil {
 		return nil, WrapError(http.StatusUnprocessableEntity, err, "parsing work page with goquery failed")
 	}
 
 	var work Work
+	state := client.newParseState(client.endpointURL(endpoint), client.Lenient)
 
-	// Find the metadata box which contains the majority of information
+	// Find the metadata box which contains the majority of information. In
+	// lenient mode, a missing box leaves the metadata empty.
 	metaNodeMatches := doc.Find(".work.meta.group")
 	if len(metaNodeMatches.Nodes) != 1 {
-		return nil, NewError(http.StatusUnprocessableEntity, "unable to find metadata box on work page")
+		ao3Err := NewError(http.StatusUnprocessableEntity, "unable to find metadata box on work page")
+		if state.fail("Metadata", ".work.meta.group", doc.Find("#main"), ao3Err) {
+			return nil, ao3Err
+		}
 	}
 	metaNode := metaNodeMatches.First()
 
@@ -98,17 +115,24 @@ func (client *AO3Client) GetWork(id string) (*Work, *AO3Error) {
 	if len(ratingNodeMatches.Nodes) > 0 {
 		work.RatingTags, err = extractMetadataLinks(ratingNodeMatches)
 		if err != nil {
-			return nil, WrapError(http.StatusUnprocessableEntity, err, "unable to extract rating tags")
+			ao3Err := WrapError(http.StatusUnprocessableEntity, err, "unable to extract rating tags")
+			if state.fail("RatingTags", "dd.rating > ul > li > a", ratingNodeMatches, ao3Err) {
+				return nil, ao3Err
+			}
 		}
 	}
 
-	// Extract warnings
+	// Extract warnings, unless the user's preferences hide them
 	work.WarningTags = []Link{}
+	work.HasHiddenWarnings = len(metaNode.Find("dd.warning "+hiddenTagsSelector).Nodes) > 0
 	warningNodeMatches := metaNode.Find("dd.warning > ul > li > a")
-	if len(warningNodeMatches.Nodes) > 0 {
+	if len(warningNodeMatches.Nodes) > 0 && !work.HasHiddenWarnings {
 		work.WarningTags, err = extractMetadataLinks(warningNodeMatches)
 		if err != nil {
-			return nil, WrapError(http.StatusUnprocessableEntity, err, "unable to extract warning tags")
+			ao3Err := WrapError(http.StatusUnprocessableEntity, err, "unable to extract warning tags")
+			if state.fail("WarningTags", "dd.warning > ul > li > a", warningNodeMatches, ao3Err) {
+				return nil, ao3Err
+			}
 		}
 	}
 
@@ -118,7 +142,10 @@ func (client *AO3Client) GetWork(id string) (*Work, *AO3Error) {
 	if len(categoryNodeMatches.Nodes) > 0 {
 		work.CategoryTags, err = extractMetadataLinks(categoryNodeMatches)
 		if err != nil {
-			return nil, WrapError(http.StatusUnprocessableEntity, err, "unable to extract category tags")
+			ao3Err := WrapError(http.StatusUnprocessableEntity, err, "unable to extract category tags")
+			if state.fail("CategoryTags", "dd.category > ul > li > a", categoryNodeMatches, ao3Err) {
+				return nil, ao3Err
+			}
 		}
 	}
 
@@ -128,7 +155,10 @@ func (client *AO3Client) GetWork(id string) (*Work, *AO3Error) {
 	if len(characterNodeMatches.Nodes) > 0 {
 		work.CharacterTags, err = extractMetadataLinks(characterNodeMatches)
 		if err != nil {
-			return nil, WrapError(http.StatusUnprocessableEntity, err, "unable to extract character tags")
+			ao3Err := WrapError(http.StatusUnprocessableEntity, err, "unable to extract character tags")
+			if state.fail("CharacterTags", "dd.character > ul > li > a", characterNodeMatches, ao3Err) {
+				return nil, ao3Err
+			}
 		}
 	}
 
@@ -138,17 +168,24 @@ func (client *AO3Client) GetWork(id string) (*Work, *AO3Error) {
 	if len(fandomNodeMatches.Nodes) > 0 {
 		work.FandomTags, err = extractMetadataLinks(fandomNodeMatches)
 		if err != nil {
-			return nil, WrapError(http.StatusUnprocessableEntity, err, "unable to extract fandom tags")
+			ao3Err := WrapError(http.StatusUnprocessableEntity, err, "unable to extract fandom tags")
+			if state.fail("FandomTags", "dd.fandom > ul > li > a", fandomNodeMatches, ao3Err) {
+				return nil, ao3Err
+			}
 		}
 	}
 
-	// Extract freeforms
+	// Extract freeforms, unless the user's preferences hide them
 	work.FreeformTags = []Link{}
+	work.HasHiddenFreeformTags = len(metaNode.Find("dd.freeform "+hiddenTagsSelector).Nodes) > 0
 	freeformNodeMatches := metaNode.Find("dd.freeform > ul > li > a")
-	if len(freeformNodeMatches.Nodes) > 0 {
+	if len(freeformNodeMatches.Nodes) > 0 && !work.HasHiddenFreeformTags {
 		work.FreeformTags, err = extractMetadataLinks(freeformNodeMatches)
 		if err != nil {
-			return nil, WrapError(http.StatusUnprocessableEntity, err, "unable to extract freeform tags")
+			ao3Err := WrapError(http.StatusUnprocessableEntity, err, "unable to extract freeform tags")
+			if state.fail("FreeformTags", "dd.freeform > ul > li > a", freeformNodeMatches, ao3Err) {
+				return nil, ao3Err
+			}
 		}
 	}
 
@@ -162,22 +199,10 @@ func (client *AO3Client) GetWork(id string) (*Work, *AO3Error) {
 	seriesMatches := metaNode.Find("span.series > span.position")
 	work.IsSeries = false
 	if len(seriesMatches.Nodes) > 0 {
-		seriesHTML, err := seriesMatches.Html()
-		if err != nil {
-			return nil, WrapError(http.StatusUnprocessableEntity, err, "parsing work series with goquery failed")
-		}
-
-		seriesValueMatches := seriesRegex.FindStringSubmatch(seriesHTML)
-		if len(seriesValueMatches) != 4 {
-			return nil, NewError(http.StatusUnprocessableEntity,"parsing work series failed")
-		}
-
-		work.IsSeries = true
-		work.Series.Slug = seriesValueMatches[2]
-		work.Series.Text = seriesValueMatches[3]
-		work.SeriesPart, err = AtoiWithComma(seriesValueMatches[1])
-		if err != nil {
-			return nil, WrapError(http.StatusUnprocessableEntity, err, "parsing work series part failed")
+		if ao3Err := parseWorkSeries(&work, seriesMatches, seriesRegex); ao3Err != nil {
+			if state.fail("Series", "span.series > span.position", seriesMatches, ao3Err) {
+				return nil, ao3Err
+			}
 		}
 	}
 
@@ -198,7 +223,10 @@ func (client *AO3Client) GetWork(id string) (*Work, *AO3Error) {
 	if len(wordsNodeMatches.Nodes) > 0 {
 		work.Words, err = AtoiWithComma(wordsNodeMatches.First().Text())
 		if err != nil {
-			return nil, WrapError(http.StatusUnprocessableEntity, err, "parsing work words count failed")
+			ao3Err := WrapError(http.StatusUnprocessableEntity, err, "parsing work words count failed")
+			if state.fail("Words", "dd.words", wordsNodeMatches, ao3Err) {
+				return nil, ao3Err
+			}
 		}
 	}
 
@@ -213,7 +241,10 @@ func (client *AO3Client) GetWork(id string) (*Work, *AO3Error) {
 	if len(commentsNodeMatches.Nodes) > 0 {
 		work.Comments, err = AtoiWithComma(commentsNodeMatches.First().Text())
 		if err != nil {
-			return nil, WrapError(http.StatusUnprocessableEntity, err, "parsing work comments count failed")
+			ao3Err := WrapError(http.StatusUnprocessableEntity, err, "parsing work comments count failed")
+			if state.fail("Comments", "dd.comments", commentsNodeMatches, ao3Err) {
+				return nil, ao3Err
+			}
 		}
 	}
 
@@ -222,7 +253,10 @@ func (client *AO3Client) GetWork(id string) (*Work, *AO3Error) {
 	if len(kudosNodeMatches.Nodes) > 0 {
 		work.Kudos, err = AtoiWithComma(kudosNodeMatches.First().Text())
 		if err != nil {
-			return nil, WrapError(http.StatusUnprocessableEntity, err, "parsing work kudos count failed")
+			ao3Err := WrapError(http.StatusUnprocessableEntity, err, "parsing work kudos count failed")
+			if state.fail("Kudos", "dd.kudos", kudosNodeMatches, ao3Err) {
+				return nil, ao3Err
+			}
 		}
 	}
 
@@ -231,7 +265,10 @@ func (client *AO3Client) GetWork(id string) (*Work, *AO3Error) {
 	if len(bookmarksNodeMatches.Nodes) > 0 {
 		work.Bookmarks, err = AtoiWithComma(bookmarksNodeMatches.First().Text())
 		if err != nil {
-			return nil, WrapError(http.StatusUnprocessableEntity, err, "parsing work bookmarks count failed")
+			ao3Err := WrapError(http.StatusUnprocessableEntity, err, "parsing work bookmarks count failed")
+			if state.fail("Bookmarks", "dd.bookmarks", bookmarksNodeMatches, ao3Err) {
+				return nil, ao3Err
+			}
 		}
 	}
 
@@ -240,60 +277,54 @@ func (client *AO3Client) GetWork(id string) (*Work, *AO3Error) {
 	if len(hitsNodeMatches.Nodes) > 0 {
 		work.Hits, err = AtoiWithComma(hitsNodeMatches.First().Text())
 		if err != nil {
-			return nil, WrapError(http.StatusUnprocessableEntity, err, "parsing work hits count failed")
+			ao3Err := WrapError(http.StatusUnprocessableEntity, err, "parsing work hits count failed")
+			if state.fail("Hits", "dd.hits", hitsNodeMatches, ao3Err) {
+				return nil, ao3Err
+			}
 		}
 	}
 
 	// Extract title
 	titleMatches := doc.Find(".preface > h2.title")
 	if len(titleMatches.Nodes) != 1 {
-		return nil, NewError(http.StatusUnprocessableEntity, "unable to extract title node")
+		ao3Err := NewError(http.StatusUnprocessableEntity, "unable to extract title node")
+		if state.fail("Title", ".preface > h2.title", doc.Find(".preface"), ao3Err) {
+			return nil, ao3Err
+		}
+	} else {
+		work.Title = strings.TrimSpace(titleMatches.First().Text())
+		work.IsRestricted = hasRestrictedIcon(titleMatches.First())
 	}
 
-	work.Title = strings.TrimSpace(titleMatches.First().Text())
-
 	// Extract summary
 	summaryMatches := doc.Find(".summary > blockquote.userstuff")
 	if len(summaryMatches.Nodes) > 0 {
 		summaryHtml, err := summaryMatches.First().Html()
 		if err != nil {
-			return nil, WrapError(http.StatusUnprocessableEntity, err, "unable to extract summary HTML")
+			ao3Err := WrapError(http.StatusUnprocessableEntity, err, "unable to extract summary HTML")
+			if state.fail("Summary", ".summary > blockquote.userstuff", summaryMatches, ao3Err) {
+				return nil, ao3Err
+			}
+		} else {
+			work.Summary = client.HtmlSanitizer.Sanitize(strings.TrimSpace(summaryHtml))
 		}
-
-		work.Summary = client.HtmlSanitizer.Sanitize(strings.TrimSpace(summaryHtml))
 	}
 
 	// Extract author(s), handling the case where the author is anonymous
 	authorNodeMatches := doc.Find("#workskin > div.preface > h3.byline.heading")
 	if len(authorNodeMatches.Nodes) != 1 {
-		return nil, WrapError(http.StatusUnprocessableEntity, err, "unable to extract author node")
-	}
-
-	if strings.TrimSpace(authorNodeMatches.First().Text()) == "Anonymous" {
-		work.IsAnonymous = true
-	} else {
-		work.IsAnonymous = false
-
-		work.Authors = []Link{}
-		authorMatches := authorNodeMatches.First().Find("a")
-		for i := range authorMatches.Nodes {
-			authorNode := authorMatches.Eq(i)
-
-			authorUrl, ok := authorNode.Attr("href")
-			if !ok {
-				return nil, NewError(http.StatusUnprocessableEntity, "extracting work author link failed")
-			}
-
-			authorSlugMatches := authorSlugRegex.FindStringSubmatch(authorUrl)
-			if len(authorSlugMatches) != 2 {
-				return nil, NewError(http.StatusUnprocessableEntity, "parsing work author link failed")
-			}
-
-			work.Authors = append(work.Authors, Link{Text: authorNode.Text(), Slug: authorSlugMatches[1]})
+		ao3Err := NewError(http.StatusUnprocessableEntity, "unable to extract author node")
+		if state.fail("Authors", "#workskin > div.preface > h3.byline.heading", doc.Find("#workskin > div.preface"), ao3Err) {
+			return nil, ao3Err
+		}
+	} else if ao3Err := parseWorkAuthors(&work, authorNodeMatches.First(), authorSlugRegex); ao3Err != nil {
+		if state.fail("Authors", "#workskin > div.preface > h3.byline.heading a", authorNodeMatches, ao3Err) {
+			return nil, ao3Err
 		}
 	}
 
 	// Extract the HTML download slug
+	downloadDiagnosed := false
 	downloadMatches := doc.Find("li.download > ul > li > a")
 	for i := range downloadMatches.Nodes {
 		downloadNode := downloadMatches.Eq(i)
@@ -301,23 +332,94 @@ func (client *AO3Client) GetWork(id string) (*Work, *AO3Error) {
 			continue
 		}
 
-		// A regex would be unnecessary when we can just trim "/downloads" from
-		// the start of the URL
+		// A regex would be unnecessary when we can just trim "/downloads/" from
+		// the start of the URL once it is made relative to the base URL
 		downloadLink, ok := downloadNode.Attr("href")
 		if !ok {
-			return  nil, NewError(http.StatusUnprocessableEntity, "retrieving work download link failed")
+			ao3Err := NewError(http.StatusUnprocessableEntity, "retrieving work download link failed")
+			if state.fail("HTMLDownloadSlug", "li.download > ul > li > a", downloadNode, ao3Err) {
+				return nil, ao3Err
+			}
+			downloadDiagnosed = true
+			continue
 		}
 
-		work.HTMLDownloadSlug = strings.TrimLeft(downloadLink, "/downloads/")
+		work.HTMLDownloadSlug = strings.TrimPrefix(client.relativeLink(downloadLink), "/downloads/")
 	}
 
-	if work.HTMLDownloadSlug == "" {
-		return nil, NewError(http.StatusUnprocessableEntity, "unable to find work download link")
+	if work.HTMLDownloadSlug == "" && !downloadDiagnosed {
+		ao3Err := NewError(http.StatusUnprocessableEntity, "unable to find work download link")
+		if state.fail("HTMLDownloadSlug", "li.download > ul > li > a", doc.Find("li.download"), ao3Err) {
+			return nil, ao3Err
+		}
 	}
 
+	work.Diagnostics = state.diagnostics
+
 	return &work, nil
 }
 
+// hasRestrictedIcon reports whether the heading of a work contains the padlock
+// AO3 shows next to the titles of works only visible to logged-in users
+func hasRestrictedIcon(headingNode *goquery.Selection) bool {
+	return len(headingNode.Find(`img[title="Restricted"]`).Nodes) > 0
+}
+
+// parseWorkSeries extracts the series, title and part of a work in a series
+func parseWorkSeries(work *Work, seriesNode *goquery.Selection, seriesRegex *regexp.Regexp) *AO3Error {
+	seriesHTML, err := seriesNode.Html()
+	if err != nil {
+		return WrapError(http.StatusUnprocessableEntity, err, "parsing work series with goquery failed")
+	}
+
+	seriesValueMatches := seriesRegex.FindStringSubmatch(seriesHTML)
+	if len(seriesValueMatches) != 4 {
+		return NewError(http.StatusUnprocessableEntity, "parsing work series failed")
+	}
+
+	seriesPart, err := AtoiWithComma(seriesValueMatches[1])
+	if err != nil {
+		return WrapError(http.StatusUnprocessableEntity, err, "parsing work series part failed")
+	}
+
+	work.IsSeries = true
+	work.Series.Slug = seriesValueMatches[2]
+	work.Series.Text = seriesValueMatches[3]
+	work.SeriesPart = seriesPart
+
+	return nil
+}
+
+// parseWorkAuthors extracts the authors from the byline of a work
+func parseWorkAuthors(work *Work, bylineNode *goquery.Selection, authorSlugRegex *regexp.Regexp) *AO3Error {
+	if strings.TrimSpace(bylineNode.Text()) == "Anonymous" {
+		work.IsAnonymous = true
+		return nil
+	}
+
+	work.IsAnonymous = false
+
+	work.Authors = []Link{}
+	authorMatches := bylineNode.Find("a")
+	for i := range authorMatches.Nodes {
+		authorNode := authorMatches.Eq(i)
+
+		authorUrl, ok := authorNode.Attr("href")
+		if !ok {
+			return NewError(http.StatusUnprocessableEntity, "extracting work author link failed")
+		}
+
+		authorSlugMatches := authorSlugRegex.FindStringSubmatch(authorUrl)
+		if len(authorSlugMatches) != 2 {
+			return NewError(http.StatusUnprocessableEntity, "parsing work author link failed")
+		}
+
+		work.Authors = append(work.Authors, Link{Text: authorNode.Text(), Slug: authorSlugMatches[1]})
+	}
+
+	return nil
+}
+
 func extractMetadataLinks(node *goquery.Selection) ([]Link, error) {
 	var tags []Link
 
@@ -341,3 +443,13 @@ func extractMetadataLinks(node *goquery.Selection) ([]Link, error) {
 
 	return tags, nil
 }
+
+// workEndpoint returns the endpoint GetWork fetches a work from
+func workEndpoint(id string) string {
+	return "/works/" + id + "?view_adult=true"
+}
+
+// workEditEndpoint returns the endpoint of a work's edit form
+func workEditEndpoint(id string) string {
+	return "/works/" + id + "/edit"
+}
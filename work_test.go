@@ -1,6 +1,8 @@
 package ao3
 
 import (
+	"errors"
+	"net/http"
 	"testing"
 	"github.com/stretchr/testify/assert"
 )
@@ -9,10 +11,7 @@ import (
 func TestDownloadWork(t *testing.T) {
 	const path = "Co/CodenameCarrot/5191202/A%20Complete%20Guide%20to%20Limited.html?updated_at=1511633931"
 
-	client, err := InitAO3Client(nil, AO3Policy)
-	if err != nil {
-		t.Fatal(err.Error())
-	}
+	client := newFixtureClient(t)
 
 	bytes, err := client.DownloadWork(path)
 	if err != nil {
@@ -74,10 +73,7 @@ func TestGetWork(t *testing.T) {
 	}
 
 	// Fetch the work
-	client, err := InitAO3Client(nil, AO3Policy)
-	if err != nil {
-		t.Fatal(err.Error())
-	}
+	client := newFixtureClient(t)
 
 	work, err := client.GetWork(workId)
 	if err != nil {
@@ -152,10 +148,7 @@ func TestGetWorkExtractsSeries(t *testing.T) {
 		SeriesPart: 1,
 	}
 
-	client, err := InitAO3Client(nil, AO3Policy)
-	if err != nil {
-		t.Fatal(err.Error())
-	}
+	client := newFixtureClient(t)
 
 	work, err := client.GetWork(workId)
 	if err != nil {
@@ -172,10 +165,7 @@ func TestGetWorkExtractsSeries(t *testing.T) {
 func TestGetWorkDetectsAnonymousAuthor(t *testing.T) {
 	const workId = "62903"
 
-	client, err := InitAO3Client(nil, AO3Policy)
-	if err != nil {
-		t.Fatal(err.Error())
-	}
+	client := newFixtureClient(t)
 
 	work, err := client.GetWork(workId)
 	if err != nil {
@@ -188,10 +178,7 @@ func TestGetWorkDetectsAnonymousAuthor(t *testing.T) {
 func TestGetWorkExtractsMultipleAuthors(t *testing.T) {
 	const workId = "4664616"
 
-	client, err := InitAO3Client(nil, AO3Policy)
-	if err != nil {
-		t.Fatal(err.Error())
-	}
+	client := newFixtureClient(t)
 
 	work, err := client.GetWork(workId)
 	if err != nil {
@@ -211,4 +198,95 @@ func TestGetWorkExtractsMultipleAuthors(t *testing.T) {
 
 	assert.Equal(t, false, work.IsAnonymous)
 	assert.Equal(t, expectedAuthors, work.Authors)
-}
\ No newline at end of file
+}
+
+const testRestrictedWorkPage = `<html><body class="logged-in"><div id="main">
+<dl class="work meta group"><dt class="language">Language:</dt><dd class="language">English</dd></dl>
+<ul class="work navigation actions"><li class="download"><ul class="expandable secondary"><li><a href="/downloads/1/Locked.html?updated_at=1">HTML</a></li></ul></li></ul>
+<div id="workskin"><div class="preface group">
+<h2 class="title heading"><img alt="(Restricted)" title="Restricted" src="/images/lockblue.png" width="15" height="15" /> Locked</h2>
+<h3 class="byline heading"><a rel="author" href="/users/reader/pseuds/reader">reader</a></h3>
+</div></div></div></body></html>`
+
+// TestGetWorkRestricted ensures works only visible to logged-in users return
+// ErrRestricted to anonymous clients and are flagged once logged in
+func TestGetWorkRestricted(t *testing.T) {
+	client, server := newTestClient(t, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if session, _ := r.Cookie("_otwarchive_session"); session == nil || session.Value != "reader" {
+			if r.URL.Path == "/users/login" {
+				w.Write([]byte(`<body class="logged-out"><div class="flash notice">This work is only available to registered users of the Archive.</div></body>`))
+				return
+			}
+			http.Redirect(w, r, "/users/login?restricted=true", http.StatusFound)
+			return
+		}
+
+		switch r.URL.Path {
+		case "/works/1":
+			w.Write([]byte(testRestrictedWorkPage))
+		case "/downloads/1/Locked.html":
+			w.Write([]byte("<!DOCTYPE html><html><body>Locked</body></html>"))
+		default:
+			http.NotFound(w, r)
+		}
+	}))
+	defer server.Close()
+
+	_, err := client.GetWork("1")
+	if assert.NotNil(t, err) {
+		assert.True(t, errors.Is(err, ErrRestricted))
+	}
+
+	_, err = client.DownloadWork("1/Locked.html?updated_at=1")
+	if assert.NotNil(t, err) {
+		assert.True(t, errors.Is(err, ErrRestricted))
+	}
+
+	importTestSession(t, client, "reader")
+
+	work, err := client.GetWork("1")
+	if err != nil {
+		t.Fatal(err.Error())
+	}
+	assert.Equal(t, "Locked", work.Title)
+	assert.True(t, work.IsRestricted)
+
+	body, err := client.DownloadWork(work.HTMLDownloadSlug)
+	if err != nil {
+		t.Fatal(err.Error())
+	}
+	assert.Contains(t, string(body), "Locked")
+}
+
+// TestGetWorkWithHiddenTags ensures warnings and additional tags hidden by the
+// user's preferences are reported instead of failing the work
+func TestGetWorkWithHiddenTags(t *testing.T) {
+	client, server := newTestClient(t, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/works/5" {
+			http.NotFound(w, r)
+			return
+		}
+		w.Write([]byte(`<html><body class="logged-in"><div id="main">
+<dl class="work meta group">
+<dd class="warning tags"><ul class="commas"><li><a class="warnings" data-remote="true" href="/tags/show_hidden?creation_id=5&amp;creation_type=Work&amp;tag_type=warnings">Show warnings</a></li></ul></dd>
+<dd class="character tags"><ul class="commas"><li><a class="tag" href="/tags/A/works">A</a></li></ul></dd>
+<dd class="freeform tags"><ul class="commas"><li><a class="freeforms" data-remote="true" href="/tags/show_hidden?creation_id=5&amp;creation_type=Work&amp;tag_type=freeforms">Show additional tags</a></li></ul></dd>
+<dd class="language">English</dd>
+</dl>
+<ul class="work navigation actions"><li class="download"><ul class="expandable secondary"><li><a href="/downloads/5/Hidden.html?updated_at=1">HTML</a></li></ul></li></ul>
+<div id="workskin"><div class="preface group"><h2 class="title heading">Hidden</h2><h3 class="byline heading"><a rel="author" href="/users/author/pseuds/author">author</a></h3></div></div>
+</div></body></html>`))
+	}))
+	defer server.Close()
+
+	work, err := client.GetWork("5")
+	if err != nil {
+		t.Fatal(err.Error())
+	}
+
+	assert.True(t, work.HasHiddenWarnings)
+	assert.True(t, work.HasHiddenFreeformTags)
+	assert.Empty(t, work.WarningTags)
+	assert.Empty(t, work.FreeformTags)
+	assert.Equal(t, []Link{{Text: "A", Slug: "A"}}, work.CharacterTags)
+}